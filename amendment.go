@@ -0,0 +1,247 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"fmt"
+	"github.com/AletheiaWareLLC/bcgo"
+	"github.com/golang/protobuf/proto"
+	"sort"
+	"sync"
+)
+
+// Contributions are checked against the canvas' bounds as of the block they were mined in, amendments take effect from the block they were mined in.
+// Only newly mined contributions are checked, so those which fall outside the bounds after a crop are kept, but are not drawn unless a later amendment expands the canvas to include them again.
+
+const (
+	ERROR_AMENDMENT_INVALID = "Amendment invalid: %s"
+)
+
+func UnmarshalAmendment(data []byte) (*Amendment, error) {
+	amendment := &Amendment{}
+	if err := proto.Unmarshal(data, amendment); err != nil {
+		return nil, err
+	}
+	return amendment, nil
+}
+
+// CreateAmendment creates an amendment which resizes the canvas, expanding or cropping from the far edges.
+func CreateAmendment(width, height, depth uint32, reason string) *Amendment {
+	return &Amendment{
+		Width:  width,
+		Height: height,
+		Depth:  depth,
+		Reason: reason,
+	}
+}
+
+func CreateAmendmentRecord(alias string, key *rsa.PrivateKey, amendment *Amendment) (*bcgo.Record, error) {
+	data, err := proto.Marshal(amendment)
+	if err != nil {
+		return nil, err
+	}
+	return CreateRecord(alias, key, data)
+}
+
+// ValidateAmendment returns an error if the amendment would leave the canvas without area.
+func ValidateAmendment(amendment *Amendment) error {
+	if amendment.Width == 0 || amendment.Height == 0 {
+		return fmt.Errorf(ERROR_AMENDMENT_INVALID, "width and height must be positive")
+	}
+	return nil
+}
+
+// AmendCanvas returns a copy of the canvas resized by the amendment.
+func AmendCanvas(canvas *Canvas, amendment *Amendment) *Canvas {
+	amended := proto.Clone(canvas).(*Canvas)
+	amended.Width = amendment.Width
+	amended.Height = amendment.Height
+	amended.Depth = amendment.Depth
+	return amended
+}
+
+// AmendmentEntry is an amendment along with the record which made it.
+type AmendmentEntry struct {
+	Entry *bcgo.BlockEntry
+	// Timestamp of the block containing the amendment
+	BlockTimestamp uint64
+	Amendment      *Amendment
+}
+
+// Amendments holds the owner's amendments to a canvas in the order they were mined.
+type Amendments struct {
+	sync.RWMutex
+	Canvas  *Canvas
+	Channel *bcgo.Channel
+	Entries []*AmendmentEntry
+}
+
+// NewAmendments creates an empty set of amendments to the canvas, as it was created, read from the channel.
+func NewAmendments(canvas *Canvas, channel *bcgo.Channel) *Amendments {
+	return &Amendments{
+		Canvas:  proto.Clone(canvas).(*Canvas),
+		Channel: channel,
+	}
+}
+
+// Update reads the amendments from the channel, starting at the given head, ignoring any not made by the owner or which are invalid.
+func (a *Amendments) Update(head []byte, cache bcgo.Cache, network bcgo.Network, verifier *Verifier) error {
+	var entries []*AmendmentEntry
+	if err := bcgo.Iterate(a.Channel.Name, head, nil, cache, network, func(hash []byte, block *bcgo.Block) error {
+		for _, entry := range block.Entry {
+			if ok, err := verifier.Accept(entry); err != nil {
+				return err
+			} else if !ok {
+				continue
+			}
+			if !IsOwner(a.Canvas, entry.Record.Creator) {
+				continue
+			}
+			amendment, err := UnmarshalAmendment(entry.Record.Payload)
+			if err != nil || ValidateAmendment(amendment) != nil {
+				continue
+			}
+			entries = append(entries, &AmendmentEntry{
+				Entry:          entry,
+				BlockTimestamp: block.Timestamp,
+				Amendment:      amendment,
+			})
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	// Order by block as record timestamps are set by the owner
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.BlockTimestamp != b.BlockTimestamp {
+			return a.BlockTimestamp < b.BlockTimestamp
+		}
+		return bytes.Compare(a.Entry.RecordHash, b.Entry.RecordHash) < 0
+	})
+	a.Lock()
+	a.Entries = entries
+	a.Unlock()
+	return nil
+}
+
+// GetCanvas returns the canvas as amended by the blocks mined at or before the block timestamp.
+func (a *Amendments) GetCanvas(blockTimestamp uint64) *Canvas {
+	a.RLock()
+	defer a.RUnlock()
+	var latest *Amendment
+	for _, e := range a.Entries {
+		if e.BlockTimestamp > blockTimestamp {
+			break
+		}
+		latest = e.Amendment
+	}
+	if latest == nil {
+		return a.Canvas
+	}
+	return AmendCanvas(a.Canvas, latest)
+}
+
+// GetAmendedCanvas returns the canvas as amended at the block timestamp, or the canvas itself if there are no amendments.
+func GetAmendedCanvas(amendments *Amendments, canvas *Canvas, blockTimestamp uint64) *Canvas {
+	if amendments == nil {
+		return canvas
+	}
+	return amendments.GetCanvas(blockTimestamp)
+}
+
+// AmendmentValidator ensures every amendment in a channel leaves the canvas with area, the channel's RoleValidator ensures they were made by the owner.
+type AmendmentValidator struct {
+	Canvas *Canvas
+}
+
+func (v *AmendmentValidator) Validate(channel *bcgo.Channel, cache bcgo.Cache, network bcgo.Network, hash []byte, block *bcgo.Block) error {
+	return bcgo.Iterate(channel.Name, hash, block, cache, network, func(h []byte, b *bcgo.Block) error {
+		for _, entry := range b.Entry {
+			amendment, err := UnmarshalAmendment(entry.Record.Payload)
+			if err != nil {
+				return err
+			}
+			if err := ValidateAmendment(amendment); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// BoundsValidator ensures every newly mined vote in a channel is within the canvas' bounds as amended when its block was mined.
+// Votes already in the chain are not checked again, so a later crop cannot invalidate them.
+type BoundsValidator struct {
+	Canvas     *Canvas
+	Amendments *Amendments
+}
+
+func (v *BoundsValidator) Validate(channel *bcgo.Channel, cache bcgo.Cache, network bcgo.Network, hash []byte, block *bcgo.Block) error {
+	if v.Amendments != nil {
+		if err := v.Amendments.Update(v.Amendments.Channel.Head, cache, network, nil); err != nil {
+			return err
+		}
+	}
+	err := bcgo.Iterate(channel.Name, hash, block, cache, network, func(h []byte, b *bcgo.Block) error {
+		if bytes.Equal(h, channel.Head) {
+			// Reached the blocks already validated
+			return bcgo.StopIterationError{}
+		}
+		canvas := GetAmendedCanvas(v.Amendments, v.Canvas, b.Timestamp)
+		for _, entry := range b.Entry {
+			vote, err := UnmarshalVote(entry.Record.Payload)
+			if err != nil {
+				return err
+			}
+			for _, e := range ExpandVote(vote) {
+				if e.Location == nil {
+					continue
+				}
+				if err := CheckBounds(canvas, e.Location); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if _, ok := err.(bcgo.StopIterationError); ok {
+		return nil
+	}
+	return err
+}
+
+// Amend writes an amendment resizing the canvas, only the owner may amend a canvas.
+func (m *BaseModel) Amend(width, height, depth uint32, reason string) error {
+	if m.Amendments == nil {
+		return fmt.Errorf(ERROR_AMENDMENT_INVALID, "canvas cannot be amended")
+	}
+	if !IsOwner(m.Amendments.Canvas, m.Node.Alias) {
+		return fmt.Errorf(ERROR_NOT_OWNER, m.Node.Alias)
+	}
+	amendment := CreateAmendment(width, height, depth, reason)
+	if err := ValidateAmendment(amendment); err != nil {
+		return err
+	}
+	record, err := CreateAmendmentRecord(m.Node.Alias, m.Node.Key, amendment)
+	if err != nil {
+		return err
+	}
+	return m.WriteRecord(m.Amendments.Channel, record)
+}
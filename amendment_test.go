@@ -0,0 +1,134 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo_test
+
+import (
+	"github.com/AletheiaWareLLC/bcgo"
+	"github.com/AletheiaWareLLC/colourgo"
+	"github.com/AletheiaWareLLC/cryptogo"
+	"github.com/AletheiaWareLLC/testinggo"
+	"github.com/golang/protobuf/proto"
+	"testing"
+)
+
+func TestAmendments(t *testing.T) {
+	canvas, err := colourgo.CreateCanvas("Test", 4, 4, 1, colourgo.Mode_MARKET)
+	testinggo.AssertNoError(t, err)
+	canvas.Faucet = 10
+	canvas.FaucetRecipient = []string{"Alice", "Bob"}
+	amendments := colourgo.NewAmendments(canvas, nil)
+	amendments.Entries = []*colourgo.AmendmentEntry{
+		{
+			Entry:          &bcgo.BlockEntry{Record: &bcgo.Record{Timestamp: 10}},
+			BlockTimestamp: 10,
+			Amendment:      colourgo.CreateAmendment(8, 8, 1, "Expand"),
+		},
+		{
+			Entry:          &bcgo.BlockEntry{Record: &bcgo.Record{Timestamp: 20}},
+			BlockTimestamp: 20,
+			Amendment:      colourgo.CreateAmendment(2, 2, 1, "Crop"),
+		},
+	}
+	outside := &colourgo.Location{X: 5, Y: 5}
+	for name, test := range map[string]struct {
+		timestamp uint64
+		width     uint32
+		inBounds  bool
+	}{
+		"Original": {5, 4, false},
+		"Expanded": {15, 8, true},
+		"Cropped":  {25, 2, false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			c := amendments.GetCanvas(test.timestamp)
+			if c.Width != test.width {
+				t.Errorf("Expected width %d, got %d", test.width, c.Width)
+			}
+			if colourgo.IsInBounds(c, outside) != test.inBounds {
+				t.Errorf("Expected in bounds %t", test.inBounds)
+			}
+		})
+	}
+
+	// Purchases are checked against the bounds when they were made
+	ledger := colourgo.NewLedger(canvas)
+	ledger.Amendments = amendments
+	purchase := colourgo.CreatePurchase(0, 5, 5, 0, 255, 0, 0, 255, 1, 0)
+	testinggo.AssertError(t, "Location out of bounds: 0,5,5,0", ledger.Apply(makeLedgerEntry("Alice", 5, nil, purchase)))
+	testinggo.AssertNoError(t, ledger.Apply(makeLedgerEntry("Alice", 15, nil, purchase)))
+	testinggo.AssertError(t, "Location out of bounds: 0,5,5,0", ledger.Apply(makeLedgerEntry("Bob", 25, nil, colourgo.CreatePurchase(0, 5, 5, 0, 0, 0, 255, 255, 2, 0))))
+}
+
+func TestValidateAmendment(t *testing.T) {
+	testinggo.AssertNoError(t, colourgo.ValidateAmendment(colourgo.CreateAmendment(1, 1, 0, "")))
+	testinggo.AssertError(t, "Amendment invalid: width and height must be positive", colourgo.ValidateAmendment(colourgo.CreateAmendment(0, 1, 1, "")))
+}
+
+func makeBlock(t *testing.T, cache bcgo.Cache, channel string, timestamp uint64, previous []byte, creator string, message proto.Message) ([]byte, *bcgo.Block) {
+	t.Helper()
+	data, err := proto.Marshal(message)
+	testinggo.AssertNoError(t, err)
+	block := &bcgo.Block{
+		Timestamp:   timestamp,
+		ChannelName: channel,
+		Length:      1,
+		Previous:    previous,
+		Entry: []*bcgo.BlockEntry{
+			&bcgo.BlockEntry{
+				RecordHash: []byte{byte(timestamp)},
+				Record: &bcgo.Record{
+					// Backdated, only the block timestamp counts
+					Timestamp: 1,
+					Creator:   creator,
+					Payload:   data,
+				},
+			},
+		},
+	}
+	hash, err := cryptogo.HashProtobuf(block)
+	testinggo.AssertNoError(t, err)
+	testinggo.AssertNoError(t, cache.PutBlock(hash, block))
+	return hash, block
+}
+
+func TestBoundsValidator(t *testing.T) {
+	canvas, err := colourgo.CreateCanvas("Test", 4, 4, 1, colourgo.Mode_FREE_FOR_ALL)
+	testinggo.AssertNoError(t, err)
+	canvas.Owner = "Alice"
+	cache := bcgo.NewMemoryCache(10)
+	amendments := &bcgo.Channel{
+		Name: "TEST_AMENDMENT",
+	}
+	amendments.Head, _ = makeBlock(t, cache, amendments.Name, 20, nil, "Alice", colourgo.CreateAmendment(2, 2, 1, "Crop"))
+	votes := &bcgo.Channel{
+		Name: "TEST_VOTE",
+	}
+	votes.Head, _ = makeBlock(t, cache, votes.Name, 10, nil, "Bob", colourgo.CreateVote(0, 3, 3, 0, 255, 0, 0, 255))
+	validator := &colourgo.BoundsValidator{
+		Canvas:     canvas,
+		Amendments: colourgo.NewAmendments(canvas, amendments),
+	}
+	t.Run("InBounds", func(t *testing.T) {
+		// The earlier vote outside the cropped canvas is not checked again
+		hash, block := makeBlock(t, cache, votes.Name, 30, votes.Head, "Bob", colourgo.CreateVote(0, 1, 1, 0, 255, 0, 0, 255))
+		testinggo.AssertNoError(t, validator.Validate(votes, cache, nil, hash, block))
+	})
+	t.Run("OutOfBounds", func(t *testing.T) {
+		hash, block := makeBlock(t, cache, votes.Name, 40, votes.Head, "Bob", colourgo.CreateVote(0, 3, 3, 0, 255, 0, 0, 255))
+		testinggo.AssertError(t, "Location out of bounds: 0,3,3,0", validator.Validate(votes, cache, nil, hash, block))
+	})
+}
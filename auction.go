@@ -0,0 +1,607 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/AletheiaWareLLC/bcgo"
+	"github.com/AletheiaWareLLC/cryptogo"
+	"sort"
+	"time"
+)
+
+const (
+	ERROR_AUCTION_NOT_CONFIGURED = "Auction not configured"
+	ERROR_BID_OUTSIDE_WINDOW     = "Bid outside window: %s"
+	ERROR_COMMITMENT_UNSEALED    = "Commitment reveals bid: %s"
+	ERROR_COMMITMENT_DUPLICATE   = "Commitment already made: %s"
+	ERROR_REVEAL_UNMATCHED       = "Reveal does not match a commitment: %s"
+	ERROR_REVEAL_DUPLICATE       = "Commitment already revealed: %s"
+
+	NONCE_SIZE = 32
+)
+
+type AuctionPhase int
+
+const (
+	// Before the first auction starts
+	AUCTION_PENDING AuctionPhase = iota
+	// Commitments are accepted
+	AUCTION_BIDDING
+	// Commitments are revealed
+	AUCTION_REVEALING
+)
+
+func getAuctionDurations(canvas *Canvas) (uint64, uint64, error) {
+	if canvas.BiddingDuration == 0 || canvas.RevealDuration == 0 {
+		return 0, 0, errors.New(ERROR_AUCTION_NOT_CONFIGURED)
+	}
+	return uint64(canvas.BiddingDuration) * uint64(time.Second), uint64(canvas.RevealDuration) * uint64(time.Second), nil
+}
+
+// GetAuctionRound returns the auction round and phase at the timestamp.
+// Auctions repeat from the canvas' AuctionStart, each with a bidding window of BiddingDuration seconds followed by a reveal window of RevealDuration seconds.
+func GetAuctionRound(canvas *Canvas, timestamp uint64) (uint64, AuctionPhase, error) {
+	bidding, reveal, err := getAuctionDurations(canvas)
+	if err != nil {
+		return 0, AUCTION_PENDING, err
+	}
+	if timestamp < canvas.AuctionStart {
+		return 0, AUCTION_PENDING, nil
+	}
+	elapsed := timestamp - canvas.AuctionStart
+	round := elapsed / (bidding + reveal)
+	if elapsed%(bidding+reveal) < bidding {
+		return round, AUCTION_BIDDING, nil
+	}
+	return round, AUCTION_REVEALING, nil
+}
+
+// GetAuctionWindow returns the timestamps at which the round's bidding starts, revealing starts, and the round ends.
+func GetAuctionWindow(canvas *Canvas, round uint64) (uint64, uint64, uint64, error) {
+	bidding, reveal, err := getAuctionDurations(canvas)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	start := canvas.AuctionStart + round*(bidding+reveal)
+	return start, start + bidding, start + bidding + reveal, nil
+}
+
+// GetCommitment returns the hash which seals the purchase's location, colour, price and nonce.
+func GetCommitment(p *Purchase) []byte {
+	var buffer bytes.Buffer
+	l, c := p.GetLocation(), p.GetColour()
+	for _, v := range []uint32{l.GetW(), l.GetX(), l.GetY(), l.GetZ(), c.GetRed(), c.GetGreen(), c.GetBlue(), c.GetAlpha(), p.Price} {
+		binary.Write(&buffer, binary.BigEndian, v)
+	}
+	buffer.Write(p.Nonce)
+	return cryptogo.Hash(buffer.Bytes())
+}
+
+// CreateBid returns a sealed commitment to purchase the location and the reveal to be written once bidding closes.
+func CreateBid(location *Location, colour *Colour, price uint32) (*Purchase, *Purchase, error) {
+	nonce := make([]byte, NONCE_SIZE)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, err
+	}
+	reveal := &Purchase{
+		Colour:   colour,
+		Location: location,
+		Price:    price,
+		Nonce:    nonce,
+	}
+	commit := &Purchase{
+		Commitment: GetCommitment(reveal),
+	}
+	return commit, reveal, nil
+}
+
+// Bid is a commitment or reveal read from an auction's purchase channel.
+type Bid struct {
+	Entry          *bcgo.BlockEntry
+	Height         uint64
+	BlockTimestamp uint64
+	Purchase       *Purchase
+}
+
+func (b *Bid) IsCommitment() bool {
+	return len(b.Purchase.Commitment) > 0
+}
+
+// ReadBids returns the bids in the channel from the given block back.
+func ReadBids(name string, hash []byte, block *bcgo.Block, cache bcgo.Cache, network bcgo.Network, verifier *Verifier) ([]*Bid, error) {
+	var bids []*Bid
+	if err := bcgo.Iterate(name, hash, block, cache, network, func(h []byte, b *bcgo.Block) error {
+		for _, entry := range b.Entry {
+			if ok, err := verifier.Accept(entry); err != nil {
+				return err
+			} else if !ok {
+				continue
+			}
+			purchase, err := UnmarshalPurchase(entry.Record.Payload)
+			if err != nil {
+				return err
+			}
+			bids = append(bids, &Bid{
+				Entry:          entry,
+				Height:         b.Length,
+				BlockTimestamp: b.Timestamp,
+				Purchase:       purchase,
+			})
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return bids, nil
+}
+
+// Reveal is a valid reveal along with the commitment it opens.
+type Reveal struct {
+	*Bid
+	Commitment *Bid
+	Round      uint64
+}
+
+// MatchBids checks each bid against the auction rules and returns the valid reveals, the callback is called for each invalid bid and matching stops if it returns an error.
+// A commitment must be written during a bidding window and mined before that window closes, so it is on chain before any reveals.
+// A reveal must be written during the following reveal window and mined within it by the creator of the commitment, and each commitment can only be revealed once.
+func MatchBids(canvas *Canvas, amendments *Amendments, bids []*Bid, callback func(*Bid, error) error) ([]*Reveal, error) {
+	sort.Slice(bids, func(i, j int) bool {
+		a, b := bids[i].Entry, bids[j].Entry
+		if a.Record.Timestamp != b.Record.Timestamp {
+			return a.Record.Timestamp < b.Record.Timestamp
+		}
+		return bytes.Compare(a.RecordHash, b.RecordHash) < 0
+	})
+	type commitment struct {
+		bid      *Bid
+		round    uint64
+		revealed bool
+	}
+	commitments := make(map[string]*commitment)
+	var reveals []*Reveal
+	for _, b := range bids {
+		id := base64.RawURLEncoding.EncodeToString(b.Entry.RecordHash)
+		round, phase, err := GetAuctionRound(canvas, b.Entry.Record.Timestamp)
+		if err != nil {
+			return nil, err
+		}
+		if b.IsCommitment() {
+			err = nil
+			key := b.Entry.Record.Creator + "/" + base64.RawURLEncoding.EncodeToString(b.Purchase.Commitment)
+			_, closes, _, _ := GetAuctionWindow(canvas, round)
+			if phase != AUCTION_BIDDING || b.BlockTimestamp >= closes {
+				err = fmt.Errorf(ERROR_BID_OUTSIDE_WINDOW, id)
+			} else if b.Purchase.Location != nil || b.Purchase.Colour != nil || b.Purchase.Price != 0 {
+				err = fmt.Errorf(ERROR_COMMITMENT_UNSEALED, id)
+			} else if _, ok := commitments[key]; ok {
+				err = fmt.Errorf(ERROR_COMMITMENT_DUPLICATE, id)
+			}
+			if err != nil {
+				if err := callback(b, err); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			commitments[key] = &commitment{
+				bid:   b,
+				round: round,
+			}
+			continue
+		}
+		key := b.Entry.Record.Creator + "/" + base64.RawURLEncoding.EncodeToString(GetCommitment(b.Purchase))
+		c, ok := commitments[key]
+		_, opens, closes, _ := GetAuctionWindow(canvas, round)
+		switch {
+		case phase != AUCTION_REVEALING || b.BlockTimestamp < opens || b.BlockTimestamp >= closes:
+			err = fmt.Errorf(ERROR_BID_OUTSIDE_WINDOW, id)
+		case !ok || c.round != round:
+			err = fmt.Errorf(ERROR_REVEAL_UNMATCHED, id)
+		case c.revealed:
+			err = fmt.Errorf(ERROR_REVEAL_DUPLICATE, id)
+		case b.Purchase.Location == nil:
+			err = fmt.Errorf(ERROR_OUT_OF_BOUNDS, 0, 0, 0, 0)
+		default:
+			if err = CheckBounds(GetAmendedCanvas(amendments, canvas, b.BlockTimestamp), b.Purchase.Location); err == nil {
+				err = ValidateColour(canvas, b.Purchase.Colour)
+			}
+		}
+		if err != nil {
+			if err := callback(b, err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		c.revealed = true
+		reveals = append(reveals, &Reveal{
+			Bid:        b,
+			Commitment: c.bid,
+			Round:      round,
+		})
+	}
+	return reveals, nil
+}
+
+// SettleAuction awards each location to its highest reveal whose creator can afford it.
+// Equal bids are won by the earliest commitment, then the lowest commitment record hash.
+func SettleAuction(ledger *Ledger, reveals []*Reveal, callback func(*Reveal, error)) {
+	candidates := make(map[Point][]*Reveal)
+	pixels := make(map[Point]*Colour)
+	for _, r := range reveals {
+		p := NewPoint(r.Purchase.Location)
+		candidates[p] = append(candidates[p], r)
+		pixels[p] = r.Purchase.Colour
+	}
+	for _, p := range SortPoints(pixels) {
+		rs := candidates[p]
+		sort.Slice(rs, func(i, j int) bool {
+			a, b := rs[i], rs[j]
+			if a.Purchase.Price != b.Purchase.Price {
+				return a.Purchase.Price > b.Purchase.Price
+			}
+			if a.Commitment.Entry.Record.Timestamp != b.Commitment.Entry.Record.Timestamp {
+				return a.Commitment.Entry.Record.Timestamp < b.Commitment.Entry.Record.Timestamp
+			}
+			return bytes.Compare(a.Commitment.Entry.RecordHash, b.Commitment.Entry.RecordHash) < 0
+		})
+		for _, r := range rs {
+			err := ledger.Award(&LedgerEntry{
+				Entry:          r.Entry,
+				Height:         r.Height,
+				BlockTimestamp: r.BlockTimestamp,
+				Purchase:       r.Purchase,
+			})
+			if err == nil {
+				break
+			}
+			callback(r, err)
+		}
+	}
+}
+
+// ReplayAuctions settles every auction which ended before the timestamp, applying grants made before each auction ended.
+func ReplayAuctions(ledger *Ledger, reveals []*Reveal, grants []*LedgerEntry, timestamp uint64, callback func(*Reveal, error)) error {
+	rounds := make(map[uint64][]*Reveal)
+	var order []uint64
+	for _, r := range reveals {
+		if _, ok := rounds[r.Round]; !ok {
+			order = append(order, r.Round)
+		}
+		rounds[r.Round] = append(rounds[r.Round], r)
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return order[i] < order[j]
+	})
+	SortLedgerEntries(grants)
+	apply := func(before uint64) {
+		for len(grants) > 0 && grants[0].BlockTimestamp < before {
+			// Invalid grants are ignored
+			ledger.Apply(grants[0])
+			grants = grants[1:]
+		}
+	}
+	for _, round := range order {
+		_, _, end, err := GetAuctionWindow(ledger.Canvas, round)
+		if err != nil {
+			return err
+		}
+		if end > timestamp {
+			// Auction still running
+			break
+		}
+		apply(end)
+		SettleAuction(ledger, rounds[round], callback)
+	}
+	apply(timestamp)
+	return nil
+}
+
+// AuctionValidator ensures every bid in a purchase channel follows the sealed-bid auction rules.
+type AuctionValidator struct {
+	Canvas     *Canvas
+	Amendments *Amendments
+}
+
+func (v *AuctionValidator) Validate(channel *bcgo.Channel, cache bcgo.Cache, network bcgo.Network, hash []byte, block *bcgo.Block) error {
+	bids, err := ReadBids(channel.Name, hash, block, cache, network, nil)
+	if err != nil {
+		return err
+	}
+	if v.Amendments != nil {
+		if err := v.Amendments.Update(v.Amendments.Channel.Head, cache, network, nil); err != nil {
+			return err
+		}
+	}
+	_, err = MatchBids(v.Canvas, v.Amendments, bids, func(b *Bid, err error) error {
+		return err
+	})
+	return err
+}
+
+// PendingReveal is a bid's reveal which will be written once the round's bidding closes.
+// It is only held in memory by the model, so it should be kept by the caller and restored with AddPendingReveal after a restart.
+type PendingReveal struct {
+	Round  uint64
+	Reveal *Purchase
+}
+
+// AuctionModel draws each location in the colour set by the winner of its most recent auction.
+// Bids are sealed when written and revealed automatically once bidding closes.
+type AuctionModel struct {
+	MarketModel
+	pending []*PendingReveal
+}
+
+func NewAuctionModel(node *bcgo.Node, listener bcgo.MiningListener, id string, canvas *Canvas, channel, grants *bcgo.Channel, callback func()) *AuctionModel {
+	m := &AuctionModel{
+		MarketModel: MarketModel{
+			Grants: grants,
+			Ledger: NewLedger(canvas),
+		},
+	}
+	m.initialize(node, listener, id, canvas, channel, callback)
+	if grants != nil {
+		m.AddCompanion(grants)
+	}
+	return m
+}
+
+// Bind reads the channels and reveals pending bids and settles auctions as each window closes.
+func (m *AuctionModel) Bind(ctx context.Context) {
+	ctx = m.bind(ctx, m.Read)
+	m.Go(func() {
+		for {
+			next, err := m.nextBoundary()
+			if err != nil {
+				m.Emit(&Event{
+					Type:    EVENT_READ_FAILED,
+					Channel: m.Channel.Name,
+					Error:   err,
+				})
+				return
+			}
+			var wait time.Duration
+			if now := bcgo.Timestamp(); next > now {
+				wait = time.Duration(next - now)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(wait):
+				m.RevealPending()
+				m.Read(ctx)
+			}
+		}
+	})
+}
+
+func (m *AuctionModel) nextBoundary() (uint64, error) {
+	now := bcgo.Timestamp()
+	round, phase, err := GetAuctionRound(m.Canvas, now)
+	if err != nil {
+		return 0, err
+	}
+	start, reveal, end, err := GetAuctionWindow(m.Canvas, round)
+	if err != nil {
+		return 0, err
+	}
+	switch phase {
+	case AUCTION_PENDING:
+		return start, nil
+	case AUCTION_BIDDING:
+		return reveal, nil
+	default:
+		return end, nil
+	}
+}
+
+// Read matches the bids and settles every auction which has ended.
+func (m *AuctionModel) Read(ctx context.Context) {
+	m.Logger.Debug("Read:", m.Channel.Name, len(m.Order))
+	m.ReadBase(ctx)
+	m.ReadAmendments(ctx)
+	m.ReadModerations(ctx)
+	bids, err := ReadBids(m.Channel.Name, m.GetHead(), nil, m.Node.Cache, m.Node.Network, m.Verifier)
+	var grants []*LedgerEntry
+	if err == nil && m.Grants != nil {
+		grants, err = ReadGrantEntries(m.Grants.Name, m.GetHeadOf(m.Grants), nil, m.Node.Cache, m.Node.Network, m.Verifier)
+	}
+	if err != nil {
+		m.Emit(&Event{
+			Type:    EVENT_READ_FAILED,
+			Channel: m.Channel.Name,
+			Error:   err,
+		})
+		return
+	}
+	if ctx.Err() != nil {
+		// Model closed
+		return
+	}
+	reject := func(b *Bid, err error) {
+		m.Emit(&Event{
+			Type:    EVENT_REJECTED_RECORD,
+			Channel: m.Channel.Name,
+			Hash:    base64.RawURLEncoding.EncodeToString(b.Entry.RecordHash),
+			Error:   err,
+		})
+	}
+	var allowed []*Bid
+	for _, b := range bids {
+		if IsBanned(m.Canvas, b.Entry.Record.Creator) {
+			m.Emit(&Event{
+				Type:    EVENT_BANNED_RECORD,
+				Channel: m.Channel.Name,
+				Hash:    base64.RawURLEncoding.EncodeToString(b.Entry.RecordHash),
+			})
+			continue
+		}
+		allowed = append(allowed, b)
+	}
+	reveals, err := MatchBids(m.Canvas, m.Amendments, allowed, func(b *Bid, err error) error {
+		reject(b, err)
+		return nil
+	})
+	ledger := NewLedger(m.Canvas)
+	ledger.Amendments = m.Amendments
+	ledger.Moderations = m.Moderations
+	if err == nil {
+		err = ReplayAuctions(ledger, reveals, grants, bcgo.Timestamp(), func(r *Reveal, err error) {
+			reject(r.Bid, err)
+		})
+	}
+	if err != nil {
+		m.Emit(&Event{
+			Type:    EVENT_READ_FAILED,
+			Channel: m.Channel.Name,
+			Error:   err,
+		})
+		return
+	}
+	m.Lock()
+	var order []string
+	for _, b := range allowed {
+		id := base64.RawURLEncoding.EncodeToString(b.Entry.RecordHash)
+		m.Entries[id] = b.Entry
+		m.Heights[id] = b.Height
+		m.BlockTimestamps[id] = b.BlockTimestamp
+		order = append(order, id)
+	}
+	m.Order = order
+	m.Ledger = ledger
+	m.Logger.Debug("Read Complete:", m.Channel.Name, len(m.Order))
+	m.Unlock()
+	m.Emit(&Event{
+		Type:    EVENT_READ,
+		Channel: m.Channel.Name,
+	})
+	m.Go(func() {
+		if f := m.OnUpdate; f != nil {
+			f()
+		}
+	})
+}
+
+// Write bids the location's current price.
+func (m *AuctionModel) Write(l *Location, c *Colour) error {
+	price, err := ToPrice(m.GetPrice(l))
+	if err != nil {
+		return err
+	}
+	_, err = m.Bid(l, c, price)
+	return err
+}
+
+// Bid writes a sealed commitment to purchase the location, the reveal is written once bidding closes.
+// The returned reveal holds the nonce needed to open the commitment.
+func (m *AuctionModel) Bid(l *Location, c *Colour, price uint32) (*PendingReveal, error) {
+	if err := m.CheckContributor(m.Node.Alias); err != nil {
+		return nil, err
+	}
+	if err := ValidateColour(m.Canvas, c); err != nil {
+		return nil, err
+	}
+	if err := CheckBounds(m.Canvas, l); err != nil {
+		return nil, err
+	}
+	round, phase, err := GetAuctionRound(m.Canvas, bcgo.Timestamp())
+	if err != nil {
+		return nil, err
+	}
+	if phase != AUCTION_BIDDING {
+		return nil, fmt.Errorf(ERROR_BID_OUTSIDE_WINDOW, "bidding closed")
+	}
+	if err := m.getLedger().CheckAffordable(m.Node.Alias, uint64(price)); err != nil {
+		return nil, err
+	}
+	commit, reveal, err := CreateBid(l, c, price)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.writePurchase(commit); err != nil {
+		return nil, err
+	}
+	pending := &PendingReveal{
+		Round:  round,
+		Reveal: reveal,
+	}
+	m.AddPendingReveal(pending)
+	return pending, nil
+}
+
+// AddPendingReveal schedules the reveal to be written once the round's bidding closes, such as one returned by Bid before a restart.
+func (m *AuctionModel) AddPendingReveal(reveal *PendingReveal) {
+	m.Lock()
+	m.pending = append(m.pending, reveal)
+	m.Unlock()
+}
+
+// RevealPending writes the reveals of any bids whose bidding window has closed.
+func (m *AuctionModel) RevealPending() {
+	round, phase, err := GetAuctionRound(m.Canvas, bcgo.Timestamp())
+	if err != nil {
+		return
+	}
+	m.Lock()
+	var due, pending []*PendingReveal
+	for _, p := range m.pending {
+		switch {
+		case p.Round == round && phase == AUCTION_REVEALING:
+			due = append(due, p)
+		case p.Round >= round:
+			pending = append(pending, p)
+		default:
+			m.Logger.Warn("Reveal window missed:", p.Round)
+		}
+	}
+	m.pending = pending
+	m.Unlock()
+	for _, p := range due {
+		if err := m.writePurchase(p.Reveal); err != nil {
+			m.Emit(&Event{
+				Type:    EVENT_WRITE_FAILED,
+				Channel: m.Channel.Name,
+				Error:   err,
+			})
+		}
+	}
+}
+
+func (m *AuctionModel) writePurchase(purchase *Purchase) error {
+	record, err := CreatePurchaseRecord(m.Node.Alias, m.Node.Key, purchase)
+	if err != nil {
+		return err
+	}
+	reference, err := bcgo.WriteRecord(m.Channel.Name, m.Node.Cache, record)
+	if err != nil {
+		return err
+	}
+	m.Emit(&Event{
+		Type:    EVENT_WRITE,
+		Channel: m.Channel.Name,
+		Hash:    base64.RawURLEncoding.EncodeToString(reference.RecordHash),
+	})
+	m.Miner.Request()
+	return nil
+}
@@ -0,0 +1,170 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo_test
+
+import (
+	"github.com/AletheiaWareLLC/bcgo"
+	"github.com/AletheiaWareLLC/colourgo"
+	"github.com/AletheiaWareLLC/testinggo"
+	"testing"
+	"time"
+)
+
+const second = uint64(time.Second)
+
+func makeBid(creator string, timestamp uint64, purchase *colourgo.Purchase) *colourgo.Bid {
+	return &colourgo.Bid{
+		Entry: &bcgo.BlockEntry{
+			RecordHash: []byte(creator + string(rune(timestamp/second))),
+			Record: &bcgo.Record{
+				Creator:   creator,
+				Timestamp: timestamp,
+			},
+		},
+		BlockTimestamp: timestamp + second,
+		Purchase:       purchase,
+	}
+}
+
+func TestGetAuctionRound(t *testing.T) {
+	canvas := &colourgo.Canvas{
+		AuctionStart:    100 * second,
+		BiddingDuration: 10,
+		RevealDuration:  5,
+	}
+	for name, test := range map[string]struct {
+		timestamp uint64
+		round     uint64
+		phase     colourgo.AuctionPhase
+	}{
+		"Pending":   {50 * second, 0, colourgo.AUCTION_PENDING},
+		"Bidding":   {100 * second, 0, colourgo.AUCTION_BIDDING},
+		"Revealing": {112 * second, 0, colourgo.AUCTION_REVEALING},
+		"NextRound": {116 * second, 1, colourgo.AUCTION_BIDDING},
+	} {
+		t.Run(name, func(t *testing.T) {
+			round, phase, err := colourgo.GetAuctionRound(canvas, test.timestamp)
+			testinggo.AssertNoError(t, err)
+			if round != test.round || phase != test.phase {
+				t.Errorf("Expected round %d phase %d, got round %d phase %d", test.round, test.phase, round, phase)
+			}
+		})
+	}
+	_, _, err := colourgo.GetAuctionRound(&colourgo.Canvas{}, 0)
+	testinggo.AssertError(t, colourgo.ERROR_AUCTION_NOT_CONFIGURED, err)
+}
+
+func TestAuction(t *testing.T) {
+	canvas := &colourgo.Canvas{
+		Width:           1,
+		Height:          1,
+		Faucet:          10,
+		FaucetRecipient: []string{"Alice", "Bob", "Carol"},
+		AuctionStart:    100 * second,
+		BiddingDuration: 10,
+		RevealDuration:  5,
+	}
+	location := &colourgo.Location{}
+	red := &colourgo.Colour{Red: 255, Alpha: 255}
+	blue := &colourgo.Colour{Blue: 255, Alpha: 255}
+	aliceCommit, aliceReveal, err := colourgo.CreateBid(location, red, 5)
+	testinggo.AssertNoError(t, err)
+	bobCommit, bobReveal, err := colourgo.CreateBid(location, blue, 8)
+	testinggo.AssertNoError(t, err)
+	carolCommit, carolReveal, err := colourgo.CreateBid(location, blue, 20)
+	testinggo.AssertNoError(t, err)
+	bids := []*colourgo.Bid{
+		makeBid("Alice", 101*second, aliceCommit),
+		makeBid("Bob", 102*second, bobCommit),
+		// Carol can't afford her bid
+		makeBid("Carol", 103*second, carolCommit),
+		// Mallory reveals Bob's bid as her own
+		makeBid("Mallory", 111*second, bobReveal),
+		makeBid("Alice", 111*second, aliceReveal),
+		makeBid("Bob", 112*second, bobReveal),
+		makeBid("Carol", 113*second, carolReveal),
+		// Bob reveals again
+		makeBid("Bob", 114*second, bobReveal),
+	}
+	var rejected []string
+	reveals, err := colourgo.MatchBids(canvas, nil, bids, func(b *colourgo.Bid, err error) error {
+		rejected = append(rejected, b.Entry.Record.Creator)
+		return nil
+	})
+	testinggo.AssertNoError(t, err)
+	if len(reveals) != 3 {
+		t.Fatalf("Incorrect reveals; expected 3, got %d", len(reveals))
+	}
+	if len(rejected) != 2 || rejected[0] != "Mallory" || rejected[1] != "Bob" {
+		t.Errorf("Incorrect rejections; expected [Mallory Bob], got %v", rejected)
+	}
+
+	ledger := colourgo.NewLedger(canvas)
+	// Auction hasn't ended
+	testinggo.AssertNoError(t, colourgo.ReplayAuctions(ledger, reveals, nil, 114*second, func(*colourgo.Reveal, error) {}))
+	if o := ledger.GetOwnership(location); o != nil {
+		t.Errorf("Unexpected owner %s before auction ended", o.Alias)
+	}
+	testinggo.AssertNoError(t, colourgo.ReplayAuctions(ledger, reveals, nil, 115*second, func(*colourgo.Reveal, error) {}))
+	if o := ledger.GetOwnership(location); o == nil || o.Alias != "Bob" {
+		t.Errorf("Incorrect owner; expected Bob, got %v", o)
+	}
+	if b := ledger.GetBalance("Bob"); b != 2 {
+		t.Errorf("Incorrect balance; expected 2, got %d", b)
+	}
+}
+
+func TestMatchBids_RevealWindow(t *testing.T) {
+	canvas := &colourgo.Canvas{
+		Width:           1,
+		Height:          1,
+		Faucet:          10,
+		FaucetRecipient: []string{"Alice", "Bob", "Carol"},
+		AuctionStart:    100 * second,
+		BiddingDuration: 10,
+		RevealDuration:  5,
+	}
+	commit, reveal, err := colourgo.CreateBid(&colourgo.Location{}, &colourgo.Colour{Red: 255, Alpha: 255}, 5)
+	testinggo.AssertNoError(t, err)
+	for name, test := range map[string]struct {
+		timestamp uint64
+		valid     bool
+	}{
+		"MinedInWindow": {112 * second, true},
+		// Backdated into the reveal window but mined after the auction ended
+		"MinedLate": {120 * second, false},
+		// Forward dated into the reveal window but mined while bidding was open
+		"MinedEarly": {108 * second, false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := makeBid("Alice", 111*second, reveal)
+			r.BlockTimestamp = test.timestamp
+			var rejected []error
+			reveals, err := colourgo.MatchBids(canvas, nil, []*colourgo.Bid{
+				makeBid("Alice", 101*second, commit),
+				r,
+			}, func(b *colourgo.Bid, err error) error {
+				rejected = append(rejected, err)
+				return nil
+			})
+			testinggo.AssertNoError(t, err)
+			if got := len(reveals) == 1; got != test.valid {
+				t.Errorf("Expected valid %t, got %t: %v", test.valid, got, rejected)
+			}
+		})
+	}
+}
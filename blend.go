@@ -0,0 +1,105 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo
+
+const (
+	MAX_CHANNEL = 255
+)
+
+// channelValue returns the channel clamped to MAX_CHANNEL.
+func channelValue(v uint32) uint64 {
+	if v > MAX_CHANNEL {
+		return MAX_CHANNEL
+	}
+	return uint64(v)
+}
+
+// divRound returns n/d rounded to the nearest integer, halves round up.
+func divRound(n, d uint64) uint64 {
+	return (2*n + d) / (2 * d)
+}
+
+// blendChannel returns the blend of the backdrop and source channels, scaled by MAX_CHANNEL squared.
+func blendChannel(mode Blend, b, s uint64) uint64 {
+	switch mode {
+	case Blend_MULTIPLY:
+		return b * s
+	case Blend_SCREEN:
+		return (b+s)*MAX_CHANNEL - b*s
+	case Blend_ADD:
+		if b+s > MAX_CHANNEL {
+			return MAX_CHANNEL * MAX_CHANNEL
+		}
+		return (b + s) * MAX_CHANNEL
+	default:
+		return s * MAX_CHANNEL
+	}
+}
+
+// BlendColour returns the result of drawing the source colour over the destination colour with the given blend mode.
+// Colours are non-premultiplied with channels in the range 0-255, nil is treated as fully transparent.
+// Blending uses integer arithmetic, rounding once to the nearest channel value, so results are identical on every platform.
+func BlendColour(mode Blend, dst, src *Colour) *Colour {
+	if mode == Blend_REPLACE || dst == nil {
+		return src
+	}
+	if src == nil {
+		return dst
+	}
+	const m = MAX_CHANNEL
+	ab, as := channelValue(dst.Alpha), channelValue(src.Alpha)
+	// Output alpha scaled by m squared
+	ao := as*m + ab*(m-as)
+	if ao == 0 {
+		return &Colour{}
+	}
+	channel := func(b, s uint32) uint32 {
+		cb, cs := channelValue(b), channelValue(s)
+		// W3C Compositing: mix the blended colour according to the backdrop alpha, scaled by m cubed
+		mixed := (m-ab)*cs*m + ab*blendChannel(mode, cb, cs)
+		// Then composite source-over, scaled by m to the fourth
+		c := divRound(as*mixed+(m-as)*ab*cb*m, ao*m)
+		if c > m {
+			c = m
+		}
+		return uint32(c)
+	}
+	return &Colour{
+		Red:   channel(dst.Red, src.Red),
+		Green: channel(dst.Green, src.Green),
+		Blue:  channel(dst.Blue, src.Blue),
+		Alpha: uint32(divRound(ao, m)),
+	}
+}
+
+// GetBackground returns the opaque colour drawn beneath all pixels of the canvas, this is the canvas fill or white if none is set.
+func GetBackground(canvas *Canvas) *Colour {
+	if canvas.Fill == nil {
+		return &Colour{
+			Red:   MAX_CHANNEL,
+			Green: MAX_CHANNEL,
+			Blue:  MAX_CHANNEL,
+			Alpha: MAX_CHANNEL,
+		}
+	}
+	return &Colour{
+		Red:   canvas.Fill.Red,
+		Green: canvas.Fill.Green,
+		Blue:  canvas.Fill.Blue,
+		Alpha: MAX_CHANNEL,
+	}
+}
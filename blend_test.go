@@ -0,0 +1,66 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo_test
+
+import (
+	"github.com/AletheiaWareLLC/colourgo"
+	"github.com/AletheiaWareLLC/testinggo"
+	"testing"
+)
+
+func TestBlendColour(t *testing.T) {
+	white := &colourgo.Colour{Red: 255, Green: 255, Blue: 255, Alpha: 255}
+	red := &colourgo.Colour{Red: 255, Alpha: 255}
+	halfBlue := &colourgo.Colour{Blue: 255, Alpha: 128}
+	for name, tt := range map[string]struct {
+		mode     colourgo.Blend
+		dst, src *colourgo.Colour
+		expected *colourgo.Colour
+	}{
+		"SourceOverOpaque": {colourgo.Blend_SOURCE_OVER, white, red, red},
+		"SourceOverHalf":   {colourgo.Blend_SOURCE_OVER, red, halfBlue, &colourgo.Colour{Red: 127, Blue: 128, Alpha: 255}},
+		"SourceOverNil":    {colourgo.Blend_SOURCE_OVER, nil, halfBlue, halfBlue},
+		"Replace":          {colourgo.Blend_REPLACE, red, halfBlue, halfBlue},
+		"Multiply":         {colourgo.Blend_MULTIPLY, white, red, red},
+		"Screen":           {colourgo.Blend_SCREEN, red, &colourgo.Colour{Blue: 255, Alpha: 255}, &colourgo.Colour{Red: 255, Blue: 255, Alpha: 255}},
+		"Add":              {colourgo.Blend_ADD, red, halfBlue, &colourgo.Colour{Red: 255, Blue: 128, Alpha: 255}},
+		"HalfOverHalf":     {colourgo.Blend_SOURCE_OVER, halfBlue, &colourgo.Colour{Red: 255, Alpha: 128}, &colourgo.Colour{Red: 170, Blue: 85, Alpha: 192}},
+		"Transparent":      {colourgo.Blend_SOURCE_OVER, &colourgo.Colour{}, &colourgo.Colour{}, &colourgo.Colour{}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			testinggo.AssertProtobufEqual(t, tt.expected, colourgo.BlendColour(tt.mode, tt.dst, tt.src))
+		})
+	}
+}
+
+func TestComposite(t *testing.T) {
+	canvas, err := colourgo.CreateCanvas("TEST_CANVAS", 2, 1, 1, colourgo.Mode_FREE_FOR_ALL)
+	testinggo.AssertNoError(t, err)
+	canvas.Extent = 2
+	canvas.Fill = &colourgo.Colour{Alpha: 255}
+	state := map[colourgo.Point]*colourgo.Colour{
+		{W: 0, X: 0}: &colourgo.Colour{Red: 255, Alpha: 255},
+		{W: 1, X: 0}: &colourgo.Colour{Green: 255, Alpha: 0},
+		{W: 1, X: 1}: &colourgo.Colour{Blue: 255, Alpha: 128},
+	}
+	pixels := colourgo.Composite(canvas, state)
+	if len(pixels) != 2 {
+		t.Fatalf("Incorrect pixels; expected 2, got '%d'", len(pixels))
+	}
+	testinggo.AssertProtobufEqual(t, &colourgo.Colour{Red: 255, Alpha: 255}, pixels[colourgo.Point{X: 0}])
+	testinggo.AssertProtobufEqual(t, &colourgo.Colour{Blue: 128, Alpha: 255}, pixels[colourgo.Point{X: 1}])
+}
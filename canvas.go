@@ -17,11 +17,17 @@
 package colourgo
 
 import (
+	"context"
 	"crypto/rsa"
+	"fmt"
 	"github.com/AletheiaWareLLC/bcgo"
 	"github.com/golang/protobuf/proto"
 )
 
+const (
+	ERROR_OUT_OF_BOUNDS = "Location out of bounds: %d,%d,%d,%d"
+)
+
 func UnmarshalCanvas(data []byte) (*Canvas, error) {
 	canvas := &Canvas{}
 	if err := proto.Unmarshal(data, canvas); err != nil {
@@ -40,20 +46,83 @@ func GetCanvas(canvases *bcgo.Channel, cache bcgo.Cache, network bcgo.Network, a
 	})
 }
 
-func CreateCanvas(name string, w, h, d uint32, mode Mode) *Canvas {
+// CreateCanvas creates a canvas, returning an error if the name is invalid.
+func CreateCanvas(name string, w, h, d uint32, mode Mode) (*Canvas, error) {
+	if err := ValidateName(name); err != nil {
+		return nil, err
+	}
 	return &Canvas{
 		Name:   name,
 		Width:  w,
 		Height: h,
 		Depth:  d,
 		Mode:   mode,
-	}
+	}, nil
 }
 
+// CreateCanvasRecord creates a record of the canvas, the creator becomes the owner if none is set.
+// The given canvas is not modified.
 func CreateCanvasRecord(alias string, key *rsa.PrivateKey, canvas *Canvas) (*bcgo.Record, error) {
+	if err := ValidateMetadata(canvas); err != nil {
+		return nil, err
+	}
+	if canvas.Owner == "" {
+		canvas = proto.Clone(canvas).(*Canvas)
+		canvas.Owner = alias
+	}
 	data, err := proto.Marshal(canvas)
 	if err != nil {
 		return nil, err
 	}
 	return CreateRecord(alias, key, data)
 }
+
+// WriteCanvas writes a record of the canvas to the canvas channel of the year the record was created, mines it, and returns the canvas' ID.
+func WriteCanvas(ctx context.Context, node *bcgo.Node, listener bcgo.MiningListener, canvas *Canvas) (string, error) {
+	record, err := CreateCanvasRecord(node.Alias, node.Key, canvas)
+	if err != nil {
+		return "", err
+	}
+	name := GetCanvasChannelNameForRecord(record)
+	channel := node.GetOrOpenChannel(name, func() *bcgo.Channel {
+		return OpenColourChannel(name)
+	})
+	if err := channel.LoadCachedHead(node.Cache); err != nil && !IsHeadNotFound(name, err) {
+		return "", err
+	}
+	reference, err := bcgo.WriteRecord(name, node.Cache, record)
+	if err != nil {
+		return "", err
+	}
+	if err := NewMiner(node, channel, COLOUR_THRESHOLD, listener, MINE_MANUAL).Mine(ctx); err != nil {
+		return "", err
+	}
+	return FormatCanvasID(GetYearOfTimestamp(record.Timestamp), reference.RecordHash), nil
+}
+
+// GetCanvasDepth returns the number of Z planes in the canvas, a Depth of zero is treated as a single plane.
+func GetCanvasDepth(canvas *Canvas) uint32 {
+	if canvas.Depth == 0 {
+		return 1
+	}
+	return canvas.Depth
+}
+
+// GetCanvasExtent returns the number of W layers or frames in the canvas, an Extent of zero is treated as a single layer.
+func GetCanvasExtent(canvas *Canvas) uint32 {
+	if canvas.Extent == 0 {
+		return 1
+	}
+	return canvas.Extent
+}
+
+func IsInBounds(canvas *Canvas, l *Location) bool {
+	return l.W < GetCanvasExtent(canvas) && l.X < canvas.Width && l.Y < canvas.Height && l.Z < GetCanvasDepth(canvas)
+}
+
+func CheckBounds(canvas *Canvas, l *Location) error {
+	if !IsInBounds(canvas, l) {
+		return fmt.Errorf(ERROR_OUT_OF_BOUNDS, l.W, l.X, l.Y, l.Z)
+	}
+	return nil
+}
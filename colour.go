@@ -28,13 +28,20 @@ const (
 	COLOUR = "Colour"
 
 	COLOUR_THRESHOLD = bcgo.THRESHOLD_G
-
-	COLOUR_HOST            = "colour.aletheiaware.com"
-	COLOUR_HOST_TEST       = "test-colour.aletheiaware.com"
-	COLOUR_PREFIX          = "Colour-"
-	COLOUR_PREFIX_CANVAS   = "Colour-Canvas-"   // Append Year
-	COLOUR_PREFIX_PURCHASE = "Colour-Purchase-" // Append Canvas ID
-	COLOUR_PREFIX_VOTE     = "Colour-Vote-"     // Append Canvas ID
+	// Default work proven by each vote on a proof of work canvas, lower than that of mined blocks as it is done for every vote
+	COLOUR_VOTE_THRESHOLD = bcgo.THRESHOLD_H
+
+	COLOUR_HOST              = "colour.aletheiaware.com"
+	COLOUR_HOST_TEST         = "test-colour.aletheiaware.com"
+	COLOUR_PREFIX            = "Colour-"
+	COLOUR_PREFIX_AMENDMENT  = "Colour-Amendment-"  // Append Canvas ID
+	COLOUR_PREFIX_CANVAS     = "Colour-Canvas-"     // Append Year
+	COLOUR_PREFIX_GRANT      = "Colour-Grant-"      // Append Canvas ID
+	COLOUR_PREFIX_MODERATION = "Colour-Moderation-" // Append Canvas ID
+	COLOUR_PREFIX_PROPOSAL   = "Colour-Proposal-"   // Append Canvas ID
+	COLOUR_PREFIX_PURCHASE   = "Colour-Purchase-"   // Append Canvas ID
+	COLOUR_PREFIX_TAX        = "Colour-Tax-"        // Append Canvas ID
+	COLOUR_PREFIX_VOTE       = "Colour-Vote-"       // Append Canvas ID
 
 	MAX_NAME_LENGTH = 100
 )
@@ -50,18 +57,59 @@ func GetColourWebsite() string {
 	return "https://" + GetColourHost()
 }
 
+// GetYear returns the current UTC year, prefer GetYearOf when the moment matters.
 func GetYear() string {
-	return fmt.Sprintf("%d", time.Now().UTC().Year())
+	return GetYearOf(time.Now())
+}
+
+// GetYearOf returns the UTC year of the given time.
+func GetYearOf(t time.Time) string {
+	return fmt.Sprintf("%d", t.UTC().Year())
+}
+
+// GetYearOfTimestamp returns the UTC year of the given record timestamp, in nanoseconds.
+func GetYearOfTimestamp(timestamp uint64) string {
+	return GetYearOf(time.Unix(0, int64(timestamp)))
+}
+
+func GetAmendmentChannelName(id string) string {
+	return COLOUR_PREFIX_AMENDMENT + id
 }
 
+// GetCanvasChannelName returns the name of the current year's canvas channel, prefer GetCanvasChannelNameForYear when the year matters.
 func GetCanvasChannelName() string {
-	return COLOUR_PREFIX_CANVAS + GetYear()
+	return GetCanvasChannelNameForYear(GetYear())
+}
+
+func GetCanvasChannelNameForYear(year string) string {
+	return COLOUR_PREFIX_CANVAS + year
+}
+
+// GetCanvasChannelNameForRecord returns the name of the canvas channel of the year the record was created, so a record created before midnight on 31 December is written to that year's channel.
+func GetCanvasChannelNameForRecord(record *bcgo.Record) string {
+	return GetCanvasChannelNameForYear(GetYearOfTimestamp(record.Timestamp))
+}
+
+func GetGrantChannelName(id string) string {
+	return COLOUR_PREFIX_GRANT + id
+}
+
+func GetModerationChannelName(id string) string {
+	return COLOUR_PREFIX_MODERATION + id
+}
+
+func GetProposalChannelName(id string) string {
+	return COLOUR_PREFIX_PROPOSAL + id
 }
 
 func GetPurchaseChannelName(id string) string {
 	return COLOUR_PREFIX_PURCHASE + id
 }
 
+func GetTaxChannelName(id string) string {
+	return COLOUR_PREFIX_TAX + id
+}
+
 func GetVoteChannelName(id string) string {
 	return COLOUR_PREFIX_VOTE + id
 }
@@ -72,14 +120,38 @@ func OpenColourChannel(name string) *bcgo.Channel {
 	return c
 }
 
+func OpenAmendmentChannel(id string) *bcgo.Channel {
+	return OpenColourChannel(GetAmendmentChannelName(id))
+}
+
 func OpenCanvasChannel() *bcgo.Channel {
 	return OpenColourChannel(GetCanvasChannelName())
 }
 
+func OpenCanvasChannelForYear(year string) *bcgo.Channel {
+	return OpenColourChannel(GetCanvasChannelNameForYear(year))
+}
+
+func OpenGrantChannel(id string) *bcgo.Channel {
+	return OpenColourChannel(GetGrantChannelName(id))
+}
+
+func OpenModerationChannel(id string) *bcgo.Channel {
+	return OpenColourChannel(GetModerationChannelName(id))
+}
+
+func OpenProposalChannel(id string) *bcgo.Channel {
+	return OpenColourChannel(GetProposalChannelName(id))
+}
+
 func OpenPurchaseChannel(id string) *bcgo.Channel {
 	return OpenColourChannel(GetPurchaseChannelName(id))
 }
 
+func OpenTaxChannel(id string) *bcgo.Channel {
+	return OpenColourChannel(GetTaxChannelName(id))
+}
+
 func OpenVoteChannel(id string) *bcgo.Channel {
 	return OpenColourChannel(GetVoteChannelName(id))
 }
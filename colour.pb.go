@@ -29,6 +29,8 @@ const (
 	Mode_MARKET            Mode = 3
 	Mode_RADICAL_DEMOCRACY Mode = 4
 	Mode_RADICAL_MARKET    Mode = 5
+	Mode_AUCTION           Mode = 6
+	Mode_PROPOSAL          Mode = 7
 )
 
 var Mode_name = map[int32]string{
@@ -38,6 +40,8 @@ var Mode_name = map[int32]string{
 	3: "MARKET",
 	4: "RADICAL_DEMOCRACY",
 	5: "RADICAL_MARKET",
+	6: "AUCTION",
+	7: "PROPOSAL",
 }
 
 var Mode_value = map[string]int32{
@@ -47,6 +51,8 @@ var Mode_value = map[string]int32{
 	"MARKET":            3,
 	"RADICAL_DEMOCRACY": 4,
 	"RADICAL_MARKET":    5,
+	"AUCTION":           6,
+	"PROPOSAL":          7,
 }
 
 func (x Mode) String() string {
@@ -57,16 +63,160 @@ func (Mode) EnumDescriptor() ([]byte, []int) {
 	return fileDescriptor_b8cfc2a33b1d9e1a, []int{0}
 }
 
+type Dimension int32
+
+const (
+	Dimension_LAYER Dimension = 0
+	Dimension_FRAME Dimension = 1
+)
+
+var Dimension_name = map[int32]string{
+	0: "LAYER",
+	1: "FRAME",
+}
+
+var Dimension_value = map[string]int32{
+	"LAYER": 0,
+	"FRAME": 1,
+}
+
+func (x Dimension) String() string {
+	return proto.EnumName(Dimension_name, int32(x))
+}
+
+func (Dimension) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_b8cfc2a33b1d9e1a, []int{1}
+}
+
+type Blend int32
+
+const (
+	Blend_SOURCE_OVER Blend = 0
+	Blend_REPLACE     Blend = 1
+	Blend_MULTIPLY    Blend = 2
+	Blend_SCREEN      Blend = 3
+	Blend_ADD         Blend = 4
+)
+
+var Blend_name = map[int32]string{
+	0: "SOURCE_OVER",
+	1: "REPLACE",
+	2: "MULTIPLY",
+	3: "SCREEN",
+	4: "ADD",
+}
+
+var Blend_value = map[string]int32{
+	"SOURCE_OVER": 0,
+	"REPLACE":     1,
+	"MULTIPLY":    2,
+	"SCREEN":      3,
+	"ADD":         4,
+}
+
+func (x Blend) String() string {
+	return proto.EnumName(Blend_name, int32(x))
+}
+
+func (Blend) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_b8cfc2a33b1d9e1a, []int{2}
+}
+
+type Weighting int32
+
+const (
+	Weighting_EQUAL         Weighting = 0
+	Weighting_PROOF_OF_WORK Weighting = 1
+	Weighting_ALLOW_LIST    Weighting = 2
+)
+
+var Weighting_name = map[int32]string{
+	0: "EQUAL",
+	1: "PROOF_OF_WORK",
+	2: "ALLOW_LIST",
+}
+
+var Weighting_value = map[string]int32{
+	"EQUAL":         0,
+	"PROOF_OF_WORK": 1,
+	"ALLOW_LIST":    2,
+}
+
+func (x Weighting) String() string {
+	return proto.EnumName(Weighting_name, int32(x))
+}
+
+func (Weighting) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_b8cfc2a33b1d9e1a, []int{3}
+}
+
+type Action int32
+
+const (
+	Action_UNKNOWN_ACTION Action = 0
+	Action_MASK           Action = 1
+	Action_REVERT         Action = 2
+	Action_BAN            Action = 3
+)
+
+var Action_name = map[int32]string{
+	0: "UNKNOWN_ACTION",
+	1: "MASK",
+	2: "REVERT",
+	3: "BAN",
+}
+
+var Action_value = map[string]int32{
+	"UNKNOWN_ACTION": 0,
+	"MASK":           1,
+	"REVERT":         2,
+	"BAN":            3,
+}
+
+func (x Action) String() string {
+	return proto.EnumName(Action_name, int32(x))
+}
+
+func (Action) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_b8cfc2a33b1d9e1a, []int{4}
+}
+
 type Canvas struct {
-	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	Width                uint32   `protobuf:"varint,2,opt,name=width,proto3" json:"width,omitempty"`
-	Height               uint32   `protobuf:"varint,3,opt,name=height,proto3" json:"height,omitempty"`
-	Depth                uint32   `protobuf:"varint,4,opt,name=depth,proto3" json:"depth,omitempty"`
-	Mode                 Mode     `protobuf:"varint,5,opt,name=mode,proto3,enum=colour.Mode" json:"mode,omitempty"`
-	Fill                 *Colour  `protobuf:"bytes,6,opt,name=fill,proto3" json:"fill,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	Name                 string    `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Width                uint32    `protobuf:"varint,2,opt,name=width,proto3" json:"width,omitempty"`
+	Height               uint32    `protobuf:"varint,3,opt,name=height,proto3" json:"height,omitempty"`
+	Depth                uint32    `protobuf:"varint,4,opt,name=depth,proto3" json:"depth,omitempty"`
+	Mode                 Mode      `protobuf:"varint,5,opt,name=mode,proto3,enum=colour.Mode" json:"mode,omitempty"`
+	Fill                 *Colour   `protobuf:"bytes,6,opt,name=fill,proto3" json:"fill,omitempty"`
+	Extent               uint32    `protobuf:"varint,7,opt,name=extent,proto3" json:"extent,omitempty"`
+	Dimension            Dimension `protobuf:"varint,8,opt,name=dimension,proto3,enum=colour.Dimension" json:"dimension,omitempty"`
+	FrameDuration        uint32    `protobuf:"varint,9,opt,name=frame_duration,json=frameDuration,proto3" json:"frame_duration,omitempty"`
+	Blend                Blend     `protobuf:"varint,10,opt,name=blend,proto3,enum=colour.Blend" json:"blend,omitempty"`
+	Palette              []*Colour `protobuf:"bytes,11,rep,name=palette,proto3" json:"palette,omitempty"`
+	Owner                string    `protobuf:"bytes,12,opt,name=owner,proto3" json:"owner,omitempty"`
+	Moderator            []string  `protobuf:"bytes,13,rep,name=moderator,proto3" json:"moderator,omitempty"`
+	Banned               []string  `protobuf:"bytes,14,rep,name=banned,proto3" json:"banned,omitempty"`
+	Faucet               uint64    `protobuf:"varint,15,opt,name=faucet,proto3" json:"faucet,omitempty"`
+	AuctionStart         uint64    `protobuf:"varint,16,opt,name=auction_start,json=auctionStart,proto3" json:"auction_start,omitempty"`
+	BiddingDuration      uint32    `protobuf:"varint,17,opt,name=bidding_duration,json=biddingDuration,proto3" json:"bidding_duration,omitempty"`
+	RevealDuration       uint32    `protobuf:"varint,18,opt,name=reveal_duration,json=revealDuration,proto3" json:"reveal_duration,omitempty"`
+	TaxRate              uint32    `protobuf:"varint,19,opt,name=tax_rate,json=taxRate,proto3" json:"tax_rate,omitempty"`
+	TaxPeriod            uint32    `protobuf:"varint,20,opt,name=tax_period,json=taxPeriod,proto3" json:"tax_period,omitempty"`
+	RoundStart           uint64    `protobuf:"varint,21,opt,name=round_start,json=roundStart,proto3" json:"round_start,omitempty"`
+	RoundLength          uint32    `protobuf:"varint,22,opt,name=round_length,json=roundLength,proto3" json:"round_length,omitempty"`
+	Weighting            Weighting `protobuf:"varint,23,opt,name=weighting,proto3,enum=colour.Weighting" json:"weighting,omitempty"`
+	Voter                []string  `protobuf:"bytes,24,rep,name=voter,proto3" json:"voter,omitempty"`
+	WorkThreshold        uint64    `protobuf:"varint,25,opt,name=work_threshold,json=workThreshold,proto3" json:"work_threshold,omitempty"`
+	Parent               string    `protobuf:"bytes,26,opt,name=parent,proto3" json:"parent,omitempty"`
+	ParentBlock          []byte    `protobuf:"bytes,27,opt,name=parent_block,json=parentBlock,proto3" json:"parent_block,omitempty"`
+	Description          string    `protobuf:"bytes,28,opt,name=description,proto3" json:"description,omitempty"`
+	Tag                  []string  `protobuf:"bytes,29,rep,name=tag,proto3" json:"tag,omitempty"`
+	License              string    `protobuf:"bytes,30,opt,name=license,proto3" json:"license,omitempty"`
+	Thumbnail            []byte    `protobuf:"bytes,31,opt,name=thumbnail,proto3" json:"thumbnail,omitempty"`
+	FaucetRecipient      []string  `protobuf:"bytes,32,rep,name=faucet_recipient,json=faucetRecipient,proto3" json:"faucet_recipient,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
 }
 
 func (m *Canvas) Reset()         { *m = Canvas{} }
@@ -136,6 +286,188 @@ func (m *Canvas) GetFill() *Colour {
 	return nil
 }
 
+func (m *Canvas) GetExtent() uint32 {
+	if m != nil {
+		return m.Extent
+	}
+	return 0
+}
+
+func (m *Canvas) GetDimension() Dimension {
+	if m != nil {
+		return m.Dimension
+	}
+	return Dimension_LAYER
+}
+
+func (m *Canvas) GetFrameDuration() uint32 {
+	if m != nil {
+		return m.FrameDuration
+	}
+	return 0
+}
+
+func (m *Canvas) GetBlend() Blend {
+	if m != nil {
+		return m.Blend
+	}
+	return Blend_SOURCE_OVER
+}
+
+func (m *Canvas) GetPalette() []*Colour {
+	if m != nil {
+		return m.Palette
+	}
+	return nil
+}
+
+func (m *Canvas) GetOwner() string {
+	if m != nil {
+		return m.Owner
+	}
+	return ""
+}
+
+func (m *Canvas) GetModerator() []string {
+	if m != nil {
+		return m.Moderator
+	}
+	return nil
+}
+
+func (m *Canvas) GetBanned() []string {
+	if m != nil {
+		return m.Banned
+	}
+	return nil
+}
+
+func (m *Canvas) GetFaucet() uint64 {
+	if m != nil {
+		return m.Faucet
+	}
+	return 0
+}
+
+func (m *Canvas) GetAuctionStart() uint64 {
+	if m != nil {
+		return m.AuctionStart
+	}
+	return 0
+}
+
+func (m *Canvas) GetBiddingDuration() uint32 {
+	if m != nil {
+		return m.BiddingDuration
+	}
+	return 0
+}
+
+func (m *Canvas) GetRevealDuration() uint32 {
+	if m != nil {
+		return m.RevealDuration
+	}
+	return 0
+}
+
+func (m *Canvas) GetTaxRate() uint32 {
+	if m != nil {
+		return m.TaxRate
+	}
+	return 0
+}
+
+func (m *Canvas) GetTaxPeriod() uint32 {
+	if m != nil {
+		return m.TaxPeriod
+	}
+	return 0
+}
+
+func (m *Canvas) GetRoundStart() uint64 {
+	if m != nil {
+		return m.RoundStart
+	}
+	return 0
+}
+
+func (m *Canvas) GetRoundLength() uint32 {
+	if m != nil {
+		return m.RoundLength
+	}
+	return 0
+}
+
+func (m *Canvas) GetWeighting() Weighting {
+	if m != nil {
+		return m.Weighting
+	}
+	return Weighting_EQUAL
+}
+
+func (m *Canvas) GetVoter() []string {
+	if m != nil {
+		return m.Voter
+	}
+	return nil
+}
+
+func (m *Canvas) GetWorkThreshold() uint64 {
+	if m != nil {
+		return m.WorkThreshold
+	}
+	return 0
+}
+
+func (m *Canvas) GetParent() string {
+	if m != nil {
+		return m.Parent
+	}
+	return ""
+}
+
+func (m *Canvas) GetParentBlock() []byte {
+	if m != nil {
+		return m.ParentBlock
+	}
+	return nil
+}
+
+func (m *Canvas) GetDescription() string {
+	if m != nil {
+		return m.Description
+	}
+	return ""
+}
+
+func (m *Canvas) GetTag() []string {
+	if m != nil {
+		return m.Tag
+	}
+	return nil
+}
+
+func (m *Canvas) GetLicense() string {
+	if m != nil {
+		return m.License
+	}
+	return ""
+}
+
+func (m *Canvas) GetThumbnail() []byte {
+	if m != nil {
+		return m.Thumbnail
+	}
+	return nil
+}
+
+func (m *Canvas) GetFaucetRecipient() []string {
+	if m != nil {
+		return m.FaucetRecipient
+	}
+	return nil
+}
+
 type Colour struct {
 	Red                  uint32   `protobuf:"varint,1,opt,name=red,proto3" json:"red,omitempty"`
 	Green                uint32   `protobuf:"varint,2,opt,name=green,proto3" json:"green,omitempty"`
@@ -263,11 +595,17 @@ func (m *Location) GetZ() uint32 {
 }
 
 type Vote struct {
-	Colour               *Colour   `protobuf:"bytes,1,opt,name=colour,proto3" json:"colour,omitempty"`
-	Location             *Location `protobuf:"bytes,2,opt,name=location,proto3" json:"location,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
-	XXX_unrecognized     []byte    `json:"-"`
-	XXX_sizecache        int32     `json:"-"`
+	Colour               *Colour     `protobuf:"bytes,1,opt,name=colour,proto3" json:"colour,omitempty"`
+	Location             *Location   `protobuf:"bytes,2,opt,name=location,proto3" json:"location,omitempty"`
+	Delegate             string      `protobuf:"bytes,3,opt,name=delegate,proto3" json:"delegate,omitempty"`
+	To                   *Location   `protobuf:"bytes,4,opt,name=to,proto3" json:"to,omitempty"`
+	Proposal             []byte      `protobuf:"bytes,5,opt,name=proposal,proto3" json:"proposal,omitempty"`
+	Nonce                uint64      `protobuf:"varint,6,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	Retract              []byte      `protobuf:"bytes,7,opt,name=retract,proto3" json:"retract,omitempty"`
+	Batch                []*Location `protobuf:"bytes,8,rep,name=batch,proto3" json:"batch,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
 }
 
 func (m *Vote) Reset()         { *m = Vote{} }
@@ -309,11 +647,119 @@ func (m *Vote) GetLocation() *Location {
 	return nil
 }
 
+func (m *Vote) GetDelegate() string {
+	if m != nil {
+		return m.Delegate
+	}
+	return ""
+}
+
+func (m *Vote) GetTo() *Location {
+	if m != nil {
+		return m.To
+	}
+	return nil
+}
+
+func (m *Vote) GetProposal() []byte {
+	if m != nil {
+		return m.Proposal
+	}
+	return nil
+}
+
+func (m *Vote) GetNonce() uint64 {
+	if m != nil {
+		return m.Nonce
+	}
+	return 0
+}
+
+func (m *Vote) GetRetract() []byte {
+	if m != nil {
+		return m.Retract
+	}
+	return nil
+}
+
+func (m *Vote) GetBatch() []*Location {
+	if m != nil {
+		return m.Batch
+	}
+	return nil
+}
+
+type Proposal struct {
+	Location             *Location `protobuf:"bytes,1,opt,name=location,proto3" json:"location,omitempty"`
+	To                   *Location `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	Pixel                []*Colour `protobuf:"bytes,3,rep,name=pixel,proto3" json:"pixel,omitempty"`
+	Description          string    `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *Proposal) Reset()         { *m = Proposal{} }
+func (m *Proposal) String() string { return proto.CompactTextString(m) }
+func (*Proposal) ProtoMessage()    {}
+func (*Proposal) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b8cfc2a33b1d9e1a, []int{4}
+}
+
+func (m *Proposal) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Proposal.Unmarshal(m, b)
+}
+func (m *Proposal) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Proposal.Marshal(b, m, deterministic)
+}
+func (m *Proposal) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Proposal.Merge(m, src)
+}
+func (m *Proposal) XXX_Size() int {
+	return xxx_messageInfo_Proposal.Size(m)
+}
+func (m *Proposal) XXX_DiscardUnknown() {
+	xxx_messageInfo_Proposal.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Proposal proto.InternalMessageInfo
+
+func (m *Proposal) GetLocation() *Location {
+	if m != nil {
+		return m.Location
+	}
+	return nil
+}
+
+func (m *Proposal) GetTo() *Location {
+	if m != nil {
+		return m.To
+	}
+	return nil
+}
+
+func (m *Proposal) GetPixel() []*Colour {
+	if m != nil {
+		return m.Pixel
+	}
+	return nil
+}
+
+func (m *Proposal) GetDescription() string {
+	if m != nil {
+		return m.Description
+	}
+	return ""
+}
+
 type Purchase struct {
 	Colour               *Colour   `protobuf:"bytes,1,opt,name=colour,proto3" json:"colour,omitempty"`
 	Location             *Location `protobuf:"bytes,2,opt,name=location,proto3" json:"location,omitempty"`
 	Price                uint32    `protobuf:"varint,3,opt,name=price,proto3" json:"price,omitempty"`
 	Tax                  uint32    `protobuf:"varint,4,opt,name=tax,proto3" json:"tax,omitempty"`
+	Commitment           []byte    `protobuf:"bytes,5,opt,name=commitment,proto3" json:"commitment,omitempty"`
+	Nonce                []byte    `protobuf:"bytes,6,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	Pool                 string    `protobuf:"bytes,7,opt,name=pool,proto3" json:"pool,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
 	XXX_unrecognized     []byte    `json:"-"`
 	XXX_sizecache        int32     `json:"-"`
@@ -323,7 +769,7 @@ func (m *Purchase) Reset()         { *m = Purchase{} }
 func (m *Purchase) String() string { return proto.CompactTextString(m) }
 func (*Purchase) ProtoMessage()    {}
 func (*Purchase) Descriptor() ([]byte, []int) {
-	return fileDescriptor_b8cfc2a33b1d9e1a, []int{4}
+	return fileDescriptor_b8cfc2a33b1d9e1a, []int{5}
 }
 
 func (m *Purchase) XXX_Unmarshal(b []byte) error {
@@ -372,45 +818,443 @@ func (m *Purchase) GetTax() uint32 {
 	return 0
 }
 
+func (m *Purchase) GetCommitment() []byte {
+	if m != nil {
+		return m.Commitment
+	}
+	return nil
+}
+
+func (m *Purchase) GetNonce() []byte {
+	if m != nil {
+		return m.Nonce
+	}
+	return nil
+}
+
+func (m *Purchase) GetPool() string {
+	if m != nil {
+		return m.Pool
+	}
+	return ""
+}
+
+type Grant struct {
+	Alias                string   `protobuf:"bytes,1,opt,name=alias,proto3" json:"alias,omitempty"`
+	Amount               uint64   `protobuf:"varint,2,opt,name=amount,proto3" json:"amount,omitempty"`
+	Reason               string   `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Grant) Reset()         { *m = Grant{} }
+func (m *Grant) String() string { return proto.CompactTextString(m) }
+func (*Grant) ProtoMessage()    {}
+func (*Grant) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b8cfc2a33b1d9e1a, []int{6}
+}
+
+func (m *Grant) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Grant.Unmarshal(m, b)
+}
+func (m *Grant) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Grant.Marshal(b, m, deterministic)
+}
+func (m *Grant) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Grant.Merge(m, src)
+}
+func (m *Grant) XXX_Size() int {
+	return xxx_messageInfo_Grant.Size(m)
+}
+func (m *Grant) XXX_DiscardUnknown() {
+	xxx_messageInfo_Grant.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Grant proto.InternalMessageInfo
+
+func (m *Grant) GetAlias() string {
+	if m != nil {
+		return m.Alias
+	}
+	return ""
+}
+
+func (m *Grant) GetAmount() uint64 {
+	if m != nil {
+		return m.Amount
+	}
+	return 0
+}
+
+func (m *Grant) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+type Amendment struct {
+	Width                uint32   `protobuf:"varint,1,opt,name=width,proto3" json:"width,omitempty"`
+	Height               uint32   `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`
+	Depth                uint32   `protobuf:"varint,3,opt,name=depth,proto3" json:"depth,omitempty"`
+	Reason               string   `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Amendment) Reset()         { *m = Amendment{} }
+func (m *Amendment) String() string { return proto.CompactTextString(m) }
+func (*Amendment) ProtoMessage()    {}
+func (*Amendment) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b8cfc2a33b1d9e1a, []int{7}
+}
+
+func (m *Amendment) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Amendment.Unmarshal(m, b)
+}
+func (m *Amendment) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Amendment.Marshal(b, m, deterministic)
+}
+func (m *Amendment) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Amendment.Merge(m, src)
+}
+func (m *Amendment) XXX_Size() int {
+	return xxx_messageInfo_Amendment.Size(m)
+}
+func (m *Amendment) XXX_DiscardUnknown() {
+	xxx_messageInfo_Amendment.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Amendment proto.InternalMessageInfo
+
+func (m *Amendment) GetWidth() uint32 {
+	if m != nil {
+		return m.Width
+	}
+	return 0
+}
+
+func (m *Amendment) GetHeight() uint32 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+func (m *Amendment) GetDepth() uint32 {
+	if m != nil {
+		return m.Depth
+	}
+	return 0
+}
+
+func (m *Amendment) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+type Settlement struct {
+	Timestamp            uint64      `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Foreclosed           []*Location `protobuf:"bytes,2,rep,name=foreclosed,proto3" json:"foreclosed,omitempty"`
+	Collected            uint64      `protobuf:"varint,3,opt,name=collected,proto3" json:"collected,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *Settlement) Reset()         { *m = Settlement{} }
+func (m *Settlement) String() string { return proto.CompactTextString(m) }
+func (*Settlement) ProtoMessage()    {}
+func (*Settlement) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b8cfc2a33b1d9e1a, []int{8}
+}
+
+func (m *Settlement) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Settlement.Unmarshal(m, b)
+}
+func (m *Settlement) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Settlement.Marshal(b, m, deterministic)
+}
+func (m *Settlement) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Settlement.Merge(m, src)
+}
+func (m *Settlement) XXX_Size() int {
+	return xxx_messageInfo_Settlement.Size(m)
+}
+func (m *Settlement) XXX_DiscardUnknown() {
+	xxx_messageInfo_Settlement.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Settlement proto.InternalMessageInfo
+
+func (m *Settlement) GetTimestamp() uint64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+func (m *Settlement) GetForeclosed() []*Location {
+	if m != nil {
+		return m.Foreclosed
+	}
+	return nil
+}
+
+func (m *Settlement) GetCollected() uint64 {
+	if m != nil {
+		return m.Collected
+	}
+	return 0
+}
+
+type Moderation struct {
+	Action               Action    `protobuf:"varint,1,opt,name=action,proto3,enum=colour.Action" json:"action,omitempty"`
+	From                 *Location `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	To                   *Location `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
+	Height               uint64    `protobuf:"varint,4,opt,name=height,proto3" json:"height,omitempty"`
+	Alias                string    `protobuf:"bytes,5,opt,name=alias,proto3" json:"alias,omitempty"`
+	Reason               string    `protobuf:"bytes,6,opt,name=reason,proto3" json:"reason,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *Moderation) Reset()         { *m = Moderation{} }
+func (m *Moderation) String() string { return proto.CompactTextString(m) }
+func (*Moderation) ProtoMessage()    {}
+func (*Moderation) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b8cfc2a33b1d9e1a, []int{9}
+}
+
+func (m *Moderation) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Moderation.Unmarshal(m, b)
+}
+func (m *Moderation) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Moderation.Marshal(b, m, deterministic)
+}
+func (m *Moderation) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Moderation.Merge(m, src)
+}
+func (m *Moderation) XXX_Size() int {
+	return xxx_messageInfo_Moderation.Size(m)
+}
+func (m *Moderation) XXX_DiscardUnknown() {
+	xxx_messageInfo_Moderation.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Moderation proto.InternalMessageInfo
+
+func (m *Moderation) GetAction() Action {
+	if m != nil {
+		return m.Action
+	}
+	return Action_UNKNOWN_ACTION
+}
+
+func (m *Moderation) GetFrom() *Location {
+	if m != nil {
+		return m.From
+	}
+	return nil
+}
+
+func (m *Moderation) GetTo() *Location {
+	if m != nil {
+		return m.To
+	}
+	return nil
+}
+
+func (m *Moderation) GetHeight() uint64 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+func (m *Moderation) GetAlias() string {
+	if m != nil {
+		return m.Alias
+	}
+	return ""
+}
+
+func (m *Moderation) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+type Alias struct {
+	Alias                string   `protobuf:"bytes,1,opt,name=alias,proto3" json:"alias,omitempty"`
+	PublicKey            []byte   `protobuf:"bytes,2,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	PublicFormat         uint32   `protobuf:"varint,3,opt,name=public_format,json=publicFormat,proto3" json:"public_format,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Alias) Reset()         { *m = Alias{} }
+func (m *Alias) String() string { return proto.CompactTextString(m) }
+func (*Alias) ProtoMessage()    {}
+func (*Alias) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b8cfc2a33b1d9e1a, []int{10}
+}
+
+func (m *Alias) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Alias.Unmarshal(m, b)
+}
+func (m *Alias) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Alias.Marshal(b, m, deterministic)
+}
+func (m *Alias) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Alias.Merge(m, src)
+}
+func (m *Alias) XXX_Size() int {
+	return xxx_messageInfo_Alias.Size(m)
+}
+func (m *Alias) XXX_DiscardUnknown() {
+	xxx_messageInfo_Alias.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Alias proto.InternalMessageInfo
+
+func (m *Alias) GetAlias() string {
+	if m != nil {
+		return m.Alias
+	}
+	return ""
+}
+
+func (m *Alias) GetPublicKey() []byte {
+	if m != nil {
+		return m.PublicKey
+	}
+	return nil
+}
+
+func (m *Alias) GetPublicFormat() uint32 {
+	if m != nil {
+		return m.PublicFormat
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterEnum("colour.Mode", Mode_name, Mode_value)
+	proto.RegisterEnum("colour.Dimension", Dimension_name, Dimension_value)
+	proto.RegisterEnum("colour.Blend", Blend_name, Blend_value)
+	proto.RegisterEnum("colour.Weighting", Weighting_name, Weighting_value)
+	proto.RegisterEnum("colour.Action", Action_name, Action_value)
 	proto.RegisterType((*Canvas)(nil), "colour.Canvas")
 	proto.RegisterType((*Colour)(nil), "colour.Colour")
 	proto.RegisterType((*Location)(nil), "colour.Location")
 	proto.RegisterType((*Vote)(nil), "colour.Vote")
+	proto.RegisterType((*Proposal)(nil), "colour.Proposal")
 	proto.RegisterType((*Purchase)(nil), "colour.Purchase")
+	proto.RegisterType((*Grant)(nil), "colour.Grant")
+	proto.RegisterType((*Amendment)(nil), "colour.Amendment")
+	proto.RegisterType((*Settlement)(nil), "colour.Settlement")
+	proto.RegisterType((*Moderation)(nil), "colour.Moderation")
+	proto.RegisterType((*Alias)(nil), "colour.Alias")
 }
 
 func init() { proto.RegisterFile("colour.proto", fileDescriptor_b8cfc2a33b1d9e1a) }
 
 var fileDescriptor_b8cfc2a33b1d9e1a = []byte{
-	// 448 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x92, 0xdb, 0x8a, 0xdb, 0x30,
-	0x10, 0x86, 0xab, 0x8d, 0x63, 0xb2, 0x93, 0x03, 0xee, 0xd0, 0x83, 0x2f, 0x8d, 0x0b, 0x25, 0x94,
-	0x92, 0x85, 0xf4, 0x09, 0x1c, 0xc7, 0x0b, 0x25, 0x4e, 0xb2, 0x88, 0xb6, 0x4b, 0x97, 0x42, 0x50,
-	0x6c, 0x35, 0x36, 0x38, 0x91, 0xd1, 0x2a, 0x4d, 0x36, 0x4f, 0xd0, 0x27, 0xe9, 0x73, 0x16, 0xc9,
-	0x72, 0x7b, 0xd1, 0xeb, 0x5e, 0x79, 0xbe, 0x99, 0x9f, 0x99, 0x7f, 0xc6, 0x82, 0x41, 0x26, 0x2a,
-	0x71, 0x94, 0x93, 0x5a, 0x0a, 0x25, 0xd0, 0x6d, 0x28, 0xfc, 0x45, 0xc0, 0x8d, 0xd9, 0xe1, 0x07,
-	0x7b, 0x44, 0x04, 0xe7, 0xc0, 0xf6, 0xdc, 0x27, 0x01, 0x19, 0x5f, 0x53, 0x13, 0xe3, 0x0b, 0xe8,
-	0x9e, 0xca, 0x5c, 0x15, 0xfe, 0x55, 0x40, 0xc6, 0x43, 0xda, 0x00, 0xbe, 0x02, 0xb7, 0xe0, 0xe5,
-	0xae, 0x50, 0x7e, 0xc7, 0xa4, 0x2d, 0x69, 0x75, 0xce, 0x6b, 0x55, 0xf8, 0x4e, 0xa3, 0x36, 0x80,
-	0x01, 0x38, 0x7b, 0x91, 0x73, 0xbf, 0x1b, 0x90, 0xf1, 0x68, 0x3a, 0x98, 0x58, 0x1f, 0x4b, 0x91,
-	0x73, 0x6a, 0x2a, 0x18, 0x82, 0xf3, 0xbd, 0xac, 0x2a, 0xdf, 0x0d, 0xc8, 0xb8, 0x3f, 0x1d, 0xb5,
-	0x8a, 0xd8, 0x7c, 0xa8, 0xa9, 0x85, 0x0f, 0xe0, 0x36, 0x8c, 0x1e, 0x74, 0x24, 0xcf, 0x8d, 0xcd,
-	0x21, 0xd5, 0xa1, 0x9e, 0xbb, 0x93, 0x9c, 0x1f, 0x5a, 0x97, 0x06, 0xf4, 0x3e, 0xdb, 0xea, 0xc8,
-	0xad, 0x47, 0x13, 0x6b, 0x25, 0xab, 0xea, 0x82, 0xb5, 0x0e, 0x0d, 0x84, 0x33, 0xe8, 0xa5, 0x22,
-	0x63, 0xaa, 0x14, 0x07, 0x1c, 0x00, 0x39, 0xd9, 0xde, 0xe4, 0xa4, 0xe9, 0x6c, 0xbb, 0x92, 0xb3,
-	0xa6, 0x27, 0xdb, 0x8e, 0x3c, 0x69, 0xba, 0xd8, 0x3e, 0xe4, 0x12, 0x7e, 0x03, 0xe7, 0x8b, 0x50,
-	0x1c, 0xdf, 0x82, 0x3d, 0xad, 0x69, 0xf2, 0xef, 0x36, 0xb6, 0x8a, 0xef, 0xa1, 0x57, 0xd9, 0x99,
-	0x66, 0x40, 0x7f, 0xea, 0xb5, 0xca, 0xd6, 0x0b, 0xfd, 0xa3, 0x08, 0x7f, 0x12, 0xe8, 0xdd, 0x1d,
-	0x65, 0x56, 0xb0, 0xc7, 0xff, 0x34, 0x42, 0x9f, 0xa6, 0x96, 0x65, 0xd6, 0xde, 0xab, 0x01, 0x7d,
-	0x6c, 0xc5, 0xce, 0x76, 0x4d, 0x1d, 0xbe, 0xab, 0xc1, 0xd1, 0xbf, 0x0e, 0x3d, 0x18, 0x7c, 0x5e,
-	0x2d, 0x56, 0xeb, 0xfb, 0xd5, 0x66, 0xb9, 0x9e, 0x27, 0xde, 0x33, 0x9d, 0xb9, 0xa5, 0x49, 0xb2,
-	0xb9, 0x5d, 0xd3, 0x4d, 0x94, 0xa6, 0x1e, 0xc1, 0x21, 0x5c, 0xcf, 0x93, 0xe5, 0x3a, 0xa6, 0x51,
-	0xfc, 0xd5, 0xbb, 0x42, 0x00, 0x77, 0x19, 0xd1, 0x45, 0xf2, 0xc9, 0xeb, 0xe0, 0x4b, 0x78, 0x4e,
-	0xa3, 0xf9, 0xc7, 0x38, 0x4a, 0x37, 0x7f, 0x25, 0x0e, 0x22, 0x8c, 0xda, 0xb4, 0x95, 0x76, 0x67,
-	0x0b, 0x78, 0x9d, 0x89, 0xfd, 0x84, 0x55, 0x5c, 0x15, 0xbc, 0x64, 0x27, 0x26, 0xb9, 0xdd, 0x63,
-	0xd6, 0x6f, 0x56, 0xbe, 0xd3, 0x6f, 0xfa, 0xe1, 0xcd, 0xae, 0x54, 0xc5, 0x71, 0x3b, 0xc9, 0xc4,
-	0xfe, 0x26, 0xb2, 0xe2, 0x7b, 0x26, 0x79, 0x9a, 0xc6, 0x37, 0x8d, 0x7e, 0x27, 0xb6, 0xae, 0x79,
-	0xff, 0x1f, 0x7e, 0x07, 0x00, 0x00, 0xff, 0xff, 0xb7, 0x55, 0x9a, 0xad, 0x0f, 0x03, 0x00, 0x00,
+	// 1400 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x56, 0x4b, 0x73, 0xdb, 0x38,
+	0x12, 0x36, 0x25, 0xea, 0xd5, 0x7a, 0x98, 0xc6, 0xe6, 0x81, 0x64, 0xf3, 0x50, 0x94, 0x6c, 0x56,
+	0xeb, 0xda, 0x72, 0xb6, 0xb2, 0xa7, 0xad, 0x3d, 0xd1, 0xb2, 0xbc, 0xeb, 0x32, 0x65, 0x69, 0x60,
+	0x3b, 0xae, 0xe4, 0xa2, 0x82, 0x48, 0x58, 0x62, 0x85, 0x24, 0x54, 0x10, 0x14, 0xcb, 0x39, 0xcd,
+	0x7d, 0xaa, 0xe6, 0x47, 0xcc, 0x4f, 0x99, 0x9f, 0x30, 0xbf, 0x68, 0x0a, 0x0f, 0x4a, 0x76, 0xe2,
+	0x64, 0x4e, 0x73, 0x12, 0xbe, 0xaf, 0x9b, 0xdd, 0x8d, 0xee, 0x0f, 0x80, 0xa0, 0x11, 0xf2, 0x84,
+	0x2f, 0xc5, 0xde, 0x5c, 0x70, 0xc9, 0x51, 0xd9, 0xa0, 0xce, 0x8f, 0x55, 0x28, 0xf7, 0x68, 0xf6,
+	0x89, 0x2e, 0x10, 0x02, 0x37, 0xa3, 0x29, 0xc3, 0x4e, 0xdb, 0xe9, 0xd6, 0x88, 0x5e, 0xa3, 0x7b,
+	0x50, 0xba, 0x8a, 0x23, 0x39, 0xc3, 0x85, 0xb6, 0xd3, 0x6d, 0x12, 0x03, 0xd0, 0x03, 0x28, 0xcf,
+	0x58, 0x3c, 0x9d, 0x49, 0x5c, 0xd4, 0xb4, 0x45, 0xca, 0x3b, 0x62, 0x73, 0x39, 0xc3, 0xae, 0xf1,
+	0xd6, 0x00, 0xb5, 0xc1, 0x4d, 0x79, 0xc4, 0x70, 0xa9, 0xed, 0x74, 0x5b, 0x6f, 0x1b, 0x7b, 0xb6,
+	0x8e, 0x01, 0x8f, 0x18, 0xd1, 0x16, 0xd4, 0x01, 0xf7, 0x32, 0x4e, 0x12, 0x5c, 0x6e, 0x3b, 0xdd,
+	0xfa, 0xdb, 0x56, 0xee, 0xd1, 0xd3, 0x3f, 0x44, 0xdb, 0x54, 0x4e, 0xb6, 0x92, 0x2c, 0x93, 0xb8,
+	0x62, 0x72, 0x1a, 0x84, 0xde, 0x40, 0x2d, 0x8a, 0x53, 0x96, 0x2d, 0x62, 0x9e, 0xe1, 0xaa, 0x4e,
+	0xb1, 0x93, 0x07, 0x38, 0xc8, 0x0d, 0x64, 0xe3, 0x83, 0xfe, 0x06, 0xad, 0x4b, 0x41, 0x53, 0x36,
+	0x8e, 0x96, 0x82, 0x4a, 0xf5, 0x55, 0x4d, 0x07, 0x6c, 0x6a, 0xf6, 0xc0, 0x92, 0xe8, 0x25, 0x94,
+	0x26, 0x09, 0xcb, 0x22, 0x0c, 0x3a, 0x66, 0x33, 0x8f, 0xb9, 0xaf, 0x48, 0x62, 0x6c, 0xa8, 0x0b,
+	0x95, 0x39, 0x4d, 0x98, 0x94, 0x0c, 0xd7, 0xdb, 0xc5, 0x3b, 0x6a, 0xcf, 0xcd, 0xaa, 0x35, 0xfc,
+	0x2a, 0x63, 0x02, 0x37, 0x74, 0x77, 0x0d, 0x40, 0x4f, 0xa0, 0xa6, 0x1a, 0x20, 0xa8, 0xe4, 0x02,
+	0x37, 0xdb, 0xc5, 0x6e, 0x8d, 0x6c, 0x08, 0xb5, 0xe5, 0x09, 0xcd, 0x32, 0x16, 0xe1, 0x96, 0x36,
+	0x59, 0xa4, 0xf8, 0x4b, 0xba, 0x0c, 0x99, 0xc4, 0xdb, 0x6d, 0xa7, 0xeb, 0x12, 0x8b, 0xd0, 0x4b,
+	0x68, 0xd2, 0x65, 0xa8, 0xaa, 0x1f, 0x2f, 0x24, 0x15, 0x12, 0x7b, 0xda, 0xdc, 0xb0, 0xe4, 0xa9,
+	0xe2, 0xd0, 0x3f, 0xc0, 0x9b, 0xc4, 0x51, 0x14, 0x67, 0xd3, 0x4d, 0x03, 0x76, 0x74, 0x03, 0xb6,
+	0x2d, 0xbf, 0x6e, 0xc1, 0xdf, 0x61, 0x5b, 0xb0, 0x4f, 0x8c, 0x26, 0x1b, 0x4f, 0xa4, 0x3d, 0x5b,
+	0x86, 0x5e, 0x3b, 0x3e, 0x82, 0xaa, 0xa4, 0xab, 0xb1, 0xa0, 0x92, 0xe1, 0xbf, 0x68, 0x8f, 0x8a,
+	0xa4, 0x2b, 0x42, 0x25, 0x43, 0x4f, 0x01, 0x94, 0x69, 0xce, 0x44, 0xcc, 0x23, 0x7c, 0x4f, 0x1b,
+	0x6b, 0x92, 0xae, 0x46, 0x9a, 0x40, 0xcf, 0xa1, 0x2e, 0xf8, 0x32, 0x8b, 0x6c, 0xc1, 0xf7, 0x75,
+	0xc1, 0xa0, 0x29, 0x53, 0xee, 0x0b, 0x68, 0x18, 0x87, 0x84, 0x65, 0x53, 0x39, 0xc3, 0x0f, 0x74,
+	0x04, 0xf3, 0x51, 0xa0, 0x29, 0xa5, 0x80, 0x2b, 0xad, 0xbf, 0x38, 0x9b, 0xe2, 0x87, 0xb7, 0x15,
+	0x70, 0x91, 0x1b, 0xc8, 0xc6, 0x47, 0xcd, 0xe2, 0x13, 0x97, 0x4c, 0x60, 0xac, 0xdb, 0x6a, 0x80,
+	0xd2, 0xc5, 0x15, 0x17, 0x1f, 0xc7, 0x72, 0x26, 0xd8, 0x62, 0xc6, 0x93, 0x08, 0x3f, 0xd2, 0xd5,
+	0x34, 0x15, 0x7b, 0x96, 0x93, 0xaa, 0xf9, 0x73, 0x2a, 0x94, 0x0e, 0x1f, 0xeb, 0x49, 0x5a, 0xa4,
+	0x0a, 0x35, 0xab, 0xf1, 0x24, 0xe1, 0xe1, 0x47, 0xfc, 0xd7, 0xb6, 0xd3, 0x6d, 0x90, 0xba, 0xe1,
+	0xf6, 0x15, 0x85, 0xda, 0x50, 0x8f, 0xd8, 0x22, 0x14, 0xf1, 0x5c, 0xf7, 0xf2, 0x89, 0xfe, 0xfe,
+	0x26, 0x85, 0x3c, 0x28, 0x4a, 0x3a, 0xc5, 0x4f, 0x75, 0x5d, 0x6a, 0x89, 0x30, 0x54, 0x92, 0x38,
+	0x64, 0xd9, 0x82, 0xe1, 0x67, 0xda, 0x3f, 0x87, 0x4a, 0x3b, 0x72, 0xb6, 0x4c, 0x27, 0x19, 0x8d,
+	0x13, 0xfc, 0x5c, 0x67, 0xdb, 0x10, 0x6a, 0xcc, 0x46, 0x15, 0x63, 0xc1, 0xc2, 0x78, 0x1e, 0xab,
+	0x82, 0xdb, 0x3a, 0xec, 0xb6, 0xe1, 0x49, 0x4e, 0x77, 0x3e, 0x40, 0xd9, 0xa8, 0x55, 0xa5, 0x17,
+	0x2c, 0xd2, 0x17, 0x40, 0x93, 0xa8, 0xa5, 0x6a, 0xd5, 0x54, 0x30, 0x96, 0xe5, 0xe7, 0x5f, 0x03,
+	0x75, 0x53, 0x4c, 0x92, 0x25, 0xb3, 0xa7, 0x5f, 0xaf, 0x95, 0x27, 0x4d, 0xe6, 0x33, 0x9a, 0x9f,
+	0x7d, 0x0d, 0x3a, 0xfb, 0x50, 0x0d, 0x78, 0x68, 0x54, 0xd2, 0x00, 0xe7, 0xca, 0xc6, 0x76, 0xae,
+	0x14, 0x5a, 0xd9, 0xa8, 0xce, 0x4a, 0xa1, 0x6b, 0x1b, 0xce, 0xb9, 0x56, 0xe8, 0xb3, 0x8d, 0xe3,
+	0x7c, 0xee, 0xfc, 0x5c, 0x00, 0xf7, 0x1d, 0x97, 0x0c, 0xbd, 0x06, 0x7b, 0x6b, 0xe9, 0x28, 0x5f,
+	0x1f, 0x36, 0x6b, 0x45, 0xff, 0x84, 0x6a, 0x62, 0x93, 0xea, 0x0c, 0xf5, 0xb7, 0x5e, 0xee, 0x99,
+	0x17, 0x43, 0xd6, 0x1e, 0xe8, 0x31, 0x54, 0x23, 0x96, 0xb0, 0xa9, 0x12, 0x6f, 0x51, 0xb7, 0x78,
+	0x8d, 0x51, 0x1b, 0x0a, 0x92, 0x63, 0xf7, 0x1b, 0x31, 0x0a, 0x92, 0xab, 0xaf, 0xe7, 0x82, 0xcf,
+	0xf9, 0x82, 0x26, 0xfa, 0x82, 0x6b, 0x90, 0x35, 0x56, 0x2d, 0xc9, 0x78, 0x16, 0x32, 0x7d, 0xaf,
+	0xb9, 0xc4, 0x00, 0x35, 0x51, 0xc1, 0xa4, 0xa0, 0xa1, 0xb9, 0xc9, 0x1a, 0x24, 0x87, 0xe8, 0x35,
+	0x94, 0x26, 0x54, 0x86, 0x33, 0x5c, 0x6d, 0x17, 0xef, 0x4c, 0x68, 0xcc, 0x9d, 0x5f, 0x1c, 0xa8,
+	0x8e, 0xf2, 0x24, 0x37, 0x37, 0xeb, 0xfc, 0xe1, 0x66, 0xcd, 0x86, 0x0a, 0xdf, 0xd9, 0xd0, 0x2b,
+	0x28, 0xcd, 0xe3, 0x15, 0x4b, 0x70, 0xf1, 0xce, 0x0b, 0xcd, 0x18, 0xbf, 0x94, 0xb2, 0xfb, 0x95,
+	0x94, 0x3b, 0xbf, 0xa9, 0x22, 0x97, 0x22, 0x9c, 0xd1, 0xc5, 0x9f, 0x35, 0xb9, 0x7b, 0x50, 0x9a,
+	0x8b, 0x38, 0xcc, 0x75, 0x68, 0x80, 0x39, 0x43, 0x2b, 0x2b, 0x1f, 0xb5, 0x44, 0xcf, 0x00, 0x42,
+	0x9e, 0xa6, 0xb1, 0x4c, 0xd5, 0x29, 0x30, 0x53, 0xba, 0xc1, 0xdc, 0x9e, 0x53, 0x23, 0x9f, 0x13,
+	0x02, 0x77, 0xce, 0x79, 0xa2, 0x87, 0x54, 0x23, 0x7a, 0xdd, 0x19, 0x40, 0xe9, 0x7f, 0x82, 0x9a,
+	0x4f, 0x68, 0x12, 0xd3, 0x85, 0x7d, 0x2c, 0x0d, 0x50, 0x77, 0x03, 0x4d, 0xf9, 0x32, 0x93, 0xba,
+	0x78, 0x97, 0x58, 0xa4, 0x78, 0xc1, 0xe8, 0x82, 0x67, 0x56, 0x60, 0x16, 0x75, 0xa6, 0x50, 0xf3,
+	0x53, 0x96, 0x45, 0x79, 0x15, 0xe6, 0xa9, 0x75, 0xee, 0x7e, 0x6a, 0x0b, 0x77, 0x3f, 0xb5, 0xc5,
+	0x9b, 0x4f, 0xed, 0x26, 0x91, 0x7b, 0x2b, 0xd1, 0x67, 0x80, 0x53, 0x26, 0x65, 0xc2, 0x74, 0x26,
+	0x75, 0x73, 0xc4, 0x29, 0x5b, 0x48, 0x9a, 0xce, 0x75, 0x36, 0x97, 0x6c, 0x08, 0xf4, 0x2f, 0x80,
+	0x4b, 0x2e, 0x58, 0x98, 0xf0, 0x05, 0x8b, 0x70, 0xe1, 0x1b, 0x52, 0xbc, 0xe1, 0xa3, 0xe2, 0x85,
+	0x3c, 0x49, 0x58, 0x28, 0x59, 0xa4, 0xeb, 0x71, 0xc9, 0x86, 0xe8, 0xfc, 0xea, 0x00, 0x0c, 0xcc,
+	0x9b, 0xa6, 0x86, 0xf6, 0x1a, 0xca, 0x34, 0x5c, 0xab, 0xb5, 0xb5, 0x91, 0x82, 0xaf, 0x59, 0x62,
+	0xad, 0xe8, 0x15, 0xb8, 0x97, 0x82, 0xa7, 0xdf, 0x94, 0x81, 0xb6, 0x5a, 0x3d, 0x17, 0xbf, 0xa3,
+	0xe7, 0x4d, 0x03, 0x5d, 0x33, 0x93, 0x4d, 0x03, 0xcd, 0x04, 0x4b, 0x5f, 0x4c, 0xd0, 0x36, 0xb0,
+	0x7c, 0xab, 0x81, 0x14, 0x4a, 0xbe, 0x76, 0xb8, 0x7b, 0xf0, 0x4f, 0x01, 0xe6, 0xcb, 0x49, 0x12,
+	0x87, 0xe3, 0x8f, 0xec, 0x5a, 0x97, 0xdc, 0x20, 0x35, 0xc3, 0x1c, 0xb3, 0x6b, 0xf5, 0x30, 0x5b,
+	0xf3, 0x25, 0x17, 0x29, 0xcd, 0xff, 0x36, 0x35, 0x0c, 0x79, 0xa8, 0xb9, 0xdd, 0x9f, 0x1c, 0x70,
+	0x55, 0x9f, 0x90, 0x07, 0x8d, 0xf3, 0x93, 0xe3, 0x93, 0xe1, 0xc5, 0xc9, 0x78, 0x30, 0x3c, 0xe8,
+	0x7b, 0x5b, 0x8a, 0x39, 0x24, 0xfd, 0xfe, 0xf8, 0x70, 0x48, 0xc6, 0x7e, 0x10, 0x78, 0x0e, 0x6a,
+	0x42, 0xed, 0xa0, 0x3f, 0x18, 0xf6, 0x88, 0xdf, 0x7b, 0xef, 0x15, 0x10, 0x40, 0x79, 0xe0, 0x93,
+	0xe3, 0xfe, 0x99, 0x57, 0x44, 0xf7, 0x61, 0x87, 0xf8, 0x07, 0x47, 0x3d, 0x3f, 0x18, 0x6f, 0x5c,
+	0x5c, 0x84, 0xa0, 0x95, 0xd3, 0xd6, 0xb5, 0x84, 0xea, 0x50, 0xf1, 0xcf, 0x7b, 0x67, 0x47, 0xc3,
+	0x13, 0xaf, 0x8c, 0x1a, 0x50, 0x1d, 0x91, 0xe1, 0x68, 0x78, 0xea, 0x07, 0x5e, 0x65, 0xf7, 0x05,
+	0xd4, 0xd6, 0xff, 0x9e, 0x50, 0x0d, 0x4a, 0x81, 0xff, 0xbe, 0x4f, 0xbc, 0x2d, 0xb5, 0x3c, 0x24,
+	0xfe, 0xa0, 0xef, 0x39, 0xbb, 0xff, 0x87, 0x92, 0xfe, 0x33, 0x84, 0xb6, 0xa1, 0x7e, 0x3a, 0x3c,
+	0x27, 0xbd, 0xfe, 0x78, 0xf8, 0x4e, 0x3b, 0xd5, 0xa1, 0x42, 0xfa, 0xa3, 0xc0, 0xef, 0xf5, 0x3d,
+	0x47, 0xc5, 0x1d, 0x9c, 0x07, 0x67, 0x47, 0xa3, 0xc0, 0x56, 0x7a, 0xda, 0x23, 0xfd, 0xfe, 0x89,
+	0x57, 0x44, 0x15, 0x28, 0xfa, 0x07, 0x07, 0x9e, 0xbb, 0xfb, 0x1f, 0xa8, 0xad, 0x1f, 0x6a, 0x95,
+	0xa1, 0xff, 0xc3, 0xb9, 0x1f, 0x78, 0x5b, 0x68, 0x07, 0x9a, 0x23, 0x32, 0x1c, 0x1e, 0x8e, 0x87,
+	0x87, 0xe3, 0x8b, 0x21, 0x39, 0xf6, 0x1c, 0xd4, 0x02, 0xf0, 0x83, 0x60, 0x78, 0x31, 0x0e, 0x8e,
+	0x4e, 0xcf, 0xbc, 0xc2, 0xee, 0x7f, 0xa1, 0x6c, 0x84, 0xa3, 0x36, 0x98, 0xb7, 0xcd, 0x37, 0x7b,
+	0xda, 0x42, 0x55, 0x70, 0x07, 0xfe, 0xa9, 0xfa, 0x0e, 0xa0, 0x4c, 0xfa, 0xef, 0xfa, 0xe4, 0xcc,
+	0x2b, 0xa8, 0xbc, 0xfb, 0xfe, 0x89, 0x57, 0xdc, 0x3f, 0x86, 0x87, 0x21, 0x4f, 0xf7, 0xd4, 0x5f,
+	0xb4, 0x19, 0x8b, 0xe9, 0x15, 0x15, 0xcc, 0x6a, 0x68, 0xbf, 0x6e, 0x6e, 0xa6, 0x91, 0xe0, 0x92,
+	0x7f, 0x78, 0x39, 0x8d, 0xe5, 0x6c, 0x39, 0xd9, 0x0b, 0x79, 0xfa, 0xc6, 0xb7, 0xce, 0x17, 0x54,
+	0xb0, 0x20, 0xe8, 0xbd, 0x31, 0xfe, 0x53, 0x3e, 0x29, 0xeb, 0x3f, 0xd6, 0xff, 0xfe, 0x7d, 0x00,
+	0x81, 0x1a, 0x11, 0xe0, 0x68, 0x0b, 0x00, 0x00,
 }
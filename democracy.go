@@ -0,0 +1,483 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo
+
+import (
+	"bytes"
+	"context"
+	"github.com/AletheiaWareLLC/bcgo"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// CreateDelegation creates a vote which delegates the creator's votes to another alias.
+// The delegation covers the region from and to, inclusive, or the whole canvas if from is nil. Delegating to oneself revokes earlier delegations.
+func CreateDelegation(delegate string, from, to *Location) *Vote {
+	return &Vote{
+		Delegate: delegate,
+		Location: from,
+		To:       to,
+	}
+}
+
+func IsDelegation(vote *Vote) bool {
+	return vote.Delegate != ""
+}
+
+// IsDelegated returns true if the delegation covers the location.
+func IsDelegated(delegation *Vote, l *Location) bool {
+	if delegation.Location == nil {
+		return true
+	}
+	return InRegion(&Moderation{From: delegation.Location, To: delegation.To}, l)
+}
+
+// Ballot is a vote along with the record which cast it.
+type Ballot struct {
+	Entry *bcgo.BlockEntry
+	// Timestamp of the block containing the vote, which decides its round as record timestamps are set by their creator
+	BlockTimestamp uint64
+	Vote           *Vote
+}
+
+func (b *Ballot) Alias() string {
+	return b.Entry.Record.Creator
+}
+
+// Before returns true if the ballot was cast before the other, ties are broken by record hash.
+func (b *Ballot) Before(o *Ballot) bool {
+	if b.Entry.Record.Timestamp != o.Entry.Record.Timestamp {
+		return b.Entry.Record.Timestamp < o.Entry.Record.Timestamp
+	}
+	return bytes.Compare(b.Entry.RecordHash, o.Entry.RecordHash) < 0
+}
+
+// Tally counts direct votes and delegations, where each alias's latest direct vote for a location overrides any delegation.
+type Tally struct {
+	Direct      map[Point]map[string]*Ballot
+	Delegations map[string][]*Ballot
+	Aliases     map[string]bool
+}
+
+func NewTally() *Tally {
+	return &Tally{
+		Direct:      make(map[Point]map[string]*Ballot),
+		Delegations: make(map[string][]*Ballot),
+		Aliases:     make(map[string]bool),
+	}
+}
+
+// Add counts the ballot, ballots may be added in any order.
+func (t *Tally) Add(b *Ballot) {
+	alias := b.Alias()
+	t.Aliases[alias] = true
+	if IsDelegation(b.Vote) {
+		// Keep latest first
+		ds := append(t.Delegations[alias], b)
+		sort.Slice(ds, func(i, j int) bool {
+			return ds[j].Before(ds[i])
+		})
+		t.Delegations[alias] = ds
+		return
+	}
+	if b.Vote.Location == nil || b.Vote.Colour == nil {
+		return
+	}
+	p := NewPoint(b.Vote.Location)
+	votes, ok := t.Direct[p]
+	if !ok {
+		votes = make(map[string]*Ballot)
+		t.Direct[p] = votes
+	}
+	if existing, ok := votes[alias]; !ok || existing.Before(b) {
+		votes[alias] = b
+	}
+}
+
+// GetDelegate returns the alias to which the alias has delegated the location, or the empty string.
+func (t *Tally) GetDelegate(alias string, l *Location) string {
+	for _, d := range t.Delegations[alias] {
+		if IsDelegated(d.Vote, l) {
+			if d.Vote.Delegate == alias {
+				// Revoked
+				return ""
+			}
+			return d.Vote.Delegate
+		}
+	}
+	return ""
+}
+
+// Resolve returns the direct ballot which decides the alias's vote for the location, following delegations.
+// Nil is returned if the alias hasn't voted, or its delegations form a cycle.
+func (t *Tally) Resolve(alias string, p Point) *Ballot {
+	l := p.Location()
+	visited := make(map[string]bool)
+	for {
+		if b, ok := t.Direct[p][alias]; ok {
+			return b
+		}
+		visited[alias] = true
+		alias = t.GetDelegate(alias, l)
+		if alias == "" || visited[alias] {
+			return nil
+		}
+	}
+}
+
+// Own returns the ballot the alias cast itself for the location, either a direct vote or the delegation covering it.
+func (t *Tally) Own(alias string, p Point) *Ballot {
+	if b, ok := t.Direct[p][alias]; ok {
+		return b
+	}
+	l := p.Location()
+	for _, d := range t.Delegations[alias] {
+		if IsDelegated(d.Vote, l) {
+			return d
+		}
+	}
+	return nil
+}
+
+// Results returns the winning colour of each location, where each alias's vote is weighted by its own ballot.
+// The colour with the greatest weight wins, ties go to the colour whose earliest supporting ballot was cast first.
+func (t *Tally) Results(weighter VoteWeighter) map[Point]*Colour {
+	var aliases []string
+	for a := range t.Aliases {
+		aliases = append(aliases, a)
+	}
+	// Sum weights in alias order so results are identical on every node
+	sort.Strings(aliases)
+	results := make(map[Point]*Colour)
+	for p := range t.Direct {
+		type count struct {
+			colour *Colour
+			weight float64
+			first  *Ballot
+		}
+		counts := make(map[string]*count)
+		for _, a := range aliases {
+			b := t.Resolve(a, p)
+			if b == nil {
+				continue
+			}
+			hex := FormatHexColour(b.Vote.Colour)
+			c, ok := counts[hex]
+			if !ok {
+				c = &count{
+					colour: b.Vote.Colour,
+					first:  b,
+				}
+				counts[hex] = c
+			}
+			c.weight += weighter.Weight(a, t.Own(a, p))
+			if b.Before(c.first) {
+				c.first = b
+			}
+		}
+		var best *count
+		for _, c := range counts {
+			if best == nil || c.weight > best.weight || (c.weight == best.weight && c.first.Before(best.first)) {
+				best = c
+			}
+		}
+		if best != nil && best.weight > 0 {
+			results[p] = best.colour
+		}
+	}
+	return results
+}
+
+// DemocracyModel draws each location in the colour with the most votes, where aliases may delegate their votes to others.
+type DemocracyModel struct {
+	VoteModel
+	resultsLock sync.Mutex
+	// Incremented when votes are read, so results computed from earlier votes aren't cached
+	generation uint64
+	// Results of closed rounds
+	roundResults map[uint64]map[Point]*Colour
+	// Results locked in by the rounds before each round
+	lockedResults map[uint64]map[Point]*Colour
+}
+
+func NewDemocracyModel(node *bcgo.Node, listener bcgo.MiningListener, id string, canvas *Canvas, channel *bcgo.Channel, callback func()) *DemocracyModel {
+	m := &DemocracyModel{
+		VoteModel: VoteModel{
+			Votes: make(map[string]*Vote),
+		},
+	}
+	m.initialize(node, listener, id, canvas, channel, callback)
+	return m
+}
+
+// Bind reads the channel, and reads again as each voting round closes so the locked results are redrawn.
+func (m *DemocracyModel) Bind(ctx context.Context) {
+	m.readEachRound(m.bind(ctx, m.Read), m.Read)
+}
+
+// readEachRound calls read as each voting round closes, until the context is done.
+func (m *DemocracyModel) readEachRound(ctx context.Context, read func(context.Context)) {
+	if !HasRounds(m.Canvas) {
+		return
+	}
+	m.Go(func() {
+		for {
+			deadline, err := m.GetDeadline()
+			if err != nil {
+				return
+			}
+			var wait time.Duration
+			if now := bcgo.Timestamp(); deadline > now {
+				wait = time.Duration(deadline - now)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(wait):
+				read(ctx)
+			}
+		}
+	})
+}
+
+// Read reads the votes and discards the cached results.
+func (m *DemocracyModel) Read(ctx context.Context) {
+	m.VoteModel.Read(ctx)
+	m.resultsLock.Lock()
+	m.generation++
+	m.roundResults = nil
+	m.lockedResults = nil
+	m.resultsLock.Unlock()
+}
+
+// getCached returns the results cached for the round, and the generation to pass to setCached.
+func (m *DemocracyModel) getCached(cache *map[uint64]map[Point]*Colour, round uint64) (map[Point]*Colour, uint64) {
+	m.resultsLock.Lock()
+	defer m.resultsLock.Unlock()
+	return (*cache)[round], m.generation
+}
+
+// setCached caches the round's results, unless votes were read since they were computed.
+func (m *DemocracyModel) setCached(cache *map[uint64]map[Point]*Colour, round, generation uint64, results map[Point]*Colour) {
+	m.resultsLock.Lock()
+	defer m.resultsLock.Unlock()
+	if generation != m.generation {
+		return
+	}
+	if *cache == nil {
+		*cache = make(map[uint64]map[Point]*Colour)
+	}
+	(*cache)[round] = results
+}
+
+// Delegate delegates the node's votes in the region, or the whole canvas if from is nil, to the alias.
+func (m *DemocracyModel) Delegate(alias string, from, to *Location) error {
+	if err := m.CheckContributor(m.Node.Alias); err != nil {
+		return err
+	}
+	if from != nil {
+		if err := CheckBounds(m.Canvas, from); err != nil {
+			return err
+		}
+		if to != nil {
+			if err := CheckBounds(m.Canvas, to); err != nil {
+				return err
+			}
+		}
+	}
+	record, err := m.createVoteRecord(m.Context(), CreateDelegation(alias, from, to))
+	if err != nil {
+		return err
+	}
+	return m.WriteRecord(m.Channel, record)
+}
+
+// GetBallots returns the ballots read in the order they were cast, including retractions and omitting those hidden by moderation.
+func (m *DemocracyModel) GetBallots() []*Ballot {
+	m.Lock()
+	defer m.Unlock()
+	var ballots []*Ballot
+	for _, id := range m.Order {
+		vote, ok := m.Votes[id]
+		if !ok {
+			continue
+		}
+		entry := m.Entries[id]
+		switch {
+		case IsRetraction(vote):
+			// Retractions only remove votes so are always honoured
+		case IsDelegation(vote):
+			if m.IsBanned(id) {
+				continue
+			}
+		default:
+			// Batch votes count as a vote for each location
+			for _, v := range ExpandVote(vote) {
+				if v.Location == nil || m.IsHidden(id, v.Location) {
+					continue
+				}
+				ballots = append(ballots, &Ballot{
+					Entry:          entry,
+					BlockTimestamp: m.BlockTimestamps[id],
+					Vote:           v,
+				})
+			}
+			continue
+		}
+		ballots = append(ballots, &Ballot{
+			Entry:          entry,
+			BlockTimestamp: m.BlockTimestamps[id],
+			Vote:           vote,
+		})
+	}
+	return ballots
+}
+
+// TallyBallots returns a tally of the direct votes mined from and until the block timestamps, and the delegations mined until the latter.
+// Ballots retracted by their creator until the latter timestamp are not counted.
+func TallyBallots(ballots []*Ballot, from, until uint64) *Tally {
+	tally := NewTally()
+	for _, b := range RemoveRetracted(ballots, until) {
+		t := b.BlockTimestamp
+		if t >= until || (!IsDelegation(b.Vote) && t < from) {
+			continue
+		}
+		tally.Add(b)
+	}
+	return tally
+}
+
+// GetTally returns a tally of all the votes read.
+func (m *DemocracyModel) GetTally() *Tally {
+	return TallyBallots(m.GetBallots(), 0, math.MaxUint64)
+}
+
+// GetResults returns the colour of each location currently drawn.
+// With voting rounds this is the result of the previous rounds, otherwise it is the result of all votes.
+func (m *DemocracyModel) GetResults() map[Point]*Colour {
+	if !HasRounds(m.Canvas) {
+		return m.GetTally().Results(m.GetWeighter())
+	}
+	round, err := GetRound(m.Canvas, bcgo.Timestamp())
+	if err != nil {
+		return nil
+	}
+	return m.GetLockedResults(round)
+}
+
+// GetCurrentRound returns the current voting round.
+func (m *DemocracyModel) GetCurrentRound() (uint64, error) {
+	return GetRound(m.Canvas, bcgo.Timestamp())
+}
+
+// GetDeadline returns the timestamp at which the current voting round closes.
+func (m *DemocracyModel) GetDeadline() (uint64, error) {
+	round, err := m.GetCurrentRound()
+	if err != nil {
+		return 0, err
+	}
+	_, end, err := GetRoundWindow(m.Canvas, round)
+	return end, err
+}
+
+// GetRoundResults returns the winning colour of each location voted on during the round.
+// Results of closed rounds are cached until the votes are next read.
+func (m *DemocracyModel) GetRoundResults(round uint64) (map[Point]*Colour, error) {
+	start, end, err := GetRoundWindow(m.Canvas, round)
+	if err != nil {
+		return nil, err
+	}
+	cached, generation := m.getCached(&m.roundResults, round)
+	if cached != nil {
+		return cached, nil
+	}
+	if round == 0 {
+		// Votes cast before the first round count towards it
+		start = 0
+	}
+	results := TallyBallots(m.GetBallots(), start, end).Results(m.GetWeighter())
+	if end <= bcgo.Timestamp() {
+		m.setCached(&m.roundResults, round, generation, results)
+	}
+	return results, nil
+}
+
+// GetProvisionalResults returns the results of the current round so far.
+func (m *DemocracyModel) GetProvisionalResults() (map[Point]*Colour, error) {
+	round, err := m.GetCurrentRound()
+	if err != nil {
+		return nil, err
+	}
+	return m.GetRoundResults(round)
+}
+
+// GetLockedResults returns the colour of each location during the round, decided by the latest earlier round in which it received votes.
+func (m *DemocracyModel) GetLockedResults(round uint64) map[Point]*Colour {
+	cached, generation := m.getCached(&m.lockedResults, round)
+	if cached != nil {
+		return cached
+	}
+	ballots := m.GetBallots()
+	// Only rounds with direct votes can change the result
+	rounds := make(map[uint64]bool)
+	for _, b := range ballots {
+		if IsDelegation(b.Vote) || IsRetraction(b.Vote) {
+			continue
+		}
+		if r, err := GetRound(m.Canvas, b.BlockTimestamp); err == nil && r < round {
+			rounds[r] = true
+		}
+	}
+	var order []uint64
+	for r := range rounds {
+		order = append(order, r)
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return order[i] < order[j]
+	})
+	locked := make(map[Point]*Colour)
+	for _, r := range order {
+		results, err := m.GetRoundResults(r)
+		if err != nil {
+			continue
+		}
+		for p, c := range results {
+			locked[p] = c
+		}
+	}
+	m.setCached(&m.lockedResults, round, generation, locked)
+	return locked
+}
+
+// Draw calls the callback with the final opaque colour of each pixel, layers are flattened and frames are iterated in order.
+func (m *DemocracyModel) Draw(callback func(*Location, *Colour)) {
+	pixels := Composite(m.Canvas, GetState(m.Canvas, m))
+	for _, p := range SortPoints(pixels) {
+		callback(p.Location(), pixels[p])
+	}
+}
+
+// DrawLayers calls the callback with the winning colour of each location.
+func (m *DemocracyModel) DrawLayers(callback func(*Location, *Colour)) {
+	m.DrawBase(callback)
+	results := m.GetResults()
+	for _, p := range SortPoints(results) {
+		callback(p.Location(), results[p])
+	}
+}
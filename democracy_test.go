@@ -0,0 +1,116 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo_test
+
+import (
+	"context"
+	"github.com/AletheiaWareLLC/bcgo"
+	"github.com/AletheiaWareLLC/colourgo"
+	"github.com/AletheiaWareLLC/testinggo"
+	"testing"
+)
+
+func makeBallot(creator string, timestamp uint64, vote *colourgo.Vote) *colourgo.Ballot {
+	return &colourgo.Ballot{
+		Entry: &bcgo.BlockEntry{
+			RecordHash: []byte{byte(timestamp)},
+			Record: &bcgo.Record{
+				Creator:   creator,
+				Timestamp: timestamp,
+			},
+		},
+		BlockTimestamp: timestamp,
+		Vote:           vote,
+	}
+}
+
+func TestTally(t *testing.T) {
+	red := colourgo.CreateVote(0, 0, 0, 0, 255, 0, 0, 255)
+	blue := colourgo.CreateVote(0, 0, 0, 0, 0, 0, 255, 255)
+	origin := colourgo.Point{}
+	tally := colourgo.NewTally()
+	for _, b := range []*colourgo.Ballot{
+		makeBallot("Alice", 1, red),
+		makeBallot("Bob", 2, colourgo.CreateDelegation("Alice", nil, nil)),
+		makeBallot("Carol", 3, colourgo.CreateDelegation("Bob", nil, nil)),
+		makeBallot("Dan", 4, blue),
+		makeBallot("Erin", 5, blue),
+		// Cycle
+		makeBallot("Frank", 6, colourgo.CreateDelegation("Grace", nil, nil)),
+		makeBallot("Grace", 7, colourgo.CreateDelegation("Frank", nil, nil)),
+	} {
+		tally.Add(b)
+	}
+	if b := tally.Resolve("Carol", origin); b == nil || b.Alias() != "Alice" {
+		t.Errorf("Expected Carol's vote to resolve to Alice's, got %v", b)
+	}
+	if b := tally.Resolve("Frank", origin); b != nil {
+		t.Errorf("Expected cycle to resolve to no vote, got %v", b)
+	}
+	equal := &colourgo.EqualWeighter{}
+	// Alice, Bob and Carol outvote Dan and Erin
+	testinggo.AssertProtobufEqual(t, red.Colour, tally.Results(equal)[origin])
+
+	// Bob's direct vote overrides his delegation, and Carol follows Bob
+	tally.Add(makeBallot("Bob", 8, blue))
+	testinggo.AssertProtobufEqual(t, blue.Colour, tally.Results(equal)[origin])
+
+	// Bob revokes his delegation outside the region he votes in
+	tally.Add(makeBallot("Bob", 9, colourgo.CreateDelegation("Bob", &colourgo.Location{X: 1}, &colourgo.Location{X: 2})))
+	if d := tally.GetDelegate("Bob", &colourgo.Location{X: 1}); d != "" {
+		t.Errorf("Expected revoked delegation, got %s", d)
+	}
+	if d := tally.GetDelegate("Bob", &colourgo.Location{X: 3}); d != "Alice" {
+		t.Errorf("Expected delegation to Alice, got %s", d)
+	}
+}
+
+func TestDemocracyModel_LockedResults(t *testing.T) {
+	node := &bcgo.Node{
+		Alias:    "Alice",
+		Cache:    bcgo.NewMemoryCache(10),
+		Channels: make(map[string]*bcgo.Channel),
+	}
+	canvas, err := colourgo.CreateCanvas("TEST_CANVAS", 1, 1, 1, colourgo.Mode_DEMOCRACY)
+	testinggo.AssertNoError(t, err)
+	canvas.RoundLength = 10
+	channel := colourgo.OpenVoteChannel("TEST_ID")
+	model := colourgo.NewDemocracyModel(node, nil, "TEST_ID", canvas, channel, nil)
+	defer model.Close()
+	ballot := makeBallot("Alice", 1, colourgo.CreateVote(0, 0, 0, 0, 255, 0, 0, 255))
+	// Backdated into the first round, but mined in the second
+	ballot.BlockTimestamp = 15 * second
+	model.Votes["A"] = ballot.Vote
+	model.Entries["A"] = ballot.Entry
+	model.BlockTimestamps["A"] = ballot.BlockTimestamp
+	model.Order = []string{"A"}
+	origin := colourgo.Point{}
+	if c := model.GetLockedResults(1)[origin]; c != nil {
+		t.Errorf("Expected no result locked by the first round, got %v", c)
+	}
+	red := model.GetLockedResults(2)[origin]
+	if red == nil || red.Red != 255 {
+		t.Fatalf("Expected red locked by the second round, got %v", red)
+	}
+	// Results are cached until the votes are read again
+	model.Votes["A"] = colourgo.CreateVote(0, 0, 0, 0, 0, 0, 255, 255)
+	testinggo.AssertProtobufEqual(t, red, model.GetLockedResults(2)[origin])
+	model.Read(context.Background())
+	if c := model.GetLockedResults(2)[origin]; c == nil || c.Blue != 255 {
+		t.Errorf("Expected blue after reading, got %v", c)
+	}
+}
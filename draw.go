@@ -0,0 +1,312 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo
+
+import (
+	"fmt"
+)
+
+// Point is a comparable form of Location which can be used as a map key.
+type Point struct {
+	W, X, Y, Z uint32
+}
+
+func NewPoint(l *Location) Point {
+	return Point{
+		W: l.W,
+		X: l.X,
+		Y: l.Y,
+		Z: l.Z,
+	}
+}
+
+func (p Point) Location() *Location {
+	return &Location{
+		W: p.W,
+		X: p.X,
+		Y: p.Y,
+		Z: p.Z,
+	}
+}
+
+// BatchWriter is implemented by Models which can write many locations in a single operation.
+type BatchWriter interface {
+	WriteBatch([]*Location, *Colour) error
+}
+
+func EqualColour(a, b *Colour) bool {
+	return a.GetRed() == b.GetRed() && a.GetGreen() == b.GetGreen() && a.GetBlue() == b.GetBlue() && a.GetAlpha() == b.GetAlpha()
+}
+
+// GetState returns the current colour of each location in each layer or frame of the model.
+// Successive colours at the same location are blended according to the canvas blend mode, locations outside the canvas are omitted.
+func GetState(canvas *Canvas, model Model) map[Point]*Colour {
+	state := make(map[Point]*Colour)
+	model.DrawLayers(func(l *Location, c *Colour) {
+		if !IsInBounds(canvas, l) {
+			// Cropped by an amendment
+			return
+		}
+		p := NewPoint(l)
+		state[p] = BlendColour(canvas.Blend, state[p], c)
+	})
+	return state
+}
+
+// GetColourAt returns the colour of the given location in the state, or the canvas fill if it has not been drawn.
+func GetColourAt(canvas *Canvas, state map[Point]*Colour, p Point) *Colour {
+	if c, ok := state[p]; ok {
+		return c
+	}
+	return canvas.Fill
+}
+
+func newLocation(canvas *Canvas, w, z uint32, x, y int64) (*Location, error) {
+	if w >= GetCanvasExtent(canvas) || x < 0 || y < 0 || x >= int64(canvas.Width) || y >= int64(canvas.Height) || z >= GetCanvasDepth(canvas) {
+		return nil, fmt.Errorf(ERROR_OUT_OF_BOUNDS, w, x, y, z)
+	}
+	return &Location{
+		W: w,
+		X: uint32(x),
+		Y: uint32(y),
+		Z: z,
+	}, nil
+}
+
+// GetRegion returns every location in the region between the two corners inclusive, ordered by frame, layer, row and then column.
+func GetRegion(canvas *Canvas, from, to *Location) ([]*Location, error) {
+	var locations []*Location
+	for w := minUint32(from.W, to.W); w <= maxUint32(from.W, to.W); w++ {
+		for z := minUint32(from.Z, to.Z); z <= maxUint32(from.Z, to.Z); z++ {
+			for y := minUint32(from.Y, to.Y); y <= maxUint32(from.Y, to.Y); y++ {
+				for x := minUint32(from.X, to.X); x <= maxUint32(from.X, to.X); x++ {
+					l := &Location{W: w, X: x, Y: y, Z: z}
+					if err := CheckBounds(canvas, l); err != nil {
+						return nil, err
+					}
+					locations = append(locations, l)
+				}
+			}
+		}
+	}
+	return locations, nil
+}
+
+// FillRectangle returns the locations inside the rectangle with the given corners (inclusive).
+func FillRectangle(canvas *Canvas, w, z, x1, y1, x2, y2 uint32) ([]*Location, error) {
+	if x1 > x2 {
+		x1, x2 = x2, x1
+	}
+	if y1 > y2 {
+		y1, y2 = y2, y1
+	}
+	var locations []*Location
+	for y := int64(y1); y <= int64(y2); y++ {
+		for x := int64(x1); x <= int64(x2); x++ {
+			l, err := newLocation(canvas, w, z, x, y)
+			if err != nil {
+				return nil, err
+			}
+			locations = append(locations, l)
+		}
+	}
+	return locations, nil
+}
+
+// Line returns the locations on the line between the given points using Bresenham's algorithm.
+func Line(canvas *Canvas, w, z, x1, y1, x2, y2 uint32) ([]*Location, error) {
+	x, y := int64(x1), int64(y1)
+	dx, dy := int64(x2)-x, int64(y2)-y
+	sx, sy := int64(1), int64(1)
+	if dx < 0 {
+		dx = -dx
+		sx = -1
+	}
+	if dy < 0 {
+		dy = -dy
+		sy = -1
+	}
+	dy = -dy
+	e := dx + dy
+	var locations []*Location
+	for {
+		l, err := newLocation(canvas, w, z, x, y)
+		if err != nil {
+			return nil, err
+		}
+		locations = append(locations, l)
+		if x == int64(x2) && y == int64(y2) {
+			break
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y += sy
+		}
+	}
+	return locations, nil
+}
+
+// Circle returns the locations on the circle, or inside the disc if fill is true, with the given centre and radius.
+func Circle(canvas *Canvas, w, z, cx, cy, r uint32, fill bool) ([]*Location, error) {
+	points := make(map[Point]bool)
+	var locations []*Location
+	add := func(x, y int64) error {
+		l, err := newLocation(canvas, w, z, x, y)
+		if err != nil {
+			return err
+		}
+		p := NewPoint(l)
+		if !points[p] {
+			points[p] = true
+			locations = append(locations, l)
+		}
+		return nil
+	}
+	x0, y0, radius := int64(cx), int64(cy), int64(r)
+	// Midpoint circle algorithm
+	x, y := radius, int64(0)
+	e := 1 - radius
+	for x >= y {
+		if fill {
+			for _, row := range [][3]int64{
+				{y0 + y, x0 - x, x0 + x},
+				{y0 - y, x0 - x, x0 + x},
+				{y0 + x, x0 - y, x0 + y},
+				{y0 - x, x0 - y, x0 + y},
+			} {
+				for i := row[1]; i <= row[2]; i++ {
+					if err := add(i, row[0]); err != nil {
+						return nil, err
+					}
+				}
+			}
+		} else {
+			for _, o := range [][2]int64{
+				{x, y}, {y, x}, {-y, x}, {-x, y},
+				{-x, -y}, {-y, -x}, {y, -x}, {x, -y},
+			} {
+				if err := add(x0+o[0], y0+o[1]); err != nil {
+					return nil, err
+				}
+			}
+		}
+		y++
+		if e < 0 {
+			e += 2*y + 1
+		} else {
+			x--
+			e += 2*(y-x) + 1
+		}
+	}
+	return locations, nil
+}
+
+// FloodFill returns the locations in the contiguous region of the same colour as the origin, within the origin's plane.
+func FloodFill(canvas *Canvas, state map[Point]*Colour, origin *Location) ([]*Location, error) {
+	if err := CheckBounds(canvas, origin); err != nil {
+		return nil, err
+	}
+	start := NewPoint(origin)
+	target := GetColourAt(canvas, state, start)
+	visited := map[Point]bool{
+		start: true,
+	}
+	queue := []Point{start}
+	var locations []*Location
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		locations = append(locations, p.Location())
+		for _, o := range [][2]int64{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+			x, y := int64(p.X)+o[0], int64(p.Y)+o[1]
+			if x < 0 || y < 0 || x >= int64(canvas.Width) || y >= int64(canvas.Height) {
+				continue
+			}
+			n := Point{
+				W: p.W,
+				X: uint32(x),
+				Y: uint32(y),
+				Z: p.Z,
+			}
+			if visited[n] {
+				continue
+			}
+			visited[n] = true
+			if EqualColour(GetColourAt(canvas, state, n), target) {
+				queue = append(queue, n)
+			}
+		}
+	}
+	return locations, nil
+}
+
+// Paint writes the given colour to each location which would be changed by blending the colour into it.
+// The whole shape is rejected if any location is outside the canvas bounds.
+// Returns the number of locations written.
+func Paint(model Model, canvas *Canvas, locations []*Location, colour *Colour) (int, error) {
+	for _, l := range locations {
+		if err := CheckBounds(canvas, l); err != nil {
+			return 0, err
+		}
+	}
+	pending := GetChanges(canvas, GetState(canvas, model), locations, colour)
+	return WriteLocations(model, pending, colour)
+}
+
+// GetChanges returns the unique locations which would be changed by blending the colour into the state.
+func GetChanges(canvas *Canvas, state map[Point]*Colour, locations []*Location, colour *Colour) []*Location {
+	points := make(map[Point]bool)
+	var changes []*Location
+	for _, l := range locations {
+		p := NewPoint(l)
+		if points[p] {
+			continue
+		}
+		points[p] = true
+		current := GetColourAt(canvas, state, p)
+		if EqualColour(BlendColour(canvas.Blend, current, colour), current) {
+			continue
+		}
+		changes = append(changes, l)
+	}
+	return changes
+}
+
+// WriteLocations writes the colour to each location, as a batch if the model supports it.
+// Returns the number of locations written.
+func WriteLocations(model Model, locations []*Location, colour *Colour) (int, error) {
+	if len(locations) == 0 {
+		return 0, nil
+	}
+	if b, ok := model.(BatchWriter); ok {
+		if err := b.WriteBatch(locations, colour); err != nil {
+			return 0, err
+		}
+		return len(locations), nil
+	}
+	for i, l := range locations {
+		if err := model.Write(l, colour); err != nil {
+			return i, err
+		}
+	}
+	return len(locations), nil
+}
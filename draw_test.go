@@ -0,0 +1,120 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo_test
+
+import (
+	"github.com/AletheiaWareLLC/colourgo"
+	"github.com/AletheiaWareLLC/testinggo"
+	"testing"
+)
+
+func assertPoints(t *testing.T, expected []colourgo.Point, locations []*colourgo.Location) {
+	t.Helper()
+	if len(locations) != len(expected) {
+		t.Fatalf("Incorrect locations; expected %d, got '%d'", len(expected), len(locations))
+	}
+	points := make(map[colourgo.Point]bool)
+	for _, l := range locations {
+		points[colourgo.NewPoint(l)] = true
+	}
+	for _, p := range expected {
+		if !points[p] {
+			t.Errorf("Missing point; expected '%v'", p)
+		}
+	}
+}
+
+func TestFillRectangle(t *testing.T) {
+	canvas, err := colourgo.CreateCanvas("TEST_CANVAS", 4, 4, 1, colourgo.Mode_FREE_FOR_ALL)
+	testinggo.AssertNoError(t, err)
+	t.Run("Inside", func(t *testing.T) {
+		locations, err := colourgo.FillRectangle(canvas, 0, 0, 2, 1, 1, 2)
+		testinggo.AssertNoError(t, err)
+		assertPoints(t, []colourgo.Point{
+			{X: 1, Y: 1},
+			{X: 2, Y: 1},
+			{X: 1, Y: 2},
+			{X: 2, Y: 2},
+		}, locations)
+	})
+	t.Run("OutOfBounds", func(t *testing.T) {
+		_, err := colourgo.FillRectangle(canvas, 0, 0, 2, 2, 4, 3)
+		testinggo.AssertError(t, "Location out of bounds: 0,4,2,0", err)
+	})
+}
+
+func TestLine(t *testing.T) {
+	canvas, err := colourgo.CreateCanvas("TEST_CANVAS", 4, 4, 1, colourgo.Mode_FREE_FOR_ALL)
+	testinggo.AssertNoError(t, err)
+	locations, err := colourgo.Line(canvas, 0, 0, 0, 0, 3, 1)
+	testinggo.AssertNoError(t, err)
+	assertPoints(t, []colourgo.Point{
+		{X: 0, Y: 0},
+		{X: 1, Y: 0},
+		{X: 2, Y: 1},
+		{X: 3, Y: 1},
+	}, locations)
+}
+
+func TestCircle(t *testing.T) {
+	canvas, err := colourgo.CreateCanvas("TEST_CANVAS", 4, 4, 1, colourgo.Mode_FREE_FOR_ALL)
+	testinggo.AssertNoError(t, err)
+	t.Run("Outline", func(t *testing.T) {
+		locations, err := colourgo.Circle(canvas, 0, 0, 1, 1, 1, false)
+		testinggo.AssertNoError(t, err)
+		assertPoints(t, []colourgo.Point{
+			{X: 2, Y: 1},
+			{X: 1, Y: 2},
+			{X: 0, Y: 1},
+			{X: 1, Y: 0},
+		}, locations)
+	})
+	t.Run("Fill", func(t *testing.T) {
+		locations, err := colourgo.Circle(canvas, 0, 0, 1, 1, 1, true)
+		testinggo.AssertNoError(t, err)
+		assertPoints(t, []colourgo.Point{
+			{X: 2, Y: 1},
+			{X: 1, Y: 1},
+			{X: 1, Y: 2},
+			{X: 0, Y: 1},
+			{X: 1, Y: 0},
+		}, locations)
+	})
+	t.Run("OutOfBounds", func(t *testing.T) {
+		_, err := colourgo.Circle(canvas, 0, 0, 0, 0, 1, false)
+		testinggo.AssertError(t, "Location out of bounds: 0,-1,0,0", err)
+	})
+}
+
+func TestFloodFill(t *testing.T) {
+	canvas, err := colourgo.CreateCanvas("TEST_CANVAS", 3, 3, 1, colourgo.Mode_FREE_FOR_ALL)
+	testinggo.AssertNoError(t, err)
+	black := &colourgo.Colour{Alpha: 255}
+	// Wall down the middle column
+	state := map[colourgo.Point]*colourgo.Colour{
+		{X: 1, Y: 0}: black,
+		{X: 1, Y: 1}: black,
+		{X: 1, Y: 2}: black,
+	}
+	locations, err := colourgo.FloodFill(canvas, state, &colourgo.Location{})
+	testinggo.AssertNoError(t, err)
+	assertPoints(t, []colourgo.Point{
+		{X: 0, Y: 0},
+		{X: 0, Y: 1},
+		{X: 0, Y: 2},
+	}, locations)
+}
@@ -0,0 +1,101 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo
+
+import (
+	"fmt"
+)
+
+const (
+	EVENT_BUFFER_SIZE = 64
+)
+
+type EventType int
+
+const (
+	// Channel was read and the model updated
+	EVENT_READ EventType = iota
+	// Record was written to the cache
+	EVENT_WRITE
+	// Record could not be written to the cache
+	EVENT_WRITE_FAILED
+	// Block was mined
+	EVENT_MINED
+	// Mining failed
+	EVENT_MINING_FAILED
+	// Channel could not be refreshed from the cache or network
+	EVENT_REFRESH_FAILED
+	// Channel could not be read
+	EVENT_READ_FAILED
+	// Record could not be parsed
+	EVENT_CORRUPT_RECORD
+	// Record signature could not be verified
+	EVENT_UNVERIFIED_RECORD
+	// Record was created by an alias banned from the canvas
+	EVENT_BANNED_RECORD
+	// Record was rejected by the model's rules, such as an unaffordable purchase
+	EVENT_REJECTED_RECORD
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EVENT_READ:
+		return "Read"
+	case EVENT_WRITE:
+		return "Write"
+	case EVENT_WRITE_FAILED:
+		return "Write Failed"
+	case EVENT_MINED:
+		return "Mined"
+	case EVENT_MINING_FAILED:
+		return "Mining Failed"
+	case EVENT_REFRESH_FAILED:
+		return "Refresh Failed"
+	case EVENT_READ_FAILED:
+		return "Read Failed"
+	case EVENT_CORRUPT_RECORD:
+		return "Corrupt Record"
+	case EVENT_UNVERIFIED_RECORD:
+		return "Unverified Record"
+	case EVENT_BANNED_RECORD:
+		return "Banned Record"
+	case EVENT_REJECTED_RECORD:
+		return "Rejected Record"
+	default:
+		return fmt.Sprintf("EventType(%d)", int(t))
+	}
+}
+
+// Event describes something which happened to a model, Error is set for failures.
+type Event struct {
+	Type    EventType
+	Channel string
+	// Base64 encoded hash of the record or block concerned, if any
+	Hash  string
+	Error error
+}
+
+func (e *Event) String() string {
+	s := e.Type.String() + " " + e.Channel
+	if e.Hash != "" {
+		s += " " + e.Hash
+	}
+	if e.Error != nil {
+		s += ": " + e.Error.Error()
+	}
+	return s
+}
@@ -0,0 +1,167 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo
+
+import (
+	"context"
+	"fmt"
+	"github.com/AletheiaWareLLC/bcgo"
+	"github.com/golang/protobuf/proto"
+	"time"
+)
+
+const (
+	ERROR_PARENT_NOT_FOUND = "Parent canvas not found: %s"
+	ERROR_PIN_UNSUPPORTED  = "Canvas model cannot be pinned: %s"
+)
+
+// IsFork returns true if the canvas was forked from another.
+func IsFork(canvas *Canvas) bool {
+	return canvas.Parent != ""
+}
+
+// GetContributionChannelName returns the name of the channel holding the canvas' votes or purchases, according to its mode.
+func GetContributionChannelName(id string, canvas *Canvas) string {
+	switch canvas.Mode {
+	case Mode_MARKET, Mode_RADICAL_MARKET, Mode_AUCTION:
+		return GetPurchaseChannelName(id)
+	default:
+		return GetVoteChannelName(id)
+	}
+}
+
+// ForkCanvas creates a copy of the parent canvas with a new name, starting from the parent's state as of the given block of its contribution channel.
+// The parent should be as amended when the block was mined, see GetAmendedParent.
+// Roles are not copied, the creator of the fork becomes its owner.
+func ForkCanvas(parent *Canvas, parentID string, block []byte, name string) *Canvas {
+	fork := proto.Clone(parent).(*Canvas)
+	fork.Name = name
+	fork.Parent = parentID
+	fork.ParentBlock = block
+	fork.Owner = ""
+	fork.Moderator = nil
+	fork.Banned = nil
+	return fork
+}
+
+// CreateFork creates a fork of the parent canvas starting from the latest block of its contribution channel known to the node.
+func CreateFork(node *bcgo.Node, parentID string, parent *Canvas, name string) (*Canvas, error) {
+	if err := ValidateName(name); err != nil {
+		return nil, err
+	}
+	reference, err := node.Cache.GetHead(GetContributionChannelName(parentID, parent))
+	if err != nil {
+		return nil, err
+	}
+	block, err := node.Cache.GetBlock(reference.BlockHash)
+	if err != nil {
+		return nil, err
+	}
+	amended, err := GetAmendedParent(node, parentID, parent, block.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+	return ForkCanvas(amended, parentID, reference.BlockHash, name), nil
+}
+
+// GetAmendedParent returns the parent canvas as amended by the blocks of its amendment channel mined at or before the block timestamp.
+func GetAmendedParent(node *bcgo.Node, parentID string, parent *Canvas, blockTimestamp uint64) (*Canvas, error) {
+	verifier := NewAliasVerifier(node)
+	amendments := NewAmendments(parent, OpenAmendments(node, parentID, parent, verifier))
+	if err := amendments.Channel.LoadCachedHead(node.Cache); err != nil && !IsHeadNotFound(amendments.Channel.Name, err) {
+		return nil, err
+	}
+	if err := amendments.Update(amendments.Channel.Head, node.Cache, node.Network, verifier); err != nil {
+		return nil, err
+	}
+	return amendments.GetCanvas(blockTimestamp), nil
+}
+
+// GetParentCanvas reads the canvas' parent from the canvas channel of the year encoded in its ID, or of each year if the ID is a legacy one.
+func GetParentCanvas(node *bcgo.Node, canvas *Canvas) (*Canvas, error) {
+	names, hash, err := GetCanvasChannelNamesForID(canvas.Parent, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		canvases := node.GetOrOpenChannel(name, func() *bcgo.Channel {
+			return OpenColourChannel(name)
+		})
+		if err := canvases.LoadCachedHead(node.Cache); err != nil && !IsHeadNotFound(name, err) {
+			return nil, err
+		}
+		var parent *Canvas
+		if err := GetCanvas(canvases, node.Cache, node.Network, node.Alias, node.Key, hash, func(entry *bcgo.BlockEntry, key []byte, c *Canvas) error {
+			parent = c
+			return bcgo.StopIterationError{}
+		}); err != nil {
+			if _, ok := err.(bcgo.StopIterationError); !ok {
+				return nil, err
+			}
+		}
+		if parent != nil {
+			return parent, nil
+		}
+	}
+	return nil, fmt.Errorf(ERROR_PARENT_NOT_FOUND, canvas.Parent)
+}
+
+// GetBaseImage returns the state of the canvas' parent as of the block it was forked from, or nil if the canvas isn't a fork.
+// Every channel of the parent is refreshed and then read as of the time that block was mined, including its amendments.
+func GetBaseImage(ctx context.Context, node *bcgo.Node, canvas *Canvas) (map[Point]*Colour, error) {
+	if !IsFork(canvas) {
+		return nil, nil
+	}
+	parent, err := GetParentCanvas(node, canvas)
+	if err != nil {
+		return nil, err
+	}
+	model, err := GetModel(node, nil, canvas.Parent, parent, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer model.Close()
+	p, ok := model.(pinner)
+	if !ok {
+		return nil, fmt.Errorf(ERROR_PIN_UNSUPPORTED, canvas.Parent)
+	}
+	if err := model.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	if err := p.SetPin(canvas.ParentBlock); err != nil {
+		return nil, err
+	}
+	model.Read(ctx)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	block, err := bcgo.GetBlock(GetContributionChannelName(canvas.Parent, parent), node.Cache, node.Network, canvas.ParentBlock)
+	if err != nil {
+		return nil, err
+	}
+	// Bounded by the parent as amended when the block was mined
+	amended, err := GetAmendedParent(node, canvas.Parent, parent, block.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+	return GetState(amended, model), nil
+}
+
+// pinner is implemented by models which can read their channels as of a given block.
+type pinner interface {
+	SetPin([]byte) error
+}
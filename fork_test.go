@@ -0,0 +1,156 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"github.com/AletheiaWareLLC/bcgo"
+	"github.com/AletheiaWareLLC/colourgo"
+	"github.com/AletheiaWareLLC/testinggo"
+	"testing"
+)
+
+func TestFork(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	testinggo.AssertNoError(t, err)
+	node := &bcgo.Node{
+		Alias:    "Alice",
+		Key:      key,
+		Cache:    bcgo.NewMemoryCache(10),
+		Channels: make(map[string]*bcgo.Channel),
+	}
+	registerAlias(t, node)
+	parent, err := colourgo.CreateCanvas("Parent", 2, 2, 1, colourgo.Mode_FREE_FOR_ALL)
+	testinggo.AssertNoError(t, err)
+	parentID, err := colourgo.WriteCanvas(context.Background(), node, nil, parent)
+	testinggo.AssertNoError(t, err)
+
+	votes := node.GetOrOpenChannel(colourgo.GetVoteChannelName(parentID), func() *bcgo.Channel {
+		return colourgo.OpenVoteChannel(parentID)
+	})
+	red := colourgo.CreateVote(0, 0, 0, 0, 255, 0, 0, 255)
+	record, err := colourgo.CreateVoteRecord(node.Alias, node.Key, red)
+	testinggo.AssertNoError(t, err)
+	writeAndMine(t, node, votes, record)
+
+	fork, err := colourgo.CreateFork(node, parentID, parent, "Fork")
+	testinggo.AssertNoError(t, err)
+	if fork.Parent != parentID || fork.Owner != "" || len(fork.ParentBlock) == 0 {
+		t.Errorf("Unexpected fork %v", fork)
+	}
+
+	// Votes on the parent after the fork aren't included
+	blue := colourgo.CreateVote(0, 1, 0, 0, 0, 0, 255, 255)
+	record, err = colourgo.CreateVoteRecord(node.Alias, node.Key, blue)
+	testinggo.AssertNoError(t, err)
+	writeAndMine(t, node, votes, record)
+
+	// Nor is moderation of the parent after the fork
+	moderation := node.GetOrOpenChannel(colourgo.GetModerationChannelName(parentID), func() *bcgo.Channel {
+		return colourgo.OpenModerationChannel(parentID)
+	})
+	record, err = colourgo.CreateModerationRecord(node.Alias, node.Key, colourgo.CreateMask(&colourgo.Location{}, &colourgo.Location{}, "Spam"))
+	testinggo.AssertNoError(t, err)
+	writeAndMine(t, node, moderation, record)
+
+	base, err := colourgo.GetBaseImage(context.Background(), node, fork)
+	testinggo.AssertNoError(t, err)
+	if len(base) != 1 {
+		t.Fatalf("Expected 1 location in base image, got %d", len(base))
+	}
+	testinggo.AssertProtobufEqual(t, red.Colour, base[colourgo.Point{}])
+}
+
+func TestFork_Amended(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	testinggo.AssertNoError(t, err)
+	node := &bcgo.Node{
+		Alias:    "Alice",
+		Key:      key,
+		Cache:    bcgo.NewMemoryCache(10),
+		Channels: make(map[string]*bcgo.Channel),
+	}
+	registerAlias(t, node)
+	parent, err := colourgo.CreateCanvas("Parent", 2, 2, 1, colourgo.Mode_FREE_FOR_ALL)
+	testinggo.AssertNoError(t, err)
+	parent.Owner = node.Alias
+	parentID, err := colourgo.WriteCanvas(context.Background(), node, nil, parent)
+	testinggo.AssertNoError(t, err)
+	amendments := colourgo.OpenAmendments(node, parentID, parent, colourgo.NewAliasVerifier(node))
+	amend := func(width, height uint32) {
+		record, err := colourgo.CreateAmendmentRecord(node.Alias, node.Key, colourgo.CreateAmendment(width, height, 1, "Resize"))
+		testinggo.AssertNoError(t, err)
+		writeAndMine(t, node, amendments, record)
+	}
+	amend(4, 4)
+
+	votes := node.GetOrOpenChannel(colourgo.GetVoteChannelName(parentID), func() *bcgo.Channel {
+		return colourgo.OpenVoteChannel(parentID)
+	})
+	red := colourgo.CreateVote(0, 3, 3, 0, 255, 0, 0, 255)
+	record, err := colourgo.CreateVoteRecord(node.Alias, node.Key, red)
+	testinggo.AssertNoError(t, err)
+	writeAndMine(t, node, votes, record)
+
+	fork, err := colourgo.CreateFork(node, parentID, parent, "Fork")
+	testinggo.AssertNoError(t, err)
+	if fork.Width != 4 || fork.Height != 4 {
+		t.Errorf("Expected fork of expanded parent, got %dx%d", fork.Width, fork.Height)
+	}
+
+	// Cropping the parent after the fork doesn't crop the base image
+	amend(1, 1)
+
+	base, err := colourgo.GetBaseImage(context.Background(), node, fork)
+	testinggo.AssertNoError(t, err)
+	if len(base) != 1 {
+		t.Fatalf("Expected 1 location in base image, got %d", len(base))
+	}
+	testinggo.AssertProtobufEqual(t, red.Colour, base[colourgo.Point{X: 3, Y: 3}])
+}
+
+func TestGetParentCanvas_Legacy(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	testinggo.AssertNoError(t, err)
+	node := &bcgo.Node{
+		Alias:    "Alice",
+		Key:      key,
+		Cache:    bcgo.NewMemoryCache(10),
+		Channels: make(map[string]*bcgo.Channel),
+	}
+	parent, err := colourgo.CreateCanvas("Parent", 2, 2, 1, colourgo.Mode_FREE_FOR_ALL)
+	testinggo.AssertNoError(t, err)
+	record, err := colourgo.CreateCanvasRecord(node.Alias, node.Key, parent)
+	testinggo.AssertNoError(t, err)
+	// Listed on a past year's channel
+	canvases := node.GetOrOpenChannel("Colour-Canvas-2019", func() *bcgo.Channel {
+		return colourgo.OpenCanvasChannelForYear("2019")
+	})
+	hash := writeAndMine(t, node, canvases, record)
+	// Closed channels are loaded from the cache
+	delete(node.Channels, canvases.Name)
+
+	fork := colourgo.ForkCanvas(parent, base64.RawURLEncoding.EncodeToString(hash), nil, "Fork")
+	got, err := colourgo.GetParentCanvas(node, fork)
+	testinggo.AssertNoError(t, err)
+	if got.Name != "Parent" {
+		t.Errorf("Expected Parent, got %s", got.Name)
+	}
+}
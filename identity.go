@@ -0,0 +1,267 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"fmt"
+	"github.com/AletheiaWareLLC/bcgo"
+	"github.com/AletheiaWareLLC/cryptogo"
+	"github.com/golang/protobuf/proto"
+	"sync"
+)
+
+const (
+	// Mirror aliasgo.ALIAS and aliasgo.ALIAS_THRESHOLD, as with the Alias message, since aliasgo is not a dependency of this module.
+	// Replace them with aliasgo's once it is added to go.mod.
+	ALIAS           = "Alias"
+	ALIAS_THRESHOLD = bcgo.THRESHOLD_G
+
+	ERROR_ALIAS_RECORD_INVALID = "Alias record invalid: %s"
+	ERROR_ALIAS_BANNED         = "Alias banned from canvas: %s"
+	ERROR_NOT_OWNER            = "Alias is not the owner of canvas: %s"
+	ERROR_NOT_MODERATOR        = "Alias is not a moderator of canvas: %s"
+)
+
+// Identity is the public key and registration details of an alias.
+type Identity struct {
+	Alias     string
+	PublicKey *rsa.PublicKey
+	// Timestamp of the alias record in nanoseconds
+	Timestamp uint64
+}
+
+// UnmarshalIdentity parses an alias record from the alias channel and checks it was signed by the key it registers.
+func UnmarshalIdentity(record *bcgo.Record) (*Identity, error) {
+	a := &Alias{}
+	if err := proto.Unmarshal(record.Payload, a); err != nil {
+		return nil, err
+	}
+	if a.Alias == "" || a.Alias != record.Creator {
+		return nil, fmt.Errorf(ERROR_ALIAS_RECORD_INVALID, "alias does not match creator")
+	}
+	publicKey, err := cryptogo.ParseRSAPublicKey(a.PublicKey, cryptogo.PublicKeyFormat(a.PublicFormat))
+	if err != nil {
+		return nil, err
+	}
+	if err := VerifyRecord(publicKey, record); err != nil {
+		return nil, err
+	}
+	return &Identity{
+		Alias:     a.Alias,
+		PublicKey: publicKey,
+		Timestamp: record.Timestamp,
+	}, nil
+}
+
+// CreateAliasRecord creates a record registering the alias with the key's public key, to be written to the alias channel.
+func CreateAliasRecord(alias string, key *rsa.PrivateKey) (*bcgo.Record, error) {
+	data, err := proto.Marshal(&Alias{
+		Alias:        alias,
+		PublicKey:    cryptogo.RSAPublicKeyToPKCS1Bytes(&key.PublicKey),
+		PublicFormat: uint32(cryptogo.PublicKeyFormat_PKCS1_PUBLIC),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return CreateRecord(alias, key, data)
+}
+
+func OpenAliasChannel() *bcgo.Channel {
+	return bcgo.OpenPoWChannel(ALIAS, ALIAS_THRESHOLD)
+}
+
+// AliasChannelResolver is an AliasResolver which reads identities from the alias channel.
+// The first registration of an alias is authoritative.
+type AliasChannelResolver struct {
+	sync.Mutex
+	Channel    *bcgo.Channel
+	Cache      bcgo.Cache
+	Network    bcgo.Network
+	Identities map[string]*Identity
+	head       []byte
+}
+
+func NewAliasChannelResolver(channel *bcgo.Channel, cache bcgo.Cache, network bcgo.Network) *AliasChannelResolver {
+	return &AliasChannelResolver{
+		Channel:    channel,
+		Cache:      cache,
+		Network:    network,
+		Identities: make(map[string]*Identity),
+	}
+}
+
+// Update refreshes the channel and reads any alias records added since the last update.
+func (r *AliasChannelResolver) Update() error {
+	r.Lock()
+	defer r.Unlock()
+	// Channel may be empty or only known to peers
+	r.Channel.LoadCachedHead(r.Cache)
+	if r.Network != nil {
+		if err := r.Channel.Pull(r.Cache, r.Network); err != nil {
+			return err
+		}
+	}
+	head := r.Channel.Head
+	var identities []*Identity
+	if err := bcgo.Iterate(r.Channel.Name, head, nil, r.Cache, r.Network, func(hash []byte, block *bcgo.Block) error {
+		if bytes.Equal(hash, r.head) {
+			return bcgo.StopIterationError{}
+		}
+		for _, entry := range block.Entry {
+			identity, err := UnmarshalIdentity(entry.Record)
+			if err != nil {
+				// Ignore invalid registrations
+				continue
+			}
+			identities = append(identities, identity)
+		}
+		return nil
+	}); err != nil {
+		switch err.(type) {
+		case bcgo.StopIterationError:
+			// Do nothing
+		default:
+			return err
+		}
+	}
+	// Apply oldest first so earlier registrations take precedence
+	for i := len(identities) - 1; i >= 0; i-- {
+		identity := identities[i]
+		if existing, ok := r.Identities[identity.Alias]; !ok || identity.Timestamp < existing.Timestamp {
+			r.Identities[identity.Alias] = identity
+		}
+	}
+	r.head = head
+	return nil
+}
+
+// GetIdentity returns the identity registered for the alias, updating from the channel if it isn't yet known.
+func (r *AliasChannelResolver) GetIdentity(alias string) (*Identity, error) {
+	r.Lock()
+	identity, ok := r.Identities[alias]
+	r.Unlock()
+	if ok {
+		return identity, nil
+	}
+	if err := r.Update(); err != nil {
+		return nil, err
+	}
+	r.Lock()
+	defer r.Unlock()
+	identity, ok = r.Identities[alias]
+	if !ok {
+		return nil, fmt.Errorf(ERROR_UNKNOWN_ALIAS, alias)
+	}
+	return identity, nil
+}
+
+func (r *AliasChannelResolver) GetPublicKey(alias string) (*rsa.PublicKey, error) {
+	identity, err := r.GetIdentity(alias)
+	if err != nil {
+		return nil, err
+	}
+	return identity.PublicKey, nil
+}
+
+// IsOwner returns true if the alias owns the canvas.
+func IsOwner(canvas *Canvas, alias string) bool {
+	return canvas.Owner != "" && canvas.Owner == alias
+}
+
+// IsModerator returns true if the alias owns or moderates the canvas.
+func IsModerator(canvas *Canvas, alias string) bool {
+	if IsOwner(canvas, alias) {
+		return true
+	}
+	for _, m := range canvas.Moderator {
+		if m == alias {
+			return true
+		}
+	}
+	return false
+}
+
+// IsBanned returns true if the alias is banned from contributing to the canvas.
+func IsBanned(canvas *Canvas, alias string) bool {
+	for _, b := range canvas.Banned {
+		if b == alias {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckBanned returns an error if the alias is banned from contributing to the canvas.
+func CheckBanned(canvas *Canvas, alias string) error {
+	if IsBanned(canvas, alias) {
+		return fmt.Errorf(ERROR_ALIAS_BANNED, alias)
+	}
+	return nil
+}
+
+// Role is the part an alias must play in a canvas to write to a channel.
+type Role int
+
+const (
+	// Any alias which isn't banned
+	ROLE_CONTRIBUTOR Role = iota
+	// The owner or a moderator
+	ROLE_MODERATOR
+	// Only the owner
+	ROLE_OWNER
+)
+
+// CheckRole returns an error if the alias doesn't play the role in the canvas.
+func CheckRole(canvas *Canvas, alias string, role Role) error {
+	switch role {
+	case ROLE_OWNER:
+		if !IsOwner(canvas, alias) {
+			return fmt.Errorf(ERROR_NOT_OWNER, alias)
+		}
+	case ROLE_MODERATOR:
+		if !IsModerator(canvas, alias) {
+			return fmt.Errorf(ERROR_NOT_MODERATOR, alias)
+		}
+	default:
+		return CheckBanned(canvas, alias)
+	}
+	return nil
+}
+
+// RoleValidator ensures every record in a channel was created by an alias playing the role in the canvas.
+// Contributors banned by a moderator are rejected in blocks mined after the ban, if Moderations is set.
+type RoleValidator struct {
+	Canvas      *Canvas
+	Role        Role
+	Moderations *Moderations
+}
+
+func (r *RoleValidator) Validate(channel *bcgo.Channel, cache bcgo.Cache, network bcgo.Network, hash []byte, block *bcgo.Block) error {
+	return bcgo.Iterate(channel.Name, hash, block, cache, network, func(h []byte, b *bcgo.Block) error {
+		for _, entry := range b.Entry {
+			if err := CheckRole(r.Canvas, entry.Record.Creator, r.Role); err != nil {
+				return err
+			}
+			if r.Role == ROLE_CONTRIBUTOR && r.Moderations.IsBanned(entry.Record.Creator, b.Timestamp) {
+				return fmt.Errorf(ERROR_ALIAS_BANNED, entry.Record.Creator)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,112 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"github.com/AletheiaWareLLC/bcgo"
+	"github.com/AletheiaWareLLC/colourgo"
+	"github.com/AletheiaWareLLC/testinggo"
+	"testing"
+)
+
+func makeAliasRecord(t *testing.T, alias string, key *rsa.PrivateKey) *bcgo.Record {
+	t.Helper()
+	record, err := colourgo.CreateAliasRecord(alias, key)
+	testinggo.AssertNoError(t, err)
+	return record
+}
+
+func registerAlias(t *testing.T, node *bcgo.Node) {
+	t.Helper()
+	writeAndMine(t, node, node.GetOrOpenChannel(colourgo.ALIAS, colourgo.OpenAliasChannel), makeAliasRecord(t, node.Alias, node.Key))
+}
+
+func TestUnmarshalIdentity(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	testinggo.AssertNoError(t, err)
+	t.Run("Valid", func(t *testing.T) {
+		identity, err := colourgo.UnmarshalIdentity(makeAliasRecord(t, "Alice", key))
+		testinggo.AssertNoError(t, err)
+		if identity.Alias != "Alice" {
+			t.Errorf("Incorrect alias; expected Alice, got '%s'", identity.Alias)
+		}
+		if identity.PublicKey.N.Cmp(key.PublicKey.N) != 0 {
+			t.Error("Incorrect public key")
+		}
+	})
+	t.Run("CreatorMismatch", func(t *testing.T) {
+		record := makeAliasRecord(t, "Alice", key)
+		record.Creator = "Mallory"
+		_, err := colourgo.UnmarshalIdentity(record)
+		testinggo.AssertError(t, "Alias record invalid: alias does not match creator", err)
+	})
+}
+
+func TestRoles(t *testing.T) {
+	canvas := &colourgo.Canvas{
+		Owner:     "Alice",
+		Moderator: []string{"Bob"},
+		Banned:    []string{"Mallory"},
+	}
+	for _, test := range []struct {
+		alias     string
+		owner     bool
+		moderator bool
+		banned    bool
+	}{
+		{"Alice", true, true, false},
+		{"Bob", false, true, false},
+		{"Carol", false, false, false},
+		{"Mallory", false, false, true},
+	} {
+		if got := colourgo.IsOwner(canvas, test.alias); got != test.owner {
+			t.Errorf("%s: expected owner %t, got %t", test.alias, test.owner, got)
+		}
+		if got := colourgo.IsModerator(canvas, test.alias); got != test.moderator {
+			t.Errorf("%s: expected moderator %t, got %t", test.alias, test.moderator, got)
+		}
+		if got := colourgo.IsBanned(canvas, test.alias); got != test.banned {
+			t.Errorf("%s: expected banned %t, got %t", test.alias, test.banned, got)
+		}
+	}
+	testinggo.AssertError(t, "Alias banned from canvas: Mallory", colourgo.CheckBanned(canvas, "Mallory"))
+	testinggo.AssertNoError(t, colourgo.CheckRole(canvas, "Alice", colourgo.ROLE_OWNER))
+	testinggo.AssertError(t, "Alias is not the owner of canvas: Bob", colourgo.CheckRole(canvas, "Bob", colourgo.ROLE_OWNER))
+	testinggo.AssertNoError(t, colourgo.CheckRole(canvas, "Bob", colourgo.ROLE_MODERATOR))
+	testinggo.AssertError(t, "Alias is not a moderator of canvas: Carol", colourgo.CheckRole(canvas, "Carol", colourgo.ROLE_MODERATOR))
+	testinggo.AssertNoError(t, colourgo.CheckRole(canvas, "Carol", colourgo.ROLE_CONTRIBUTOR))
+	testinggo.AssertError(t, "Alias banned from canvas: Mallory", colourgo.CheckRole(canvas, "Mallory", colourgo.ROLE_CONTRIBUTOR))
+}
+
+func TestCreateCanvasRecord_Owner(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	testinggo.AssertNoError(t, err)
+	canvas, err := colourgo.CreateCanvas("TEST_CANVAS", 1, 1, 1, colourgo.Mode_FREE_FOR_ALL)
+	testinggo.AssertNoError(t, err)
+	record, err := colourgo.CreateCanvasRecord("Alice", key, canvas)
+	testinggo.AssertNoError(t, err)
+	if canvas.Owner != "" {
+		t.Errorf("Expected caller's canvas to be unchanged, got owner '%s'", canvas.Owner)
+	}
+	written, err := colourgo.UnmarshalCanvas(record.Payload)
+	testinggo.AssertNoError(t, err)
+	if written.Owner != "Alice" {
+		t.Errorf("Incorrect owner; expected Alice, got '%s'", written.Owner)
+	}
+}
@@ -0,0 +1,144 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	_ "image/png"
+	"io"
+	"math"
+	"sort"
+)
+
+// Pricer is implemented by Models which charge to write a location.
+type Pricer interface {
+	GetPrice(*Location) uint64
+}
+
+type ImportOptions struct {
+	// Location of the top left corner of the image
+	W, X, Y, Z uint32
+	// Dither the image to the canvas palette instead of snapping each pixel to the nearest colour
+	Dither bool
+	// Report the changes without writing them
+	DryRun bool
+}
+
+type ImportReport struct {
+	// Number of pixels which differ from the current state
+	Pixels int
+	// Total price to write the pixels, if the model charges for writes
+	Cost uint64
+}
+
+// DecodeImage reads a PNG or GIF image.
+func DecodeImage(reader io.Reader) (image.Image, error) {
+	img, _, err := image.Decode(reader)
+	return img, err
+}
+
+// ConvertImage returns the colour of each pixel in the image, restricted to the canvas palette if one is declared.
+// Fully transparent pixels are omitted.
+func ConvertImage(canvas *Canvas, img image.Image, dither bool) map[image.Point]*Colour {
+	bounds := img.Bounds()
+	if len(canvas.Palette) > 0 && dither {
+		paletted := image.NewPaletted(bounds, ToPalette(canvas.Palette))
+		draw.FloydSteinberg.Draw(paletted, bounds, img, bounds.Min)
+		img = paletted
+	}
+	colours := make(map[image.Point]*Colour)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := FromColor(img.At(x, y))
+			if c.Alpha == 0 {
+				continue
+			}
+			if len(canvas.Palette) > 0 {
+				c = NearestColour(canvas.Palette, c)
+			}
+			colours[image.Pt(x-bounds.Min.X, y-bounds.Min.Y)] = c
+		}
+	}
+	return colours
+}
+
+// Import writes the pixels of the image which differ from the current state of the model.
+// The image is rejected if any part of it is outside the canvas bounds.
+func Import(model Model, canvas *Canvas, img image.Image, options *ImportOptions) (*ImportReport, error) {
+	if img.Bounds().Empty() {
+		return &ImportReport{}, nil
+	}
+	size := img.Bounds().Size()
+	// Sum in 64 bits so a large offset can't wrap around into the canvas
+	right := uint64(options.X) + uint64(size.X) - 1
+	bottom := uint64(options.Y) + uint64(size.Y) - 1
+	if right > math.MaxUint32 || bottom > math.MaxUint32 {
+		return nil, fmt.Errorf(ERROR_OUT_OF_BOUNDS, options.W, right, bottom, options.Z)
+	}
+	for _, corner := range []*Location{
+		{W: options.W, X: options.X, Y: options.Y, Z: options.Z},
+		{W: options.W, X: uint32(right), Y: uint32(bottom), Z: options.Z},
+	} {
+		if err := CheckBounds(canvas, corner); err != nil {
+			return nil, err
+		}
+	}
+
+	// Group locations by colour so each colour can be written as a batch
+	groups := make(map[string][]*Location)
+	colours := make(map[string]*Colour)
+	for p, c := range ConvertImage(canvas, img, options.Dither) {
+		key := FormatHexColour(c)
+		colours[key] = c
+		groups[key] = append(groups[key], &Location{
+			W: options.W,
+			X: options.X + uint32(p.X),
+			Y: options.Y + uint32(p.Y),
+			Z: options.Z,
+		})
+	}
+	var keys []string
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	state := GetState(canvas, model)
+	pricer, hasPrice := model.(Pricer)
+	report := &ImportReport{}
+	for _, k := range keys {
+		changes := GetChanges(canvas, state, groups[k], colours[k])
+		if hasPrice {
+			for _, l := range changes {
+				report.Cost += pricer.GetPrice(l)
+			}
+		}
+		if options.DryRun {
+			report.Pixels += len(changes)
+			continue
+		}
+		count, err := WriteLocations(model, changes, colours[k])
+		report.Pixels += count
+		if err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,139 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo_test
+
+import (
+	"bytes"
+	"github.com/AletheiaWareLLC/colourgo"
+	"github.com/AletheiaWareLLC/testinggo"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"image/png"
+	"math"
+	"testing"
+)
+
+// pricedModel charges a fixed price to write each location.
+type pricedModel struct {
+	fakeModel
+}
+
+func (m *pricedModel) GetPrice(l *colourgo.Location) uint64 {
+	return 3
+}
+
+// newTestImage returns a 2x2 image with a red top row and a blue bottom row.
+func newTestImage() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	img.SetNRGBA(0, 0, color.NRGBA{R: 255, A: 255})
+	img.SetNRGBA(1, 0, color.NRGBA{R: 255, A: 255})
+	img.SetNRGBA(0, 1, color.NRGBA{B: 255, A: 255})
+	img.SetNRGBA(1, 1, color.NRGBA{B: 255, A: 255})
+	return img
+}
+
+func newImportCanvas(t *testing.T) *colourgo.Canvas {
+	t.Helper()
+	canvas, err := colourgo.CreateCanvas("TEST_CANVAS", 4, 4, 1, colourgo.Mode_FREE_FOR_ALL)
+	testinggo.AssertNoError(t, err)
+	canvas.Fill = black
+	return canvas
+}
+
+func TestImport_PNG(t *testing.T) {
+	var buffer bytes.Buffer
+	testinggo.AssertNoError(t, png.Encode(&buffer, newTestImage()))
+	img, err := colourgo.DecodeImage(&buffer)
+	testinggo.AssertNoError(t, err)
+	canvas := newImportCanvas(t)
+	model := &fakeModel{canvas: canvas}
+	// Already red so isn't written again
+	testinggo.AssertNoError(t, model.Write(&colourgo.Location{X: 1, Y: 1}, red))
+	report, err := colourgo.Import(model, canvas, img, &colourgo.ImportOptions{X: 1, Y: 1})
+	testinggo.AssertNoError(t, err)
+	if report.Pixels != 3 {
+		t.Errorf("Incorrect pixels; expected 3, got '%d'", report.Pixels)
+	}
+	if len(model.locations) != 4 {
+		t.Fatalf("Incorrect writes; expected 4, got '%d'", len(model.locations))
+	}
+	state := colourgo.GetState(canvas, model)
+	for p, expected := range map[colourgo.Point]*colourgo.Colour{
+		{X: 1, Y: 1}: red,
+		{X: 2, Y: 1}: red,
+		{X: 1, Y: 2}: blue,
+		{X: 2, Y: 2}: blue,
+	} {
+		testinggo.AssertProtobufEqual(t, expected, state[p])
+	}
+}
+
+func TestImport_GIF(t *testing.T) {
+	src := newTestImage()
+	paletted := image.NewPaletted(src.Bounds(), palette.Plan9)
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 2; x++ {
+			paletted.Set(x, y, src.At(x, y))
+		}
+	}
+	var buffer bytes.Buffer
+	testinggo.AssertNoError(t, gif.Encode(&buffer, paletted, nil))
+	img, err := colourgo.DecodeImage(&buffer)
+	testinggo.AssertNoError(t, err)
+	canvas := newImportCanvas(t)
+	model := &fakeModel{canvas: canvas}
+	report, err := colourgo.Import(model, canvas, img, &colourgo.ImportOptions{})
+	testinggo.AssertNoError(t, err)
+	if report.Pixels != 4 {
+		t.Errorf("Incorrect pixels; expected 4, got '%d'", report.Pixels)
+	}
+	state := colourgo.GetState(canvas, model)
+	testinggo.AssertProtobufEqual(t, red, state[colourgo.Point{X: 1}])
+	testinggo.AssertProtobufEqual(t, blue, state[colourgo.Point{Y: 1}])
+}
+
+func TestImport_DryRun(t *testing.T) {
+	canvas := newImportCanvas(t)
+	model := &pricedModel{fakeModel{canvas: canvas}}
+	testinggo.AssertNoError(t, model.Write(&colourgo.Location{}, red))
+	report, err := colourgo.Import(model, canvas, newTestImage(), &colourgo.ImportOptions{DryRun: true})
+	testinggo.AssertNoError(t, err)
+	if report.Pixels != 3 || report.Cost != 9 {
+		t.Errorf("Incorrect report; expected 3 pixels costing 9, got '%d' costing '%d'", report.Pixels, report.Cost)
+	}
+	if len(model.locations) != 1 {
+		t.Errorf("Incorrect writes; expected 1, got '%d'", len(model.locations))
+	}
+}
+
+func TestImport_OutOfBounds(t *testing.T) {
+	canvas := newImportCanvas(t)
+	model := &fakeModel{canvas: canvas}
+	t.Run("Overlap", func(t *testing.T) {
+		_, err := colourgo.Import(model, canvas, newTestImage(), &colourgo.ImportOptions{X: 3})
+		testinggo.AssertError(t, "Location out of bounds: 0,4,1,0", err)
+	})
+	t.Run("Overflow", func(t *testing.T) {
+		_, err := colourgo.Import(model, canvas, newTestImage(), &colourgo.ImportOptions{X: math.MaxUint32})
+		testinggo.AssertError(t, "Location out of bounds: 0,4294967296,1,0", err)
+	})
+	if len(model.locations) != 0 {
+		t.Errorf("Incorrect writes; expected 0, got '%d'", len(model.locations))
+	}
+}
@@ -0,0 +1,542 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"fmt"
+	"github.com/AletheiaWareLLC/bcgo"
+	"github.com/golang/protobuf/proto"
+	"math"
+	"sort"
+	"sync"
+)
+
+const (
+	ERROR_INSUFFICIENT_BALANCE = "Insufficient balance: %s has %d, needs %d"
+	ERROR_PRICE_TOO_LOW        = "Price too low: %d, must exceed %d"
+	ERROR_PRICE_TOO_HIGH       = "Price too high: %d, must not exceed %d"
+	ERROR_RECORD_AFTER_BLOCK   = "Record timestamp %d after block timestamp %d"
+)
+
+func UnmarshalGrant(data []byte) (*Grant, error) {
+	grant := &Grant{}
+	if err := proto.Unmarshal(data, grant); err != nil {
+		return nil, err
+	}
+	return grant, nil
+}
+
+func CreateGrant(alias string, amount uint64, reason string) *Grant {
+	return &Grant{
+		Alias:  alias,
+		Amount: amount,
+		Reason: reason,
+	}
+}
+
+func CreateGrantRecord(alias string, key *rsa.PrivateKey, grant *Grant) (*bcgo.Record, error) {
+	data, err := proto.Marshal(grant)
+	if err != nil {
+		return nil, err
+	}
+	return CreateRecord(alias, key, data)
+}
+
+// Ownership records who owns a location, the colour they set and the price they paid.
+type Ownership struct {
+	Alias  string
+	Colour *Colour
+	Price  uint64
+	Entry  *bcgo.BlockEntry
+	Height uint64
+	// Timestamp of the block containing the purchase
+	BlockTimestamp uint64
+	// ID of the pool which owns the location, if any
+	Pool string
+	// Timestamp until which tax has been paid
+	PaidUntil uint64
+	// Tax paid in advance with the purchase and not yet used
+	Prepaid uint64
+}
+
+// LedgerEntry is a grant or purchase to be applied to a ledger.
+type LedgerEntry struct {
+	Entry  *bcgo.BlockEntry
+	Height uint64
+	// Timestamp of the block containing the entry
+	BlockTimestamp uint64
+	// Position of the entry within its block
+	Index    uint64
+	Grant    *Grant
+	Purchase *Purchase
+	// ID of the pool for purchases read from a pool's channel, which are contributions to the pool
+	Pool string
+}
+
+// SortLedgerEntries orders entries by the timestamp and height of their block, then their position in it, so all nodes replay them identically.
+// Record timestamps are set by their creator so aren't used, otherwise a purchase could be backdated before one mined earlier.
+func SortLedgerEntries(entries []*LedgerEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.BlockTimestamp != b.BlockTimestamp {
+			return a.BlockTimestamp < b.BlockTimestamp
+		}
+		if a.Height != b.Height {
+			return a.Height < b.Height
+		}
+		if a.Index != b.Index {
+			return a.Index < b.Index
+		}
+		return bytes.Compare(a.Entry.RecordHash, b.Entry.RecordHash) < 0
+	})
+}
+
+// CheckTimestamp returns an error if the entry's record claims to have been created after the block containing it was mined.
+func CheckTimestamp(e *LedgerEntry) error {
+	if t := e.Entry.Record.Timestamp; e.BlockTimestamp != 0 && t > e.BlockTimestamp {
+		return fmt.Errorf(ERROR_RECORD_AFTER_BLOCK, t, e.BlockTimestamp)
+	}
+	return nil
+}
+
+// ReadGrantEntries returns the grants in the channel from the given block back.
+func ReadGrantEntries(name string, hash []byte, block *bcgo.Block, cache bcgo.Cache, network bcgo.Network, verifier *Verifier) ([]*LedgerEntry, error) {
+	var entries []*LedgerEntry
+	if err := bcgo.Iterate(name, hash, block, cache, network, func(h []byte, b *bcgo.Block) error {
+		for i, entry := range b.Entry {
+			if ok, err := verifier.Accept(entry); err != nil {
+				return err
+			} else if !ok {
+				continue
+			}
+			grant, err := UnmarshalGrant(entry.Record.Payload)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, &LedgerEntry{
+				Entry:          entry,
+				Height:         b.Length,
+				BlockTimestamp: b.Timestamp,
+				Index:          uint64(i),
+				Grant:          grant,
+			})
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ReadPurchaseEntries returns the purchases in the channel from the given block back.
+func ReadPurchaseEntries(name string, hash []byte, block *bcgo.Block, cache bcgo.Cache, network bcgo.Network, verifier *Verifier) ([]*LedgerEntry, error) {
+	var entries []*LedgerEntry
+	if err := bcgo.Iterate(name, hash, block, cache, network, func(h []byte, b *bcgo.Block) error {
+		for i, entry := range b.Entry {
+			if ok, err := verifier.Accept(entry); err != nil {
+				return err
+			} else if !ok {
+				continue
+			}
+			purchase, err := UnmarshalPurchase(entry.Record.Payload)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, &LedgerEntry{
+				Entry:          entry,
+				Height:         b.Length,
+				BlockTimestamp: b.Timestamp,
+				Index:          uint64(i),
+				Purchase:       purchase,
+			})
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Ledger tracks the token balance of each alias and the ownership of each location in a market canvas.
+// Every alias starts with the canvas' faucet amount, is credited by grants from the canvas owner and when locations they own are resold, and is debited by their purchases and tax.
+type Ledger struct {
+	sync.RWMutex
+	Canvas   *Canvas
+	Balances map[string]uint64
+	Owners   map[Point]*Ownership
+	// Contributions of each alias to each open pool
+	Pools map[string]map[string]uint64
+	// Amount each contributor has spent from each pool
+	Spent map[string]map[string]uint64
+	// Amendments to the canvas, purchases are checked against the bounds as of when they were made
+	Amendments *Amendments
+	// Moderation actions on the canvas, contributions by banned aliases are rejected
+	Moderations *Moderations
+}
+
+func NewLedger(canvas *Canvas) *Ledger {
+	return &Ledger{
+		Canvas:   canvas,
+		Balances: make(map[string]uint64),
+		Owners:   make(map[Point]*Ownership),
+		Pools:    make(map[string]map[string]uint64),
+		Spent:    make(map[string]map[string]uint64),
+	}
+}
+
+// Copy returns a copy of the ledger which can be updated without changing the original.
+func (l *Ledger) Copy() *Ledger {
+	l.RLock()
+	defer l.RUnlock()
+	c := NewLedger(l.Canvas)
+	c.Amendments = l.Amendments
+	c.Moderations = l.Moderations
+	for a, b := range l.Balances {
+		c.Balances[a] = b
+	}
+	for p, o := range l.Owners {
+		owner := *o
+		c.Owners[p] = &owner
+	}
+	for _, m := range []struct {
+		from, to map[string]map[string]uint64
+	}{
+		{l.Pools, c.Pools},
+		{l.Spent, c.Spent},
+	} {
+		for id, amounts := range m.from {
+			m.to[id] = make(map[string]uint64)
+			for a, v := range amounts {
+				m.to[id][a] = v
+			}
+		}
+	}
+	return c
+}
+
+func (l *Ledger) getCanvas(blockTimestamp uint64) *Canvas {
+	return GetAmendedCanvas(l.Amendments, l.Canvas, blockTimestamp)
+}
+
+func (l *Ledger) balance(alias string) uint64 {
+	if b, ok := l.Balances[alias]; ok {
+		return b
+	}
+	if !IsFaucetRecipient(l.Canvas, alias) {
+		return 0
+	}
+	return l.Canvas.Faucet
+}
+
+// IsFaucetRecipient returns true if the alias is credited by the canvas' faucet.
+// Only listed aliases are credited, as aliases are free to register.
+func IsFaucetRecipient(canvas *Canvas, alias string) bool {
+	for _, r := range canvas.FaucetRecipient {
+		if r == alias {
+			return true
+		}
+	}
+	return false
+}
+
+// ToPrice returns the price as written in a purchase, or an error if it doesn't fit.
+func ToPrice(price uint64) (uint32, error) {
+	if price > math.MaxUint32 {
+		return 0, fmt.Errorf(ERROR_PRICE_TOO_HIGH, price, uint64(math.MaxUint32))
+	}
+	return uint32(price), nil
+}
+
+// GetBalance returns the number of tokens held by the alias.
+func (l *Ledger) GetBalance(alias string) uint64 {
+	l.RLock()
+	defer l.RUnlock()
+	return l.balance(alias)
+}
+
+// GetOwnership returns the current ownership of the location, or nil if it has never been purchased.
+func (l *Ledger) GetOwnership(location *Location) *Ownership {
+	l.RLock()
+	defer l.RUnlock()
+	return l.Owners[NewPoint(location)]
+}
+
+// GetPrice returns the minimum price at which the location can be purchased.
+func (l *Ledger) GetPrice(location *Location) uint64 {
+	l.RLock()
+	defer l.RUnlock()
+	if o, ok := l.Owners[NewPoint(location)]; ok {
+		return o.Price + 1
+	}
+	return 1
+}
+
+// CheckAffordable returns an error if the alias cannot afford the amount.
+func (l *Ledger) CheckAffordable(alias string, amount uint64) error {
+	l.RLock()
+	defer l.RUnlock()
+	if b := l.balance(alias); b < amount {
+		return fmt.Errorf(ERROR_INSUFFICIENT_BALANCE, alias, b, amount)
+	}
+	return nil
+}
+
+// Apply updates the ledger with the entry, an error is returned and the ledger is unchanged if the entry is invalid.
+func (l *Ledger) Apply(e *LedgerEntry) error {
+	if err := CheckTimestamp(e); err != nil {
+		return err
+	}
+	l.Lock()
+	defer l.Unlock()
+	creator := e.Entry.Record.Creator
+	switch {
+	case e.Grant != nil:
+		if !IsOwner(l.Canvas, creator) {
+			return fmt.Errorf(ERROR_NOT_OWNER, creator)
+		}
+		l.Balances[e.Grant.Alias] = l.balance(e.Grant.Alias) + e.Grant.Amount
+	case e.Purchase != nil && e.Pool != "":
+		return l.contribute(e)
+	case e.Purchase != nil && e.Purchase.Pool != "" && e.Purchase.Location == nil:
+		return l.openPool(e.Purchase.Pool, e.BlockTimestamp)
+	case e.Purchase != nil:
+		return l.transfer(e, true)
+	}
+	return nil
+}
+
+// Award transfers the location to the purchase's creator at its price, regardless of the previous price, such as when an auction is won.
+func (l *Ledger) Award(e *LedgerEntry) error {
+	if err := CheckTimestamp(e); err != nil {
+		return err
+	}
+	l.Lock()
+	defer l.Unlock()
+	return l.transfer(e, false)
+}
+
+func (l *Ledger) transfer(e *LedgerEntry, exceed bool) error {
+	creator := e.Entry.Record.Creator
+	p := e.Purchase
+	if p.Location == nil {
+		return fmt.Errorf(ERROR_OUT_OF_BOUNDS, 0, 0, 0, 0)
+	}
+	if err := CheckBounds(l.getCanvas(e.BlockTimestamp), p.Location); err != nil {
+		return err
+	}
+	point := NewPoint(p.Location)
+	previous, owned := l.Owners[point]
+	price := uint64(p.Price)
+	if exceed && owned && price <= previous.Price {
+		return fmt.Errorf(ERROR_PRICE_TOO_LOW, price, previous.Price)
+	}
+	if price == 0 {
+		return fmt.Errorf(ERROR_PRICE_TOO_LOW, price, 0)
+	}
+	cost := price + uint64(p.Tax)
+	buyer := creator
+	if p.Pool != "" {
+		// Pooled purchase, made by a contributor and paid by the pool
+		if err := l.checkPoolPurchase(creator, p, cost); err != nil {
+			return err
+		}
+		buyer = GetPoolAccount(p.Pool)
+	}
+	if b := l.balance(buyer); b < cost {
+		return fmt.Errorf(ERROR_INSUFFICIENT_BALANCE, buyer, b, cost)
+	}
+	if p.Pool != "" {
+		l.Spent[p.Pool][creator] += cost
+	}
+	l.Balances[buyer] = l.balance(buyer) - cost
+	if owned {
+		// Resale, previous owner receives the price, the first sale is paid to no one
+		l.credit(previous, price)
+	}
+	l.Owners[point] = &Ownership{
+		Alias:          buyer,
+		Colour:         p.Colour,
+		Price:          price,
+		Entry:          e.Entry,
+		Height:         e.Height,
+		BlockTimestamp: e.BlockTimestamp,
+		Pool:           p.Pool,
+		PaidUntil:      e.BlockTimestamp,
+		Prepaid:        uint64(p.Tax),
+	}
+	return nil
+}
+
+// Replay sorts and applies the entries, the callback is called for each invalid entry and replay stops if it returns an error.
+func (l *Ledger) Replay(entries []*LedgerEntry, callback func(*LedgerEntry, error) error) error {
+	SortLedgerEntries(entries)
+	for _, e := range entries {
+		if err := l.Apply(e); err != nil {
+			if err := callback(e, err); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// GetLedger replays the grant and purchase channels into a ledger, skipping invalid entries.
+func GetLedger(canvas *Canvas, grants, purchases *bcgo.Channel, cache bcgo.Cache, network bcgo.Network, verifier *Verifier) (*Ledger, error) {
+	entries, err := ReadPurchaseEntries(purchases.Name, purchases.Head, nil, cache, network, verifier)
+	if err != nil {
+		return nil, err
+	}
+	if grants != nil {
+		gs, err := ReadGrantEntries(grants.Name, grants.Head, nil, cache, network, verifier)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, gs...)
+	}
+	ledger := NewLedger(canvas)
+	if err := ledger.Replay(entries, func(*LedgerEntry, error) error {
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return ledger, nil
+}
+
+// GetBalance returns the number of tokens held by the alias in the market canvas.
+func GetBalance(canvas *Canvas, grants, purchases *bcgo.Channel, cache bcgo.Cache, network bcgo.Network, alias string) (uint64, error) {
+	ledger, err := GetLedger(canvas, grants, purchases, cache, network, nil)
+	if err != nil {
+		return 0, err
+	}
+	return ledger.GetBalance(alias), nil
+}
+
+// LedgerSource reads the entries of a market canvas' ledger from its purchase, grant and pool channels.
+// Models and validators share it so they replay identical ledgers.
+type LedgerSource struct {
+	Canvas      *Canvas
+	Grants      *bcgo.Channel
+	Amendments  *Amendments
+	Moderations *Moderations
+	Verifier    *Verifier
+	// Returns the block from which a channel is read, its head if not set
+	GetHead func(*bcgo.Channel) []byte
+	// Returns the purchase channel of a pool, one with the cached head if not set
+	GetPool func(string) *bcgo.Channel
+}
+
+// Read returns the purchases in the channel from the given block back, the grants, and the contributions to each pool opened by the purchases.
+// Purchases by aliases banned from the canvas are omitted and passed to banned, if set.
+func (s *LedgerSource) Read(name string, hash []byte, block *bcgo.Block, cache bcgo.Cache, network bcgo.Network, banned func(*LedgerEntry)) ([]*LedgerEntry, error) {
+	purchases, err := ReadPurchaseEntries(name, hash, block, cache, network, s.Verifier)
+	if err != nil {
+		return nil, err
+	}
+	var entries []*LedgerEntry
+	for _, e := range purchases {
+		if IsBanned(s.Canvas, e.Entry.Record.Creator) {
+			if banned != nil {
+				banned(e)
+			}
+			continue
+		}
+		entries = append(entries, e)
+	}
+	if s.Grants != nil {
+		grants, err := ReadGrantEntries(s.Grants.Name, s.head(s.Grants), nil, cache, network, s.Verifier)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, grants...)
+	}
+	for _, id := range GetPoolIDs(entries) {
+		var head []byte
+		if s.GetPool != nil {
+			head = s.head(s.GetPool(GetPurchaseChannelName(id)))
+		} else if reference, err := cache.GetHead(GetPurchaseChannelName(id)); err == nil {
+			head = reference.BlockHash
+		}
+		contributions, err := ReadContributionEntries(id, head, cache, network, s.Verifier)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, contributions...)
+	}
+	return entries, nil
+}
+
+func (s *LedgerSource) head(channel *bcgo.Channel) []byte {
+	if s.GetHead == nil {
+		return channel.Head
+	}
+	return s.GetHead(channel)
+}
+
+// NewLedger returns an empty ledger which checks entries against the canvas' amendments and moderation.
+func (s *LedgerSource) NewLedger() *Ledger {
+	ledger := NewLedger(s.Canvas)
+	ledger.Amendments = s.Amendments
+	ledger.Moderations = s.Moderations
+	return ledger
+}
+
+// LedgerValidator ensures every purchase in a channel is affordable by its buyer and exceeds the previous price, given the grants in the grant channel.
+// If Taxed is set tax is settled between purchases, as by RadicalMarketModel, so foreclosed locations can be bought again at any price.
+type LedgerValidator struct {
+	Canvas      *Canvas
+	Grants      *bcgo.Channel
+	Amendments  *Amendments
+	Moderations *Moderations
+	Taxed       bool
+}
+
+func (v *LedgerValidator) Validate(channel *bcgo.Channel, cache bcgo.Cache, network bcgo.Network, hash []byte, block *bcgo.Block) error {
+	if v.Amendments != nil {
+		if err := v.Amendments.Update(v.Amendments.Channel.Head, cache, network, nil); err != nil {
+			return err
+		}
+	}
+	source := &LedgerSource{
+		Canvas:      v.Canvas,
+		Grants:      v.Grants,
+		Amendments:  v.Amendments,
+		Moderations: v.Moderations,
+	}
+	entries, err := source.Read(channel.Name, hash, block, cache, network, nil)
+	if err != nil {
+		return err
+	}
+	ledger := source.NewLedger()
+	if v.Taxed {
+		var invalid error
+		ReplayTaxed(ledger, entries, block.Timestamp, func(e *LedgerEntry, err error) {
+			if invalid == nil && e.Grant == nil && e.Pool == "" {
+				invalid = err
+			}
+		})
+		return invalid
+	}
+	return ledger.Replay(entries, func(e *LedgerEntry, err error) error {
+		if e.Grant != nil || e.Pool != "" {
+			// Invalid grants and contributions are ignored
+			return nil
+		}
+		return err
+	})
+}
@@ -0,0 +1,229 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"github.com/AletheiaWareLLC/bcgo"
+	"github.com/AletheiaWareLLC/colourgo"
+	"github.com/AletheiaWareLLC/testinggo"
+	"math"
+	"testing"
+)
+
+func makeLedgerEntry(creator string, timestamp uint64, grant *colourgo.Grant, purchase *colourgo.Purchase) *colourgo.LedgerEntry {
+	return &colourgo.LedgerEntry{
+		Entry: &bcgo.BlockEntry{
+			RecordHash: []byte{byte(timestamp)},
+			Record: &bcgo.Record{
+				Creator:   creator,
+				Timestamp: timestamp,
+			},
+		},
+		BlockTimestamp: timestamp,
+		Grant:          grant,
+		Purchase:       purchase,
+	}
+}
+
+func TestLedger_Replay(t *testing.T) {
+	canvas := &colourgo.Canvas{
+		Width:           2,
+		Height:          2,
+		Owner:           "Owner",
+		Faucet:          10,
+		FaucetRecipient: []string{"Alice", "Bob", "Carol"},
+	}
+	ledger := colourgo.NewLedger(canvas)
+	var rejected []uint64
+	entries := []*colourgo.LedgerEntry{
+		// Out of order to check sorting
+		makeLedgerEntry("Bob", 4, nil, colourgo.CreatePurchase(0, 0, 0, 0, 0, 0, 255, 255, 9, 0)),
+		makeLedgerEntry("Alice", 1, nil, colourgo.CreatePurchase(0, 0, 0, 0, 255, 0, 0, 255, 6, 1)),
+		makeLedgerEntry("Bob", 2, nil, colourgo.CreatePurchase(0, 0, 0, 0, 0, 255, 0, 255, 6, 0)),
+		makeLedgerEntry("Mallory", 3, colourgo.CreateGrant("Mallory", 100, "Free money"), nil),
+		makeLedgerEntry("Owner", 3, colourgo.CreateGrant("Bob", 5, "Prize"), nil),
+		makeLedgerEntry("Carol", 5, nil, colourgo.CreatePurchase(0, 1, 1, 0, 0, 0, 0, 255, 11, 0)),
+	}
+	if err := ledger.Replay(entries, func(e *colourgo.LedgerEntry, err error) error {
+		rejected = append(rejected, e.Entry.Record.Timestamp)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	// Bob's first purchase doesn't exceed Alice's price, Mallory isn't the owner, Carol can't afford
+	expected := []uint64{2, 3, 5}
+	if len(rejected) != len(expected) {
+		t.Fatalf("Incorrect rejections; expected %v, got %v", expected, rejected)
+	}
+	for i, e := range expected {
+		if rejected[i] != e {
+			t.Errorf("Incorrect rejections; expected %v, got %v", expected, rejected)
+		}
+	}
+	for alias, balance := range map[string]uint64{
+		// 10 - 6 - 1 tax + 9 resale
+		"Alice": 12,
+		// 10 + 5 grant - 9
+		"Bob":   6,
+		"Carol": 10,
+		// Not a faucet recipient
+		"Mallory": 0,
+	} {
+		if got := ledger.GetBalance(alias); got != balance {
+			t.Errorf("Incorrect balance for %s; expected %d, got %d", alias, balance, got)
+		}
+	}
+	location := &colourgo.Location{}
+	if o := ledger.GetOwnership(location); o == nil || o.Alias != "Bob" {
+		t.Errorf("Incorrect owner; expected Bob, got %v", o)
+	}
+	if p := ledger.GetPrice(location); p != 10 {
+		t.Errorf("Incorrect price; expected 10, got %d", p)
+	}
+}
+
+func TestLedger_Backdated(t *testing.T) {
+	canvas := &colourgo.Canvas{
+		Width:           2,
+		Height:          2,
+		Faucet:          10,
+		FaucetRecipient: []string{"Alice", "Mallory"},
+	}
+	ledger := colourgo.NewLedger(canvas)
+	alice := makeLedgerEntry("Alice", 10, nil, colourgo.CreatePurchase(0, 0, 0, 0, 255, 0, 0, 255, 5, 0))
+	// Mined after Alice's purchase but backdated before it, to collect its resale price
+	mallory := makeLedgerEntry("Mallory", 20, nil, colourgo.CreatePurchase(0, 0, 0, 0, 0, 0, 255, 255, 1, 0))
+	mallory.Entry.Record.Timestamp = 1
+	// Claims to have been created after it was mined
+	future := makeLedgerEntry("Mallory", 30, nil, colourgo.CreatePurchase(0, 1, 0, 0, 0, 0, 255, 255, 1, 0))
+	future.Entry.Record.Timestamp = 40
+	var errs []error
+	testinggo.AssertNoError(t, ledger.Replay([]*colourgo.LedgerEntry{mallory, future, alice}, func(e *colourgo.LedgerEntry, err error) error {
+		errs = append(errs, err)
+		return nil
+	}))
+	if len(errs) != 2 {
+		t.Fatalf("Expected 2 rejections, got %v", errs)
+	}
+	testinggo.AssertError(t, "Price too low: 1, must exceed 5", errs[0])
+	testinggo.AssertError(t, "Record timestamp 40 after block timestamp 30", errs[1])
+	if o := ledger.GetOwnership(&colourgo.Location{}); o == nil || o.Alias != "Alice" {
+		t.Errorf("Incorrect owner; expected Alice, got %v", o)
+	}
+	for alias, balance := range map[string]uint64{
+		"Alice":   5,
+		"Mallory": 10,
+	} {
+		if got := ledger.GetBalance(alias); got != balance {
+			t.Errorf("Incorrect balance for %s; expected %d, got %d", alias, balance, got)
+		}
+	}
+}
+
+func TestToPrice(t *testing.T) {
+	price, err := colourgo.ToPrice(math.MaxUint32)
+	testinggo.AssertNoError(t, err)
+	if price != math.MaxUint32 {
+		t.Errorf("Incorrect price; expected %d, got %d", uint32(math.MaxUint32), price)
+	}
+	_, err = colourgo.ToPrice(math.MaxUint32 + 1)
+	testinggo.AssertError(t, "Price too high: 4294967296, must not exceed 4294967295", err)
+}
+
+func TestMarketModel_PoolChannels(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	testinggo.AssertNoError(t, err)
+	node := &bcgo.Node{
+		Alias:    "Alice",
+		Key:      key,
+		Cache:    bcgo.NewMemoryCache(10),
+		Channels: make(map[string]*bcgo.Channel),
+	}
+	canvas, err := colourgo.CreateCanvas("TEST_CANVAS", 2, 2, 1, colourgo.Mode_MARKET)
+	testinggo.AssertNoError(t, err)
+	canvas.Faucet = 10
+	canvas.FaucetRecipient = []string{"Alice"}
+	model, err := colourgo.GetModel(node, nil, "TEST_ID", canvas, nil)
+	testinggo.AssertNoError(t, err)
+	defer model.Close()
+	id := colourgo.GetPoolID("TEST_ID", &colourgo.Location{}, &colourgo.Location{X: 1})
+	testinggo.AssertNoError(t, model.(*colourgo.MarketModel).Contribute(id, 1))
+	channel, err := node.GetChannel(colourgo.GetPurchaseChannelName(id))
+	testinggo.AssertNoError(t, err)
+	var signature, role bool
+	for _, v := range channel.Validators {
+		switch v.(type) {
+		case *colourgo.SignatureValidator:
+			signature = true
+		case *colourgo.RoleValidator:
+			role = true
+		}
+	}
+	if !signature || !role {
+		t.Errorf("Expected pool channel to validate signatures and roles, got %v", channel.Validators)
+	}
+}
+
+func TestMarketModel_PendingWrites(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	testinggo.AssertNoError(t, err)
+	node := &bcgo.Node{
+		Alias:    "Alice",
+		Key:      key,
+		Cache:    bcgo.NewMemoryCache(10),
+		Channels: make(map[string]*bcgo.Channel),
+	}
+	registerAlias(t, node)
+	canvas, err := colourgo.CreateCanvas("TEST_CANVAS", 2, 2, 1, colourgo.Mode_MARKET)
+	testinggo.AssertNoError(t, err)
+	canvas.Faucet = 10
+	canvas.FaucetRecipient = []string{"Alice"}
+	m, err := colourgo.GetModel(node, nil, "TEST_ID", canvas, nil)
+	testinggo.AssertNoError(t, err)
+	defer m.Close()
+	model := m.(*colourgo.MarketModel)
+	model.Logger = colourgo.NewLogger(colourgo.LOG_NONE)
+	model.Miner.Policy = colourgo.MINE_MANUAL
+	red := &colourgo.Colour{Red: 255, Alpha: 255}
+	blue := &colourgo.Colour{Blue: 255, Alpha: 255}
+	testinggo.AssertNoError(t, model.Write(&colourgo.Location{}, red))
+	// Outbids the pending purchase, rather than repeating its price
+	testinggo.AssertNoError(t, model.Write(&colourgo.Location{}, blue))
+	entries, err := node.Cache.GetBlockEntries(model.Channel.Name, 0)
+	testinggo.AssertNoError(t, err)
+	if len(entries) != 2 {
+		t.Fatalf("Incorrect entries; expected 2, got '%d'", len(entries))
+	}
+	for i, e := range entries {
+		purchase, err := colourgo.UnmarshalPurchase(e.Record.Payload)
+		testinggo.AssertNoError(t, err)
+		if purchase.Price != uint32(i+1) {
+			t.Errorf("Incorrect price; expected %d, got %d", i+1, purchase.Price)
+		}
+	}
+	ctx := context.Background()
+	testinggo.AssertNoError(t, model.Miner.Mine(ctx))
+	model.Read(ctx)
+	o := model.Ledger.GetOwnership(&colourgo.Location{})
+	if o == nil || o.Price != 2 {
+		t.Fatalf("Expected location owned at price 2, got %v", o)
+	}
+	testinggo.AssertProtobufEqual(t, blue, o.Colour)
+}
@@ -0,0 +1,301 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo
+
+import (
+	"context"
+	"github.com/AletheiaWareLLC/bcgo"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// How long the previous year's canvas channel is still read after the year changes, as canvases created before midnight may be mined after it
+	CANVAS_LIST_GRACE_PERIOD = 24 * time.Hour
+)
+
+// CanvasListing is a canvas along with the record which created it.
+// The ID encodes the year of the channel the canvas was listed from, see FormatCanvasID.
+type CanvasListing struct {
+	ID     string
+	Entry  *bcgo.BlockEntry
+	Canvas *Canvas
+}
+
+// CanvasListModel keeps a list of the canvases created on the canvas channel of the current year, newest first.
+// When the year changes the model moves to the new year's channel, keeping the canvases already listed and reading the previous year's channel for GracePeriod.
+type CanvasListModel struct {
+	sync.Mutex
+	Node     *bcgo.Node
+	Channel  *bcgo.Channel
+	Year     string
+	Previous *bcgo.Channel
+	// Year of the Previous channel
+	PreviousYear string
+	Verifier     *Verifier
+	Logger       Logger
+	Listings     map[string]*CanvasListing
+	Order        []string
+	// Returns the current time, used to decide when the year changes
+	Now            func() time.Time
+	GracePeriod    time.Duration
+	listeners      map[int]func(*CanvasListing)
+	next           int
+	remove         func()
+	removePrevious func()
+	cancel         context.CancelFunc
+	group          sync.WaitGroup
+}
+
+func NewCanvasListModel(node *bcgo.Node) *CanvasListModel {
+	return &CanvasListModel{
+		Node:        node,
+		Logger:      NewLogger(DEFAULT_LOG_LEVEL),
+		Listings:    make(map[string]*CanvasListing),
+		Now:         time.Now,
+		GracePeriod: CANVAS_LIST_GRACE_PERIOD,
+		listeners:   make(map[int]func(*CanvasListing)),
+	}
+}
+
+// AddListener adds a listener which is called with each canvas added to the list, and returns a function which removes it.
+func (m *CanvasListModel) AddListener(listener func(*CanvasListing)) func() {
+	m.Lock()
+	defer m.Unlock()
+	id := m.next
+	m.next++
+	m.listeners[id] = listener
+	return func() {
+		m.Lock()
+		defer m.Unlock()
+		delete(m.listeners, id)
+	}
+}
+
+// Bind opens the canvas channel for the current year, reads it whenever it is updated, and moves to the next year's channel when the year changes.
+// All are cancelled when the given context is done or the model is closed.
+func (m *CanvasListModel) Bind(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	m.Lock()
+	m.cancel = cancel
+	m.Unlock()
+	m.open(ctx, GetYearOf(m.Now()))
+	m.Go(func() {
+		RunYearRollover(ctx, m.Now, func(year string) {
+			m.open(ctx, year)
+		})
+	})
+}
+
+// open switches to the canvas channel of the given year, if not already open, then refreshes and reads it.
+// The channel being switched from is kept as Previous until the grace period has passed.
+func (m *CanvasListModel) open(ctx context.Context, year string) {
+	name := GetCanvasChannelNameForYear(year)
+	m.Lock()
+	if m.Channel != nil && m.Channel.Name == name {
+		m.Unlock()
+		return
+	}
+	if m.removePrevious != nil {
+		m.removePrevious()
+	}
+	previous := m.Channel
+	m.Previous, m.PreviousYear, m.removePrevious = previous, m.Year, m.remove
+	channel := m.Node.GetOrOpenChannel(name, func() *bcgo.Channel {
+		return OpenColourChannel(name)
+	})
+	m.Channel = channel
+	m.Year = year
+	m.remove = AddTrigger(channel, func() {
+		m.readChannel(ctx, channel, year)
+	})
+	grace := m.GracePeriod
+	m.Unlock()
+	if previous != nil {
+		m.Go(func() {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(grace):
+				m.closePrevious(previous)
+			}
+		})
+	}
+	m.Go(func() {
+		if err := channel.LoadCachedHead(m.Node.Cache); err != nil {
+			m.Logger.Debug(err)
+		}
+		if m.Node.Network != nil {
+			if err := channel.Pull(m.Node.Cache, m.Node.Network); err != nil {
+				m.Logger.Warn(err)
+			}
+		}
+		m.readChannel(ctx, channel, year)
+	})
+}
+
+// closePrevious removes the trigger of the previous year's channel, unless another channel has replaced it.
+func (m *CanvasListModel) closePrevious(channel *bcgo.Channel) {
+	m.Lock()
+	defer m.Unlock()
+	if m.Previous != channel {
+		return
+	}
+	if m.removePrevious != nil {
+		m.removePrevious()
+	}
+	m.Previous, m.PreviousYear, m.removePrevious = nil, "", nil
+}
+
+// Read adds the canvases in the current and previous year's channels which aren't already listed, and notifies the listeners of each in the order they were created.
+func (m *CanvasListModel) Read(ctx context.Context) {
+	m.Lock()
+	channel, year := m.Channel, m.Year
+	previous, previousYear := m.Previous, m.PreviousYear
+	m.Unlock()
+	if previous != nil {
+		m.readChannel(ctx, previous, previousYear)
+	}
+	if channel != nil {
+		m.readChannel(ctx, channel, year)
+	}
+}
+
+// readChannel adds the canvases in the given year's channel which aren't already listed, and notifies the listeners of each in the order they were created.
+func (m *CanvasListModel) readChannel(ctx context.Context, channel *bcgo.Channel, year string) {
+	var added []*CanvasListing
+	if err := bcgo.Iterate(channel.Name, channel.Head, nil, m.Node.Cache, m.Node.Network, func(hash []byte, block *bcgo.Block) error {
+		for _, entry := range block.Entry {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			id := FormatCanvasID(year, entry.RecordHash)
+			m.Lock()
+			_, ok := m.Listings[id]
+			m.Unlock()
+			if ok {
+				return bcgo.StopIterationError{}
+			}
+			if ok, err := m.Verifier.Accept(entry); err != nil {
+				return err
+			} else if !ok {
+				continue
+			}
+			canvas, err := UnmarshalCanvas(entry.Record.Payload)
+			if err != nil {
+				// Encrypted or corrupt records aren't listed
+				continue
+			}
+			added = append(added, &CanvasListing{
+				ID:     id,
+				Entry:  entry,
+				Canvas: canvas,
+			})
+		}
+		return nil
+	}); err != nil {
+		if _, ok := err.(bcgo.StopIterationError); !ok {
+			m.Logger.Error("Read Failed:", channel.Name, err)
+		}
+	}
+	if len(added) == 0 {
+		return
+	}
+	sort.Slice(added, func(i, j int) bool {
+		return added[i].Entry.Record.Timestamp < added[j].Entry.Record.Timestamp
+	})
+	m.Lock()
+	var fresh []*CanvasListing
+	for _, l := range added {
+		if _, ok := m.Listings[l.ID]; ok {
+			// Added by a concurrent read
+			continue
+		}
+		m.Listings[l.ID] = l
+		m.Order = append(m.Order, l.ID)
+		fresh = append(fresh, l)
+	}
+	sort.Slice(m.Order, func(i, j int) bool {
+		return m.Listings[m.Order[i]].Entry.Record.Timestamp > m.Listings[m.Order[j]].Entry.Record.Timestamp
+	})
+	var ids []int
+	for id := range m.listeners {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	var listeners []func(*CanvasListing)
+	for _, id := range ids {
+		listeners = append(listeners, m.listeners[id])
+	}
+	m.Unlock()
+	for _, l := range fresh {
+		for _, listener := range listeners {
+			listener(l)
+		}
+	}
+}
+
+// GetCanvases returns the listed canvases, newest first.
+func (m *CanvasListModel) GetCanvases() []*CanvasListing {
+	m.Lock()
+	defer m.Unlock()
+	var listings []*CanvasListing
+	for _, id := range m.Order {
+		listings = append(listings, m.Listings[id])
+	}
+	return listings
+}
+
+// Search returns the listed canvases which match the query, newest first.
+func (m *CanvasListModel) Search(query string) []*CanvasListing {
+	var matches []*CanvasListing
+	for _, l := range m.GetCanvases() {
+		if MatchesQuery(l.Canvas, query) {
+			matches = append(matches, l)
+		}
+	}
+	return matches
+}
+
+// Go runs the function in a goroutine which Close will wait for.
+func (m *CanvasListModel) Go(f func()) {
+	m.group.Add(1)
+	go func() {
+		defer m.group.Done()
+		f()
+	}()
+}
+
+// Close removes the channel triggers, stops the year rollover, and waits for the model's goroutines to finish.
+func (m *CanvasListModel) Close() error {
+	m.Lock()
+	remove, removePrevious, cancel := m.remove, m.removePrevious, m.cancel
+	m.remove, m.removePrevious, m.cancel = nil, nil, nil
+	m.Unlock()
+	if remove != nil {
+		remove()
+	}
+	if removePrevious != nil {
+		removePrevious()
+	}
+	if cancel != nil {
+		cancel()
+	}
+	m.group.Wait()
+	return nil
+}
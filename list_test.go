@@ -0,0 +1,172 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"github.com/AletheiaWareLLC/bcgo"
+	"github.com/AletheiaWareLLC/colourgo"
+	"github.com/AletheiaWareLLC/testinggo"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// lockedCache guards a cache which isn't safe to use from multiple goroutines, such as when mining while the model reads.
+type lockedCache struct {
+	sync.Mutex
+	cache bcgo.Cache
+}
+
+func (c *lockedCache) GetHead(channel string) (*bcgo.Reference, error) {
+	c.Lock()
+	defer c.Unlock()
+	return c.cache.GetHead(channel)
+}
+
+func (c *lockedCache) GetBlock(hash []byte) (*bcgo.Block, error) {
+	c.Lock()
+	defer c.Unlock()
+	return c.cache.GetBlock(hash)
+}
+
+func (c *lockedCache) GetBlockEntries(channel string, timestamp uint64) ([]*bcgo.BlockEntry, error) {
+	c.Lock()
+	defer c.Unlock()
+	return c.cache.GetBlockEntries(channel, timestamp)
+}
+
+func (c *lockedCache) GetBlockContainingRecord(channel string, hash []byte) (*bcgo.Block, error) {
+	c.Lock()
+	defer c.Unlock()
+	return c.cache.GetBlockContainingRecord(channel, hash)
+}
+
+func (c *lockedCache) PutHead(channel string, reference *bcgo.Reference) error {
+	c.Lock()
+	defer c.Unlock()
+	return c.cache.PutHead(channel, reference)
+}
+
+func (c *lockedCache) PutBlock(hash []byte, block *bcgo.Block) error {
+	c.Lock()
+	defer c.Unlock()
+	return c.cache.PutBlock(hash, block)
+}
+
+func (c *lockedCache) PutBlockEntry(channel string, entry *bcgo.BlockEntry) error {
+	c.Lock()
+	defer c.Unlock()
+	return c.cache.PutBlockEntry(channel, entry)
+}
+
+func TestCanvasListModel(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	testinggo.AssertNoError(t, err)
+	node := &bcgo.Node{
+		Alias:    "Alice",
+		Key:      key,
+		Cache:    &lockedCache{cache: bcgo.NewMemoryCache(10)},
+		Channels: make(map[string]*bcgo.Channel),
+	}
+	// Shortly before the end of 2020
+	start := time.Now()
+	end := time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+	offset := end.Sub(start) - 500*time.Millisecond
+	model := colourgo.NewCanvasListModel(node)
+	model.Now = func() time.Time {
+		return time.Now().Add(offset)
+	}
+	model.GracePeriod = time.Second
+	added := make(chan *colourgo.CanvasListing, 2)
+	model.AddListener(func(l *colourgo.CanvasListing) {
+		added <- l
+	})
+	channel := node.GetOrOpenChannel("Colour-Canvas-2020", func() *bcgo.Channel {
+		return colourgo.OpenColourChannel("Colour-Canvas-2020")
+	})
+	canvas, err := colourgo.CreateCanvas("Sunset", 2, 2, 1, colourgo.Mode_FREE_FOR_ALL)
+	testinggo.AssertNoError(t, err)
+	record, err := colourgo.CreateCanvasRecord(node.Alias, node.Key, canvas)
+	testinggo.AssertNoError(t, err)
+	writeAndMine(t, node, channel, record)
+
+	model.Bind(context.Background())
+	defer model.Close()
+	select {
+	case l := <-added:
+		if !strings.HasPrefix(l.ID, "2020.") {
+			t.Errorf("Expected ID to encode 2020, got %s", l.ID)
+		}
+		if l.Canvas.Name != "Sunset" {
+			t.Errorf("Expected Sunset, got %s", l.Canvas.Name)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for canvas")
+	}
+
+	// Rolls over to the new year's channel
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		model.Lock()
+		name := model.Channel.Name
+		model.Unlock()
+		if name == "Colour-Canvas-2021" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected rollover to 2021, got %s", name)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if listings := model.GetCanvases(); len(listings) != 1 {
+		t.Errorf("Expected canvases to be kept after rollover, got %d", len(listings))
+	}
+
+	// Canvases created before midnight but mined after it are still listed during the grace period
+	canvas, err = colourgo.CreateCanvas("Midnight", 2, 2, 1, colourgo.Mode_FREE_FOR_ALL)
+	testinggo.AssertNoError(t, err)
+	record, err = colourgo.CreateCanvasRecord(node.Alias, node.Key, canvas)
+	testinggo.AssertNoError(t, err)
+	writeAndMine(t, node, channel, record)
+	select {
+	case l := <-added:
+		if l.Canvas.Name != "Midnight" || !strings.HasPrefix(l.ID, "2020.") {
+			t.Errorf("Expected Midnight from 2020, got %s %s", l.Canvas.Name, l.ID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for canvas")
+	}
+
+	// The previous year's channel is dropped after the grace period
+	deadline = time.Now().Add(3 * time.Second)
+	for {
+		model.Lock()
+		previous := model.Previous
+		model.Unlock()
+		if previous == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %s to be dropped", previous.Name)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
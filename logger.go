@@ -0,0 +1,98 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo
+
+import (
+	"fmt"
+	"log"
+)
+
+type LogLevel int
+
+const (
+	LOG_DEBUG LogLevel = iota
+	LOG_INFO
+	LOG_WARN
+	LOG_ERROR
+	LOG_NONE
+
+	DEFAULT_LOG_LEVEL = LOG_WARN
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LOG_DEBUG:
+		return "DEBUG"
+	case LOG_INFO:
+		return "INFO"
+	case LOG_WARN:
+		return "WARN"
+	case LOG_ERROR:
+		return "ERROR"
+	case LOG_NONE:
+		return "NONE"
+	default:
+		return fmt.Sprintf("LogLevel(%d)", int(l))
+	}
+}
+
+type Logger interface {
+	Debug(...interface{})
+	Info(...interface{})
+	Warn(...interface{})
+	Error(...interface{})
+}
+
+// LevelLogger writes messages at or above its level to the standard logger, or to Output if set.
+type LevelLogger struct {
+	Level  LogLevel
+	Output *log.Logger
+}
+
+func NewLogger(level LogLevel) *LevelLogger {
+	return &LevelLogger{
+		Level: level,
+	}
+}
+
+func (l *LevelLogger) log(level LogLevel, v ...interface{}) {
+	if level < l.Level {
+		return
+	}
+	message := fmt.Sprintln(append([]interface{}{level.String() + ":"}, v...)...)
+	if l.Output != nil {
+		l.Output.Output(3, message)
+	} else {
+		log.Output(3, message)
+	}
+}
+
+func (l *LevelLogger) Debug(v ...interface{}) {
+	l.log(LOG_DEBUG, v...)
+}
+
+func (l *LevelLogger) Info(v ...interface{}) {
+	l.log(LOG_INFO, v...)
+}
+
+func (l *LevelLogger) Warn(v ...interface{}) {
+	l.log(LOG_WARN, v...)
+}
+
+func (l *LevelLogger) Error(v ...interface{}) {
+	l.log(LOG_ERROR, v...)
+}
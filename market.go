@@ -0,0 +1,412 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"github.com/AletheiaWareLLC/bcgo"
+)
+
+// MarketModel draws each location in the colour set by its current owner, who bought it from the previous owner with tokens.
+type MarketModel struct {
+	BaseModel
+	Grants *bcgo.Channel
+	Ledger *Ledger
+	// Colours chosen by contributors for locations owned by pools
+	PoolColours map[Point]*Colour
+}
+
+func NewMarketModel(node *bcgo.Node, listener bcgo.MiningListener, id string, canvas *Canvas, channel, grants *bcgo.Channel, callback func()) *MarketModel {
+	m := &MarketModel{
+		Grants: grants,
+		Ledger: NewLedger(canvas),
+	}
+	m.initialize(node, listener, id, canvas, channel, callback)
+	if grants != nil {
+		m.AddCompanion(grants)
+	}
+	return m
+}
+
+func (m *MarketModel) Bind(ctx context.Context) {
+	m.bind(ctx, m.Read)
+}
+
+// Read replays the grant and purchase channels into a new ledger.
+func (m *MarketModel) Read(ctx context.Context) {
+	m.Logger.Debug("Read:", m.Channel.Name, len(m.Order))
+	m.ReadBase(ctx)
+	m.ReadAmendments(ctx)
+	m.ReadModerations(ctx)
+	source := m.ledgerSource()
+	entries, err := m.readEntries(source)
+	if err != nil {
+		m.Emit(&Event{
+			Type:    EVENT_READ_FAILED,
+			Channel: m.Channel.Name,
+			Error:   err,
+		})
+		return
+	}
+	if ctx.Err() != nil {
+		// Model closed
+		return
+	}
+	ledger := source.NewLedger()
+	var order []string
+	ledger.Replay(entries, func(e *LedgerEntry, err error) error {
+		m.Emit(&Event{
+			Type:    EVENT_REJECTED_RECORD,
+			Channel: m.Channel.Name,
+			Hash:    base64.RawURLEncoding.EncodeToString(e.Entry.RecordHash),
+			Error:   err,
+		})
+		return nil
+	})
+	poolColours := m.readPoolColours(ledger)
+	m.Lock()
+	for _, e := range entries {
+		if e.Purchase == nil {
+			continue
+		}
+		id := base64.RawURLEncoding.EncodeToString(e.Entry.RecordHash)
+		m.Entries[id] = e.Entry
+		m.Heights[id] = e.Height
+		m.BlockTimestamps[id] = e.BlockTimestamp
+		order = append(order, id)
+	}
+	m.Order = order
+	m.Ledger = ledger
+	m.PoolColours = poolColours
+	m.Logger.Debug("Read Complete:", m.Channel.Name, len(m.Order))
+	m.Unlock()
+	m.Emit(&Event{
+		Type:    EVENT_READ,
+		Channel: m.Channel.Name,
+	})
+	m.Go(func() {
+		if f := m.OnUpdate; f != nil {
+			f()
+		}
+	})
+}
+
+// ledgerSource returns the source of the canvas' ledger, read as of the model's heads.
+func (m *MarketModel) ledgerSource() *LedgerSource {
+	return &LedgerSource{
+		Canvas:      m.Canvas,
+		Grants:      m.Grants,
+		Amendments:  m.Amendments,
+		Moderations: m.Moderations,
+		Verifier:    m.Verifier,
+		GetHead:     m.GetHeadOf,
+		GetPool:     m.openPoolChannel,
+	}
+}
+
+func (m *MarketModel) readEntries(source *LedgerSource) ([]*LedgerEntry, error) {
+	return source.Read(m.Channel.Name, m.GetHead(), nil, m.Node.Cache, m.Node.Network, func(e *LedgerEntry) {
+		m.Emit(&Event{
+			Type:    EVENT_BANNED_RECORD,
+			Channel: m.Channel.Name,
+			Hash:    base64.RawURLEncoding.EncodeToString(e.Entry.RecordHash),
+		})
+	})
+}
+
+// openPoolChannel gets or opens a pool channel and watches it for updates.
+// Pool channels only accept records signed by contributors who aren't banned.
+func (m *MarketModel) openPoolChannel(name string) *bcgo.Channel {
+	channel := m.Node.GetOrOpenChannel(name, func() *bcgo.Channel {
+		c := OpenColourChannel(name)
+		if m.Verifier != nil {
+			c.AddValidator(&SignatureValidator{
+				Verifier: m.Verifier,
+			})
+		}
+		c.AddValidator(&RoleValidator{
+			Canvas:      m.Canvas,
+			Moderations: m.Moderations,
+		})
+		return c
+	})
+	if err := channel.LoadCachedHead(m.Node.Cache); err != nil {
+		m.Logger.Debug(err)
+	}
+	m.Watch(channel)
+	return channel
+}
+
+// readPoolColours tallies the votes of each pool which owns a location.
+func (m *MarketModel) readPoolColours(ledger *Ledger) map[Point]*Colour {
+	colours := make(map[Point]*Colour)
+	ledger.RLock()
+	owned := make(map[string]bool)
+	for _, o := range ledger.Owners {
+		if o.Pool != "" {
+			owned[o.Pool] = true
+		}
+	}
+	ledger.RUnlock()
+	for id := range owned {
+		channel := m.openPoolChannel(GetVoteChannelName(id))
+		votes, err := ReadPoolVotes(id, m.GetHeadOf(channel), m.Node.Cache, m.Node.Network, m.Verifier)
+		if err != nil {
+			m.Emit(&Event{
+				Type:    EVENT_READ_FAILED,
+				Channel: channel.Name,
+				Error:   err,
+			})
+			continue
+		}
+		for p, c := range TallyPool(id, ledger.GetContributions(id), votes) {
+			colours[p] = c
+		}
+	}
+	return colours
+}
+
+func (m *MarketModel) getLedger() *Ledger {
+	m.Lock()
+	defer m.Unlock()
+	return m.Ledger
+}
+
+// getPendingLedger returns a copy of the ledger updated with the purchases written but not yet mined, as they will be when mined.
+// Purchases which would be rejected, or which the miner has rejected, are left out.
+func (m *MarketModel) getPendingLedger() *Ledger {
+	ledger := m.getLedger().Copy()
+	timestamp, err := m.Node.GetLastMinedTimestamp(m.Channel)
+	if err != nil {
+		return ledger
+	}
+	entries, err := m.Node.Cache.GetBlockEntries(m.Channel.Name, timestamp)
+	if err != nil {
+		return ledger
+	}
+	now := bcgo.Timestamp()
+	for _, entry := range entries {
+		if m.Miner.IsRejected(entry.RecordHash) {
+			continue
+		}
+		if ok, err := m.Verifier.Accept(entry); err != nil || !ok {
+			continue
+		}
+		purchase, err := UnmarshalPurchase(entry.Record.Payload)
+		if err != nil {
+			continue
+		}
+		// Mined no earlier than now
+		ledger.Apply(&LedgerEntry{
+			Entry:          entry,
+			BlockTimestamp: now,
+			Purchase:       purchase,
+		})
+	}
+	return ledger
+}
+
+// GetPrice returns the minimum price at which the location can be purchased.
+func (m *MarketModel) GetPrice(l *Location) uint64 {
+	return m.getLedger().GetPrice(l)
+}
+
+// GetBalance returns the number of tokens held by the alias.
+func (m *MarketModel) GetBalance(alias string) uint64 {
+	return m.getLedger().GetBalance(alias)
+}
+
+// Write purchases the location at its current price.
+func (m *MarketModel) Write(l *Location, c *Colour) error {
+	if err := m.CheckContributor(m.Node.Alias); err != nil {
+		return err
+	}
+	if err := ValidateColour(m.Canvas, c); err != nil {
+		return err
+	}
+	if err := CheckBounds(m.Canvas, l); err != nil {
+		return err
+	}
+	// Priced after any pending purchases so writing the same location again outbids the earlier purchase
+	ledger := m.getPendingLedger()
+	price := ledger.GetPrice(l)
+	if err := ledger.CheckAffordable(m.Node.Alias, price); err != nil {
+		return err
+	}
+	p, err := ToPrice(price)
+	if err != nil {
+		return err
+	}
+	record, err := CreatePurchaseRecord(m.Node.Alias, m.Node.Key, &Purchase{
+		Colour:   c,
+		Location: l,
+		Price:    p,
+	})
+	if err != nil {
+		return err
+	}
+	reference, err := bcgo.WriteRecord(m.Channel.Name, m.Node.Cache, record)
+	if err != nil {
+		return err
+	}
+	m.Emit(&Event{
+		Type:    EVENT_WRITE,
+		Channel: m.Channel.Name,
+		Hash:    base64.RawURLEncoding.EncodeToString(reference.RecordHash),
+	})
+	m.Miner.Request()
+	return nil
+}
+
+// Draw calls the callback with the final opaque colour of each pixel, layers are flattened and frames are iterated in order.
+func (m *MarketModel) Draw(callback func(*Location, *Colour)) {
+	pixels := Composite(m.Canvas, GetState(m.Canvas, m))
+	for _, p := range SortPoints(pixels) {
+		callback(p.Location(), pixels[p])
+	}
+}
+
+// DrawLayers calls the callback with the colour set by the owner of each location, omitting those hidden by moderation.
+// Locations owned by a pool are drawn in the colour chosen by its contributors, if they have voted.
+func (m *MarketModel) DrawLayers(callback func(*Location, *Colour)) {
+	m.DrawBase(callback)
+	m.Lock()
+	poolColours := m.PoolColours
+	m.Unlock()
+	ledger := m.getLedger()
+	ledger.RLock()
+	owners := make(map[Point]*Ownership, len(ledger.Owners))
+	for p, o := range ledger.Owners {
+		owners[p] = o
+	}
+	ledger.RUnlock()
+	for _, p := range sortOwned(owners) {
+		o := owners[p]
+		l := p.Location()
+		if m.Moderations.IsHidden(o.Entry.Record, o.Height, o.BlockTimestamp, l) {
+			continue
+		}
+		if c, ok := poolColours[p]; ok && o.Pool != "" {
+			callback(l, c)
+			continue
+		}
+		callback(l, o.Colour)
+	}
+}
+
+func sortOwned(owners map[Point]*Ownership) []Point {
+	pixels := make(map[Point]*Colour, len(owners))
+	for p, o := range owners {
+		pixels[p] = o.Colour
+	}
+	return SortPoints(pixels)
+}
+
+// OpenPool opens a pool to buy the region, returning its ID.
+func (m *MarketModel) OpenPool(from, to *Location) (string, error) {
+	id := GetPoolID(m.ID, from, to)
+	if _, err := GetPoolRegion(m.Canvas, id); err != nil {
+		return "", err
+	}
+	record, err := CreatePurchaseRecord(m.Node.Alias, m.Node.Key, CreatePoolOpening(id))
+	if err != nil {
+		return "", err
+	}
+	if err := m.WriteRecord(m.Channel, record); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// Contribute transfers the amount to the pool.
+func (m *MarketModel) Contribute(id string, amount uint32) error {
+	if err := m.CheckContributor(m.Node.Alias); err != nil {
+		return err
+	}
+	if err := m.getLedger().CheckAffordable(m.Node.Alias, uint64(amount)); err != nil {
+		return err
+	}
+	record, err := CreatePurchaseRecord(m.Node.Alias, m.Node.Key, CreateContribution(amount))
+	if err != nil {
+		return err
+	}
+	return m.WriteRecord(m.openPoolChannel(GetPurchaseChannelName(id)), record)
+}
+
+// BuyRegion purchases every location in the pool's region with the pool's funds, at each location's current price.
+func (m *MarketModel) BuyRegion(id string, c *Colour) error {
+	if err := m.CheckContributor(m.Node.Alias); err != nil {
+		return err
+	}
+	if err := ValidateColour(m.Canvas, c); err != nil {
+		return err
+	}
+	locations, err := GetPoolRegion(m.Canvas, id)
+	if err != nil {
+		return err
+	}
+	ledger := m.getPendingLedger()
+	var records []*bcgo.Record
+	var total uint64
+	for _, l := range locations {
+		price := ledger.GetPrice(l)
+		total += price
+		p, err := ToPrice(price)
+		if err != nil {
+			return err
+		}
+		record, err := CreatePurchaseRecord(m.Node.Alias, m.Node.Key, &Purchase{
+			Colour:   c,
+			Location: l,
+			Price:    p,
+			Pool:     id,
+		})
+		if err != nil {
+			return err
+		}
+		records = append(records, record)
+	}
+	if err := ledger.CheckAffordable(GetPoolAccount(id), total); err != nil {
+		return err
+	}
+	for _, record := range records {
+		if err := m.WriteRecord(m.Channel, record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VotePool votes for the colour of a location owned by the pool.
+func (m *MarketModel) VotePool(id string, l *Location, c *Colour) error {
+	if err := ValidateColour(m.Canvas, c); err != nil {
+		return err
+	}
+	if !InPool(id, l) {
+		return fmt.Errorf(ERROR_OUTSIDE_POOL, l.W, l.X, l.Y, l.Z)
+	}
+	record, err := CreateVoteRecord(m.Node.Alias, m.Node.Key, &Vote{
+		Colour:   c,
+		Location: l,
+	})
+	if err != nil {
+		return err
+	}
+	return m.WriteRecord(m.openPoolChannel(GetVoteChannelName(id)), record)
+}
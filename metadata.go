@@ -0,0 +1,204 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/AletheiaWareLLC/bcgo"
+	"github.com/AletheiaWareLLC/cryptogo"
+	"image"
+	"image/png"
+	"strings"
+	"unicode"
+)
+
+const (
+	ERROR_NAME_EMPTY            = "Name empty"
+	ERROR_NAME_TOO_LONG         = "Name too long: %d (max %d)"
+	ERROR_NAME_INVALID          = "Name contains invalid character: %q"
+	ERROR_DESCRIPTION_TOO_LONG  = "Description too long: %d (max %d)"
+	ERROR_TOO_MANY_TAGS         = "Too many tags: %d (max %d)"
+	ERROR_TAG_INVALID           = "Tag invalid: %q"
+	ERROR_LICENSE_TOO_LONG      = "License too long: %d (max %d)"
+	ERROR_THUMBNAIL_HASH_LENGTH = "Thumbnail hash length invalid: %d"
+
+	MAX_DESCRIPTION_LENGTH = 1000
+	MAX_TAGS               = 10
+	MAX_TAG_LENGTH         = 32
+	MAX_LICENSE_LENGTH     = 100
+	THUMBNAIL_SIZE         = 128
+
+	// Punctuation allowed in names, in addition to letters, digits and spaces
+	NAME_PUNCTUATION = "-_.,:;'!?&()#"
+)
+
+// ValidateName returns an error if the name is empty, too long, or contains characters other than letters, digits, spaces and common punctuation.
+func ValidateName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return errors.New(ERROR_NAME_EMPTY)
+	}
+	if length := len([]rune(name)); length > MAX_NAME_LENGTH {
+		return fmt.Errorf(ERROR_NAME_TOO_LONG, length, MAX_NAME_LENGTH)
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != ' ' && !strings.ContainsRune(NAME_PUNCTUATION, r) {
+			return fmt.Errorf(ERROR_NAME_INVALID, r)
+		}
+	}
+	return nil
+}
+
+// ValidateTag returns an error if the tag isn't a short run of lowercase letters, digits and hyphens.
+func ValidateTag(tag string) error {
+	if tag == "" || len([]rune(tag)) > MAX_TAG_LENGTH {
+		return fmt.Errorf(ERROR_TAG_INVALID, tag)
+	}
+	for _, r := range tag {
+		if !unicode.IsLower(r) && !unicode.IsDigit(r) && r != '-' {
+			return fmt.Errorf(ERROR_TAG_INVALID, tag)
+		}
+	}
+	return nil
+}
+
+// ValidateMetadata returns an error if the canvas' name, description, tags, license or thumbnail are invalid.
+func ValidateMetadata(canvas *Canvas) error {
+	if err := ValidateName(canvas.Name); err != nil {
+		return err
+	}
+	if length := len([]rune(canvas.Description)); length > MAX_DESCRIPTION_LENGTH {
+		return fmt.Errorf(ERROR_DESCRIPTION_TOO_LONG, length, MAX_DESCRIPTION_LENGTH)
+	}
+	if len(canvas.Tag) > MAX_TAGS {
+		return fmt.Errorf(ERROR_TOO_MANY_TAGS, len(canvas.Tag), MAX_TAGS)
+	}
+	for _, t := range canvas.Tag {
+		if err := ValidateTag(t); err != nil {
+			return err
+		}
+	}
+	if length := len([]rune(canvas.License)); length > MAX_LICENSE_LENGTH {
+		return fmt.Errorf(ERROR_LICENSE_TOO_LONG, length, MAX_LICENSE_LENGTH)
+	}
+	if length := len(canvas.Thumbnail); length != 0 && length != len(cryptogo.Hash(nil)) {
+		return fmt.Errorf(ERROR_THUMBNAIL_HASH_LENGTH, length)
+	}
+	return nil
+}
+
+// SetMetadata sets the canvas' description, tags and license, tags are lowercased, and validates the result.
+func SetMetadata(canvas *Canvas, description string, tags []string, license string) error {
+	canvas.Description = description
+	canvas.Tag = nil
+	for _, t := range tags {
+		canvas.Tag = append(canvas.Tag, strings.ToLower(strings.TrimSpace(t)))
+	}
+	canvas.License = license
+	return ValidateMetadata(canvas)
+}
+
+// CreateThumbnail returns a PNG image of the first frame and Z plane of the model, scaled to fit within THUMBNAIL_SIZE, and its hash.
+// The hash can be stored in a canvas' Thumbnail so listings can use a cached image.
+func CreateThumbnail(canvas *Canvas, model Model) ([]byte, []byte, error) {
+	img := RenderImage(canvas, GetPixels(model), 0, 0)
+	width, height := int(canvas.Width), int(canvas.Height)
+	if width > THUMBNAIL_SIZE || height > THUMBNAIL_SIZE {
+		scale := float64(THUMBNAIL_SIZE) / float64(width)
+		if height > width {
+			scale = float64(THUMBNAIL_SIZE) / float64(height)
+		}
+		w, h := int(float64(width)*scale), int(float64(height)*scale)
+		if w < 1 {
+			w = 1
+		}
+		if h < 1 {
+			h = 1
+		}
+		// Nearest neighbour keeps pixel art sharp
+		scaled := image.NewNRGBA(image.Rect(0, 0, w, h))
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				scaled.Set(x, y, img.At(x*width/w, y*height/h))
+			}
+		}
+		img = scaled
+	}
+	var buffer bytes.Buffer
+	if err := png.Encode(&buffer, img); err != nil {
+		return nil, nil, err
+	}
+	data := buffer.Bytes()
+	return data, cryptogo.Hash(data), nil
+}
+
+// MatchesQuery returns true if the canvas matches every term in the query, ignoring case.
+// Terms of the form tag:x and license:x must match a tag or the license exactly, other terms may appear anywhere in the name, description, tags or license.
+func MatchesQuery(canvas *Canvas, query string) bool {
+	for _, term := range strings.Fields(strings.ToLower(query)) {
+		switch {
+		case strings.HasPrefix(term, "tag:"):
+			found := false
+			for _, t := range canvas.Tag {
+				if strings.ToLower(t) == term[4:] {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		case strings.HasPrefix(term, "license:"):
+			if strings.ToLower(canvas.License) != term[8:] {
+				return false
+			}
+		default:
+			fields := append([]string{canvas.Name, canvas.Description, canvas.License}, canvas.Tag...)
+			found := false
+			for _, f := range fields {
+				if strings.Contains(strings.ToLower(f), term) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// SearchCanvases calls the callback with each canvas in the channel which matches the query, starting from the latest block.
+func SearchCanvases(canvases *bcgo.Channel, cache bcgo.Cache, network bcgo.Network, query string, callback func(*bcgo.BlockEntry, *Canvas) error) error {
+	return bcgo.Iterate(canvases.Name, canvases.Head, nil, cache, network, func(hash []byte, block *bcgo.Block) error {
+		for _, entry := range block.Entry {
+			canvas, err := UnmarshalCanvas(entry.Record.Payload)
+			if err != nil {
+				// Encrypted or corrupt records aren't listed
+				continue
+			}
+			if MatchesQuery(canvas, query) {
+				if err := callback(entry, canvas); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,81 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo_test
+
+import (
+	"github.com/AletheiaWareLLC/colourgo"
+	"github.com/AletheiaWareLLC/testinggo"
+	"strings"
+	"testing"
+)
+
+func TestCreateCanvas_Name(t *testing.T) {
+	for name, test := range map[string]struct {
+		name  string
+		error string
+	}{
+		"Valid":    {"Sunset #2 (Draft)", ""},
+		"Unicode":  {"Ciel étoilé", ""},
+		"Empty":    {" ", "Name empty"},
+		"TooLong":  {strings.Repeat("a", colourgo.MAX_NAME_LENGTH+1), "Name too long: 101 (max 100)"},
+		"Invalid":  {"<script>", "Name contains invalid character: '<'"},
+		"Newlines": {"Line\nBreak", "Name contains invalid character: '\\n'"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := colourgo.CreateCanvas(test.name, 1, 1, 1, colourgo.Mode_FREE_FOR_ALL)
+			if test.error == "" {
+				testinggo.AssertNoError(t, err)
+			} else {
+				testinggo.AssertError(t, test.error, err)
+			}
+		})
+	}
+}
+
+func TestSetMetadata(t *testing.T) {
+	canvas, err := colourgo.CreateCanvas("Sunset", 1, 1, 1, colourgo.Mode_FREE_FOR_ALL)
+	testinggo.AssertNoError(t, err)
+	testinggo.AssertNoError(t, colourgo.SetMetadata(canvas, "A collaborative sunset", []string{"Landscape", "pixel-art"}, "CC-BY-4.0"))
+	if canvas.Tag[0] != "landscape" {
+		t.Errorf("Expected tag to be lowercased, got %s", canvas.Tag[0])
+	}
+	testinggo.AssertError(t, `Tag invalid: "two words"`, colourgo.SetMetadata(canvas, "", []string{"two words"}, ""))
+}
+
+func TestMatchesQuery(t *testing.T) {
+	canvas := &colourgo.Canvas{
+		Name:        "Sunset",
+		Description: "A collaborative landscape",
+		Tag:         []string{"pixel-art"},
+		License:     "CC-BY-4.0",
+	}
+	for query, expected := range map[string]bool{
+		"":                  true,
+		"sunset":            true,
+		"COLLAB":            true,
+		"sunset pixel":      true,
+		"sunset sunrise":    false,
+		"tag:pixel-art":     true,
+		"tag:pixel":         false,
+		"license:cc-by-4.0": true,
+		"license:cc0":       false,
+	} {
+		if actual := colourgo.MatchesQuery(canvas, query); actual != expected {
+			t.Errorf("Query %q: expected %t, got %t", query, expected, actual)
+		}
+	}
+}
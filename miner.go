@@ -0,0 +1,386 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/AletheiaWareLLC/bcgo"
+	"github.com/AletheiaWareLLC/cryptogo"
+	"github.com/golang/protobuf/proto"
+	"sync"
+	"time"
+)
+
+const (
+	ERROR_MINING_CANCELLED = "Mining cancelled"
+
+	DEFAULT_MINING_DELAY  = time.Second
+	DEFAULT_MINING_PERIOD = time.Minute
+)
+
+type MiningPolicy int
+
+const (
+	// Mine shortly after records are written, coalescing writes which occur within the delay
+	MINE_ON_WRITE MiningPolicy = iota
+	// Mine any pending records once every period
+	MINE_PERIODIC
+	// Only mine when Mine is called
+	MINE_MANUAL
+)
+
+// miningKey identifies a channel on a node, at most one block is mined for each at a time.
+type miningKey struct {
+	node    *bcgo.Node
+	channel string
+}
+
+type miningState struct {
+	// Set when records are written while the channel is being mined
+	pending bool
+}
+
+var (
+	miningLock sync.Mutex
+	mining     = make(map[miningKey]*miningState)
+)
+
+// Miner schedules mining of the records written to a channel.
+// Miners of the same channel on the same node never mine at the same time, even when owned by different models.
+type Miner struct {
+	sync.Mutex
+	Node      *bcgo.Node
+	Channel   *bcgo.Channel
+	Threshold uint64
+	Listener  bcgo.MiningListener
+	Verifier  *Verifier
+	Policy    MiningPolicy
+	Delay     time.Duration
+	Period    time.Duration
+	Logger    Logger
+	OnError   func(error)
+	OnMined   func([]byte, *bcgo.Block)
+	// Called when a record is left out of the chain as the channel's validators reject it
+	OnRejected func(*bcgo.BlockEntry, error)
+	// Base64 encoded hashes of rejected records, which aren't retried
+	rejected map[string]bool
+	ctx      context.Context
+	timer    *time.Timer
+	ticker   *time.Ticker
+	stop     chan struct{}
+	group    sync.WaitGroup
+}
+
+func NewMiner(node *bcgo.Node, channel *bcgo.Channel, threshold uint64, listener bcgo.MiningListener, policy MiningPolicy) *Miner {
+	m := &Miner{
+		Node:      node,
+		Channel:   channel,
+		Threshold: threshold,
+		Listener:  listener,
+		Policy:    policy,
+		Delay:     DEFAULT_MINING_DELAY,
+		Period:    DEFAULT_MINING_PERIOD,
+		Logger:    NewLogger(DEFAULT_LOG_LEVEL),
+		rejected:  make(map[string]bool),
+		ctx:       context.Background(),
+		stop:      make(chan struct{}),
+	}
+	m.OnError = func(err error) {
+		m.Logger.Error("Mining Failed:", channel.Name, err)
+	}
+	m.OnRejected = func(entry *bcgo.BlockEntry, err error) {
+		m.Logger.Error("Record Rejected:", channel.Name, base64.RawURLEncoding.EncodeToString(entry.RecordHash), err)
+	}
+	return m
+}
+
+// Start begins periodic mining if required by the policy.
+// Scheduled mining is cancelled when the context is done.
+func (m *Miner) Start(ctx context.Context) {
+	m.Lock()
+	defer m.Unlock()
+	select {
+	case <-m.stop:
+		// Restart after Stop
+		m.stop = make(chan struct{})
+	default:
+	}
+	m.ctx = ctx
+	if m.Policy == MINE_PERIODIC && m.ticker == nil {
+		m.ticker = time.NewTicker(m.Period)
+		m.group.Add(1)
+		go func(ticker *time.Ticker, stop chan struct{}) {
+			defer m.group.Done()
+			for {
+				select {
+				case <-ticker.C:
+					m.mineAndReport(ctx)
+				case <-ctx.Done():
+					return
+				case <-stop:
+					return
+				}
+			}
+		}(m.ticker, m.stop)
+	}
+}
+
+// Stop cancels any scheduled or in-flight mining and waits for it to finish.
+func (m *Miner) Stop() {
+	m.Lock()
+	if m.timer != nil {
+		if m.timer.Stop() {
+			// Timer hadn't fired
+			m.group.Done()
+		}
+		m.timer = nil
+	}
+	if m.ticker != nil {
+		m.ticker.Stop()
+		m.ticker = nil
+	}
+	select {
+	case <-m.stop:
+		// Already stopped
+	default:
+		close(m.stop)
+	}
+	m.Unlock()
+	m.group.Wait()
+}
+
+// Request notifies the miner that a record has been written.
+// With the MINE_ON_WRITE policy mining is debounced so a burst of writes is mined into a single block.
+func (m *Miner) Request() {
+	if m.Policy != MINE_ON_WRITE {
+		return
+	}
+	m.Lock()
+	defer m.Unlock()
+	select {
+	case <-m.stop:
+		// Stopped
+		return
+	default:
+	}
+	if m.timer != nil && m.timer.Stop() {
+		// Timer hadn't fired
+		m.group.Done()
+	}
+	ctx := m.ctx
+	m.group.Add(1)
+	m.timer = time.AfterFunc(m.Delay, func() {
+		defer m.group.Done()
+		m.mineAndReport(ctx)
+	})
+}
+
+func (m *Miner) mineAndReport(ctx context.Context) {
+	if err := m.Mine(ctx); err != nil {
+		if f := m.OnError; f != nil {
+			f(err)
+		}
+	}
+}
+
+// Mine mines all pending records in the channel.
+// If the channel is already being mined on the node the pending records will be mined once it completes.
+// Mining is cancelled when the context is done or the miner is stopped.
+func (m *Miner) Mine(ctx context.Context) error {
+	key := miningKey{
+		node:    m.Node,
+		channel: m.Channel.Name,
+	}
+	miningLock.Lock()
+	if s, ok := mining[key]; ok {
+		s.pending = true
+		miningLock.Unlock()
+		return nil
+	}
+	state := &miningState{}
+	mining[key] = state
+	miningLock.Unlock()
+	for {
+		m.Lock()
+		stop := m.stop
+		m.Unlock()
+		err := m.mine(ctx, stop)
+		miningLock.Lock()
+		if err != nil || !state.pending {
+			delete(mining, key)
+			miningLock.Unlock()
+			return err
+		}
+		state.pending = false
+		miningLock.Unlock()
+	}
+}
+
+// mine follows bcgo's Node.Mine, which can neither be cancelled nor skip unverified records.
+func (m *Miner) mine(ctx context.Context, stop <-chan struct{}) error {
+	timestamp, err := m.Node.GetLastMinedTimestamp(m.Channel)
+	if err != nil {
+		return err
+	}
+	cached, err := m.Node.Cache.GetBlockEntries(m.Channel.Name, timestamp)
+	if err != nil {
+		return err
+	}
+	var entries []*bcgo.BlockEntry
+	for _, entry := range cached {
+		if m.IsRejected(entry.RecordHash) {
+			continue
+		}
+		// Don't mine forged records into the chain
+		if ok, err := m.Verifier.Accept(entry); err != nil {
+			return err
+		} else if ok {
+			entries = append(entries, entry)
+		}
+	}
+	if len(entries) == 0 {
+		// Nothing to mine
+		return nil
+	}
+
+	block := &bcgo.Block{
+		Timestamp:   bcgo.Timestamp(),
+		ChannelName: m.Channel.Name,
+		Length:      1,
+		Miner:       m.Node.Alias,
+		Entry:       entries,
+	}
+	if previousHash := m.Channel.Head; previousHash != nil {
+		previousBlock, err := m.Node.Cache.GetBlock(previousHash)
+		if err != nil {
+			return err
+		}
+		block.Length = previousBlock.Length + 1
+		block.Previous = previousHash
+	}
+
+	if err := m.screen(block); err != nil {
+		return err
+	}
+	if len(block.Entry) == 0 {
+		// Nothing left to mine
+		return nil
+	}
+
+	size := uint64(proto.Size(block))
+	if size > bcgo.MAX_BLOCK_SIZE_BYTES {
+		return fmt.Errorf(bcgo.ERROR_BLOCK_TOO_LARGE, bcgo.BinarySizeToString(size), bcgo.BinarySizeToString(bcgo.MAX_BLOCK_SIZE_BYTES))
+	}
+
+	if m.Listener != nil {
+		m.Listener.OnMiningStarted(m.Channel, size)
+	}
+
+	var max uint64
+	for nonce := uint64(1); nonce > 0; nonce++ {
+		select {
+		case <-ctx.Done():
+			return errors.New(ERROR_MINING_CANCELLED)
+		case <-stop:
+			return errors.New(ERROR_MINING_CANCELLED)
+		default:
+		}
+		block.Nonce = nonce
+		hash, err := cryptogo.HashProtobuf(block)
+		if err != nil {
+			return err
+		}
+		ones := bcgo.Ones(hash)
+		if ones > max {
+			if m.Listener != nil {
+				m.Listener.OnNewMaxOnes(m.Channel, nonce, ones)
+			}
+			max = ones
+		}
+		if ones > m.Threshold {
+			if m.Listener != nil {
+				m.Listener.OnMiningThresholdReached(m.Channel, hash, block)
+			}
+			if err := m.Channel.Update(m.Node.Cache, m.Node.Network, hash, block); err != nil {
+				return err
+			}
+			if f := m.OnMined; f != nil {
+				f(hash, block)
+			}
+			if m.Node.Network != nil {
+				// Push Update to Peers
+				return m.Channel.Push(m.Node.Cache, m.Node.Network)
+			}
+			return nil
+		}
+	}
+	return errors.New(bcgo.ERROR_NONCE_WRAP_AROUND)
+}
+
+// IsRejected returns true if the record was left out of the chain by the channel's validators.
+func (m *Miner) IsRejected(recordHash []byte) bool {
+	m.Lock()
+	defer m.Unlock()
+	return m.rejected[base64.RawURLEncoding.EncodeToString(recordHash)]
+}
+
+// screen removes the entries which the channel's validators reject, such as a purchase outbid by an earlier pending purchase, so they don't fail every block mined after them.
+// Entries are added in turn and any which makes the block invalid is rejected.
+func (m *Miner) screen(block *bcgo.Block) error {
+	if m.validate(block) == nil {
+		return nil
+	}
+	entries := block.Entry
+	block.Entry = nil
+	if err := m.validate(block); err != nil {
+		// Invalid regardless of the entries
+		return err
+	}
+	for _, entry := range entries {
+		block.Entry = append(block.Entry, entry)
+		if err := m.validate(block); err != nil {
+			block.Entry = block.Entry[:len(block.Entry)-1]
+			m.Lock()
+			m.rejected[base64.RawURLEncoding.EncodeToString(entry.RecordHash)] = true
+			m.Unlock()
+			if f := m.OnRejected; f != nil {
+				f(entry, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validate checks the block against the channel's validators, except proof of work which is yet to be done.
+func (m *Miner) validate(block *bcgo.Block) error {
+	hash, err := cryptogo.HashProtobuf(block)
+	if err != nil {
+		return err
+	}
+	for _, v := range m.Channel.Validators {
+		if _, ok := v.(*bcgo.PoWValidator); ok {
+			continue
+		}
+		if err := v.Validate(m.Channel, m.Node.Cache, m.Node.Network, hash, block); err != nil {
+			return err
+		}
+	}
+	return nil
+}
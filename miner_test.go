@@ -0,0 +1,184 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo_test
+
+import (
+	"context"
+	"errors"
+	"github.com/AletheiaWareLLC/bcgo"
+	"github.com/AletheiaWareLLC/colourgo"
+	"github.com/AletheiaWareLLC/testinggo"
+	"testing"
+	"time"
+)
+
+func makeMinerNode(t *testing.T) (*bcgo.Node, *bcgo.Channel) {
+	t.Helper()
+	node := &bcgo.Node{
+		Alias:    "TEST_ALIAS",
+		Cache:    bcgo.NewMemoryCache(10),
+		Channels: make(map[string]*bcgo.Channel),
+	}
+	channel := &bcgo.Channel{
+		Name: "TEST_CHANNEL",
+	}
+	for i := 0; i < 3; i++ {
+		_, err := bcgo.WriteRecord(channel.Name, node.Cache, &bcgo.Record{
+			Timestamp: uint64(i + 1),
+			Creator:   node.Alias,
+			Payload:   []byte{byte(i)},
+		})
+		testinggo.AssertNoError(t, err)
+	}
+	return node, channel
+}
+
+func TestMiner_Mine(t *testing.T) {
+	node, channel := makeMinerNode(t)
+	miner := colourgo.NewMiner(node, channel, bcgo.THRESHOLD_Z, nil, colourgo.MINE_MANUAL)
+	miner.Request()
+	if channel.Head != nil {
+		t.Fatal("Manual miner mined on request")
+	}
+	testinggo.AssertNoError(t, miner.Mine(context.Background()))
+	if channel.Head == nil {
+		t.Fatal("Expected channel head to be set")
+	}
+	block, err := node.Cache.GetBlock(channel.Head)
+	testinggo.AssertNoError(t, err)
+	if len(block.Entry) != 3 {
+		t.Fatalf("Incorrect entries; expected 3, got '%d'", len(block.Entry))
+	}
+}
+
+func TestMiner_Stop(t *testing.T) {
+	node, channel := makeMinerNode(t)
+	// Threshold can never be reached so mining only ends when cancelled
+	miner := colourgo.NewMiner(node, channel, 512, nil, colourgo.MINE_MANUAL)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- miner.Mine(context.Background())
+	}()
+	time.Sleep(10 * time.Millisecond)
+	miner.Stop()
+	select {
+	case err := <-errs:
+		testinggo.AssertError(t, colourgo.ERROR_MINING_CANCELLED, err)
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for mining to stop")
+	}
+}
+
+func TestMiner_SharedChannel(t *testing.T) {
+	node, channel := makeMinerNode(t)
+	// Threshold can never be reached so the first miner holds the channel until stopped
+	first := colourgo.NewMiner(node, channel, 512, nil, colourgo.MINE_MANUAL)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- first.Mine(context.Background())
+	}()
+	time.Sleep(10 * time.Millisecond)
+	second := colourgo.NewMiner(node, channel, bcgo.THRESHOLD_Z, nil, colourgo.MINE_MANUAL)
+	testinggo.AssertNoError(t, second.Mine(context.Background()))
+	if channel.Head != nil {
+		t.Fatal("Second miner mined while channel was being mined")
+	}
+	first.Stop()
+	select {
+	case err := <-errs:
+		testinggo.AssertError(t, colourgo.ERROR_MINING_CANCELLED, err)
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for mining to stop")
+	}
+	testinggo.AssertNoError(t, second.Mine(context.Background()))
+	if channel.Head == nil {
+		t.Fatal("Expected channel head to be set")
+	}
+}
+
+// payloadValidator rejects blocks containing the payload, or with more than Max entries.
+type payloadValidator struct {
+	Payload byte
+	Max     int
+}
+
+func (v *payloadValidator) Validate(channel *bcgo.Channel, cache bcgo.Cache, network bcgo.Network, hash []byte, block *bcgo.Block) error {
+	if v.Max > 0 && len(block.Entry) > v.Max {
+		return errors.New("Too many entries")
+	}
+	for _, e := range block.Entry {
+		if e.Record.Payload[0] == v.Payload {
+			return errors.New("Invalid payload")
+		}
+	}
+	return nil
+}
+
+func TestMiner_Rejected(t *testing.T) {
+	node, channel := makeMinerNode(t)
+	channel.AddValidator(&payloadValidator{Payload: 1})
+	channel.AddValidator(&bcgo.PoWValidator{Threshold: bcgo.THRESHOLD_Z})
+	miner := colourgo.NewMiner(node, channel, bcgo.THRESHOLD_Z, nil, colourgo.MINE_MANUAL)
+	var rejected []*bcgo.BlockEntry
+	miner.OnRejected = func(entry *bcgo.BlockEntry, err error) {
+		testinggo.AssertError(t, "Invalid payload", err)
+		rejected = append(rejected, entry)
+	}
+	testinggo.AssertNoError(t, miner.Mine(context.Background()))
+	if len(rejected) != 1 || rejected[0].Record.Payload[0] != 1 {
+		t.Fatalf("Expected invalid record to be rejected, got %v", rejected)
+	}
+	if !miner.IsRejected(rejected[0].RecordHash) {
+		t.Error("Expected record to be marked rejected")
+	}
+	block, err := node.Cache.GetBlock(channel.Head)
+	testinggo.AssertNoError(t, err)
+	if len(block.Entry) != 2 {
+		t.Fatalf("Incorrect entries; expected 2, got '%d'", len(block.Entry))
+	}
+	// The rejected record isn't retried in later blocks
+	_, err = bcgo.WriteRecord(channel.Name, node.Cache, &bcgo.Record{
+		Timestamp: bcgo.Timestamp(),
+		Creator:   node.Alias,
+		Payload:   []byte{3},
+	})
+	testinggo.AssertNoError(t, err)
+	testinggo.AssertNoError(t, miner.Mine(context.Background()))
+	if len(rejected) != 1 {
+		t.Errorf("Expected record to be rejected once, got %d", len(rejected))
+	}
+	block, err = node.Cache.GetBlock(channel.Head)
+	testinggo.AssertNoError(t, err)
+	if len(block.Entry) != 1 || block.Entry[0].Record.Payload[0] != 3 {
+		t.Errorf("Expected block with new record, got %v", block.Entry)
+	}
+}
+
+func TestMiner_RejectedByCount(t *testing.T) {
+	node, channel := makeMinerNode(t)
+	// Each record is valid alone, but not all together
+	channel.AddValidator(&payloadValidator{Payload: 255, Max: 2})
+	miner := colourgo.NewMiner(node, channel, bcgo.THRESHOLD_Z, nil, colourgo.MINE_MANUAL)
+	var rejected []*bcgo.BlockEntry
+	miner.OnRejected = func(entry *bcgo.BlockEntry, err error) {
+		rejected = append(rejected, entry)
+	}
+	testinggo.AssertNoError(t, miner.Mine(context.Background()))
+	if len(rejected) != 1 || rejected[0].Record.Payload[0] != 2 {
+		t.Fatalf("Expected last record to be rejected, got %v", rejected)
+	}
+}
@@ -17,55 +17,225 @@
 package colourgo
 
 import (
+	"context"
+	"encoding/base64"
 	"fmt"
 	"github.com/AletheiaWareLLC/bcgo"
+	"math"
+	"os"
+	"strings"
 	"sync"
 )
 
+const (
+	ERROR_REFRESH_FAILED = "Refresh failed: %s"
+)
+
 type Model interface {
-	Bind()
-	Refresh() error
+	Bind(context.Context)
+	Close() error
+	Refresh(context.Context) error
 
 	Draw(func(*Location, *Colour))
+	DrawLayers(func(*Location, *Colour))
 
-	Read()
+	Read(context.Context)
 	Write(*Location, *Colour) error
-	Mine() error
+	Mine(context.Context) error
+
+	Events() <-chan *Event
 }
 
 func GetModel(node *bcgo.Node, listener bcgo.MiningListener, id string, canvas *Canvas, callback func()) (Model, error) {
+	verifier := NewAliasVerifier(node)
+	moderations := NewModerations(canvas)
+	amendments := NewAmendments(canvas, OpenAmendments(node, id, canvas, verifier))
 	switch canvas.Mode {
 	case Mode_FREE_FOR_ALL:
 		name := GetVoteChannelName(id)
 		channel := node.GetOrOpenChannel(name, func() *bcgo.Channel {
-			return OpenVoteChannel(id)
+			c := OpenVoteChannel(id)
+			c.AddValidator(&SignatureValidator{
+				Verifier: verifier,
+			})
+			c.AddValidator(&RetractionValidator{})
+			c.AddValidator(&BoundsValidator{
+				Canvas:     canvas,
+				Amendments: amendments,
+			})
+			c.AddValidator(NewVoteColourValidator(canvas))
+			c.AddValidator(&RoleValidator{
+				Canvas:      canvas,
+				Moderations: moderations,
+			})
+			return c
+		})
+		model := NewFreeForAllModel(node, listener, id, canvas, channel, callback)
+		model.SetModerationChannel(OpenModeration(node, id, canvas, verifier), moderations)
+		model.SetAmendments(amendments)
+		model.SetVerifier(verifier)
+		return model, nil
+	case Mode_MARKET:
+		grants := OpenGrants(node, id, canvas, verifier)
+		channel := node.GetOrOpenChannel(GetPurchaseChannelName(id), func() *bcgo.Channel {
+			c := OpenPurchaseChannel(id)
+			c.AddValidator(&SignatureValidator{
+				Verifier: verifier,
+			})
+			c.AddValidator(NewPurchaseColourValidator(canvas))
+			c.AddValidator(&RoleValidator{
+				Canvas:      canvas,
+				Moderations: moderations,
+			})
+			c.AddValidator(&LedgerValidator{
+				Canvas:      canvas,
+				Grants:      grants,
+				Amendments:  amendments,
+				Moderations: moderations,
+			})
+			return c
+		})
+		model := NewMarketModel(node, listener, id, canvas, channel, grants, callback)
+		model.SetModerationChannel(OpenModeration(node, id, canvas, verifier), moderations)
+		model.SetAmendments(amendments)
+		model.SetVerifier(verifier)
+		return model, nil
+	case Mode_AUCTION:
+		if _, _, err := GetAuctionRound(canvas, 0); err != nil {
+			return nil, err
+		}
+		grants := OpenGrants(node, id, canvas, verifier)
+		channel := node.GetOrOpenChannel(GetPurchaseChannelName(id), func() *bcgo.Channel {
+			c := OpenPurchaseChannel(id)
+			c.AddValidator(&SignatureValidator{
+				Verifier: verifier,
+			})
+			c.AddValidator(NewPurchaseColourValidator(canvas))
+			c.AddValidator(&RoleValidator{
+				Canvas:      canvas,
+				Moderations: moderations,
+			})
+			c.AddValidator(&AuctionValidator{
+				Canvas:     canvas,
+				Amendments: amendments,
+			})
+			return c
+		})
+		model := NewAuctionModel(node, listener, id, canvas, channel, grants, callback)
+		model.SetModerationChannel(OpenModeration(node, id, canvas, verifier), moderations)
+		model.SetAmendments(amendments)
+		model.SetVerifier(verifier)
+		return model, nil
+	case Mode_RADICAL_MARKET:
+		grants := OpenGrants(node, id, canvas, verifier)
+		channel := node.GetOrOpenChannel(GetPurchaseChannelName(id), func() *bcgo.Channel {
+			c := OpenPurchaseChannel(id)
+			c.AddValidator(&SignatureValidator{
+				Verifier: verifier,
+			})
+			c.AddValidator(NewPurchaseColourValidator(canvas))
+			c.AddValidator(&RoleValidator{
+				Canvas:      canvas,
+				Moderations: moderations,
+			})
+			c.AddValidator(&LedgerValidator{
+				Canvas:      canvas,
+				Grants:      grants,
+				Amendments:  amendments,
+				Moderations: moderations,
+				Taxed:       true,
+			})
+			return c
+		})
+		tax := node.GetOrOpenChannel(GetTaxChannelName(id), func() *bcgo.Channel {
+			c := OpenTaxChannel(id)
+			c.AddValidator(&SignatureValidator{
+				Verifier: verifier,
+			})
+			c.AddValidator(&SettlementValidator{
+				Canvas:      canvas,
+				Grants:      grants,
+				Purchases:   channel,
+				Amendments:  amendments,
+				Moderations: moderations,
+			})
+			return c
 		})
-		return NewFreeForAllModel(node, listener, id, canvas, channel, callback), nil
+		model := NewRadicalMarketModel(node, listener, id, canvas, channel, grants, tax, callback)
+		model.SetModerationChannel(OpenModeration(node, id, canvas, verifier), moderations)
+		model.SetAmendments(amendments)
+		model.SetVerifier(verifier)
+		return model, nil
+	case Mode_DEMOCRACY:
+		channel := node.GetOrOpenChannel(GetVoteChannelName(id), func() *bcgo.Channel {
+			c := OpenVoteChannel(id)
+			c.AddValidator(&SignatureValidator{
+				Verifier: verifier,
+			})
+			c.AddValidator(&RetractionValidator{})
+			c.AddValidator(&BoundsValidator{
+				Canvas:     canvas,
+				Amendments: amendments,
+			})
+			c.AddValidator(NewVoteColourValidator(canvas))
+			c.AddValidator(&RoleValidator{
+				Canvas:      canvas,
+				Moderations: moderations,
+			})
+			return c
+		})
+		model := NewDemocracyModel(node, listener, id, canvas, channel, callback)
+		model.SetModerationChannel(OpenModeration(node, id, canvas, verifier), moderations)
+		model.SetAmendments(amendments)
+		model.SetVerifier(verifier)
+		return model, nil
+	case Mode_PROPOSAL:
+		if _, err := GetRound(canvas, 0); err != nil {
+			return nil, err
+		}
+		channel := node.GetOrOpenChannel(GetVoteChannelName(id), func() *bcgo.Channel {
+			c := OpenVoteChannel(id)
+			c.AddValidator(&SignatureValidator{
+				Verifier: verifier,
+			})
+			c.AddValidator(&RetractionValidator{})
+			c.AddValidator(&BoundsValidator{
+				Canvas:     canvas,
+				Amendments: amendments,
+			})
+			c.AddValidator(&RoleValidator{
+				Canvas:      canvas,
+				Moderations: moderations,
+			})
+			return c
+		})
+		proposals := node.GetOrOpenChannel(GetProposalChannelName(id), func() *bcgo.Channel {
+			c := OpenProposalChannel(id)
+			c.AddValidator(&SignatureValidator{
+				Verifier: verifier,
+			})
+			c.AddValidator(&RoleValidator{
+				Canvas:      canvas,
+				Moderations: moderations,
+			})
+			c.AddValidator(&ProposalValidator{
+				Canvas:     canvas,
+				Amendments: amendments,
+			})
+			return c
+		})
+		model := NewProposalModel(node, listener, id, canvas, channel, proposals, callback)
+		model.SetModerationChannel(OpenModeration(node, id, canvas, verifier), moderations)
+		model.SetAmendments(amendments)
+		model.SetVerifier(verifier)
+		return model, nil
 		/* TODO
-		   case Mode_DEMOCRACY:
-		       name := GetVoteChannelName(id)
-		       channel := m.Node.GetOrOpenChannel(name, func() *bcgo.Channel {
-		           return OpenVoteChannel(id)
-		       })
-		       return NewDemocracyModel(node, listener, id, canvas, channel, callback), nil
 		   case Mode_RADICAL_DEMOCRACY:
 		       name := GetVoteChannelName(id)
 		       channel := m.Node.GetOrOpenChannel(name, func() *bcgo.Channel {
 		           return OpenVoteChannel(id)
 		       })
 		       return NewRadicalDemocracyModel(node, listener, id, canvas, channel, callback), nil
-		   case Mode_MARKET:
-		       name := GetPurchaseChannelName(id)
-		       channel := m.Node.GetOrOpenChannel(name, func() *bcgo.Channel {
-		           return OpenPurchaseChannel(id)
-		       })
-		       return NewMarketModel(node, listener, id, canvas, channel, callback), nil
-		   case Mode_RADICAL_MARKET:
-		       name := GetPurchaseChannelName(id)
-		       channel := m.Node.GetOrOpenChannel(name, func() *bcgo.Channel {
-		           return OpenPurchaseChannel(id)
-		       })
-		       return NewRadicalMarketModel(node, listener, id, canvas, channel, callback), nil
 		*/
 	case Mode_UNKNOWN_MODE:
 		fallthrough
@@ -74,6 +244,54 @@ func GetModel(node *bcgo.Node, listener bcgo.MiningListener, id string, canvas *
 	}
 }
 
+// OpenModeration gets or opens the canvas' moderation channel, which only accepts records from moderators signed as checked by the verifier.
+func OpenModeration(node *bcgo.Node, id string, canvas *Canvas, verifier *Verifier) *bcgo.Channel {
+	return node.GetOrOpenChannel(GetModerationChannelName(id), func() *bcgo.Channel {
+		c := OpenModerationChannel(id)
+		c.AddValidator(&SignatureValidator{
+			Verifier: verifier,
+		})
+		c.AddValidator(&RoleValidator{
+			Canvas: canvas,
+			Role:   ROLE_MODERATOR,
+		})
+		return c
+	})
+}
+
+// OpenAmendments gets or opens the canvas' amendment channel, which only accepts records from the owner signed as checked by the verifier.
+func OpenAmendments(node *bcgo.Node, id string, canvas *Canvas, verifier *Verifier) *bcgo.Channel {
+	return node.GetOrOpenChannel(GetAmendmentChannelName(id), func() *bcgo.Channel {
+		c := OpenAmendmentChannel(id)
+		c.AddValidator(&SignatureValidator{
+			Verifier: verifier,
+		})
+		c.AddValidator(&RoleValidator{
+			Canvas: canvas,
+			Role:   ROLE_OWNER,
+		})
+		c.AddValidator(&AmendmentValidator{
+			Canvas: canvas,
+		})
+		return c
+	})
+}
+
+// OpenGrants gets or opens the canvas' grant channel, which only accepts records from the owner signed as checked by the verifier.
+func OpenGrants(node *bcgo.Node, id string, canvas *Canvas, verifier *Verifier) *bcgo.Channel {
+	return node.GetOrOpenChannel(GetGrantChannelName(id), func() *bcgo.Channel {
+		c := OpenGrantChannel(id)
+		c.AddValidator(&SignatureValidator{
+			Verifier: verifier,
+		})
+		c.AddValidator(&RoleValidator{
+			Canvas: canvas,
+			Role:   ROLE_OWNER,
+		})
+		return c
+	})
+}
+
 type BaseModel struct {
 	sync.Mutex
 	Node     *bcgo.Node
@@ -82,33 +300,440 @@ type BaseModel struct {
 	Canvas   *Canvas
 	Channel  *bcgo.Channel
 	OnUpdate func()
+	Miner    *Miner
+	Logger   Logger
+	Verifier *Verifier
 	Entries  map[string]*bcgo.BlockEntry
-	Order    []string
+	// Height of the block containing each entry
+	Heights map[string]uint64
+	// Timestamp of the block containing each entry, used for moderation as record timestamps are set by their creator
+	BlockTimestamps map[string]uint64
+	Order           []string
+	Moderation      *bcgo.Channel
+	Moderations     *Moderations
+	Amendments      *Amendments
+	// Other channels read by the model, refreshed and triggering reads along with Channel
+	Companions []*bcgo.Channel
+	// State of the parent canvas a fork starts from
+	Base map[Point]*Colour
+
+	events chan *Event
+	// Guards events, which is closed when the model is
+	eventLock sync.RWMutex
+	closed    bool
+	cancel    context.CancelFunc
+	// Done once the model is closed
+	ctx      context.Context
+	stop     context.CancelFunc
+	trigger  func()
+	removes  []func()
+	watching map[string]bool
+	miners   map[string]*Miner
+	group    sync.WaitGroup
+	// Guards pinned, pinTimestamp and pins
+	pinLock      sync.RWMutex
+	pinned       bool
+	pinTimestamp uint64
+	pins         map[string][]byte
 }
 
 func NewBaseModel(node *bcgo.Node, listener bcgo.MiningListener, id string, canvas *Canvas, channel *bcgo.Channel, callback func()) *BaseModel {
-	m := &BaseModel{
-		Node:     node,
-		Listener: listener,
-		ID:       id,
-		Canvas:   canvas,
-		Channel:  channel,
-		OnUpdate: callback,
-		Entries:  make(map[string]*bcgo.BlockEntry),
-	}
-	go m.Refresh()
+	m := &BaseModel{}
+	m.initialize(node, listener, id, canvas, channel, callback)
 	return m
 }
 
-func (m *BaseModel) Bind() {
+func (m *BaseModel) initialize(node *bcgo.Node, listener bcgo.MiningListener, id string, canvas *Canvas, channel *bcgo.Channel, callback func()) {
+	m.Node = node
+	m.Listener = listener
+	m.ID = id
+	m.Canvas = canvas
+	m.Channel = channel
+	m.OnUpdate = callback
+	m.Miner = NewMiner(node, channel, COLOUR_THRESHOLD, listener, MINE_ON_WRITE)
+	m.Miner.OnError = func(err error) {
+		m.Emit(&Event{
+			Type:    EVENT_MINING_FAILED,
+			Channel: channel.Name,
+			Error:   err,
+		})
+	}
+	m.Miner.OnRejected = func(entry *bcgo.BlockEntry, err error) {
+		m.Emit(&Event{
+			Type:    EVENT_REJECTED_RECORD,
+			Channel: channel.Name,
+			Hash:    base64.RawURLEncoding.EncodeToString(entry.RecordHash),
+			Error:   err,
+		})
+	}
+	m.Miner.OnMined = func(hash []byte, block *bcgo.Block) {
+		m.Emit(&Event{
+			Type:    EVENT_MINED,
+			Channel: channel.Name,
+			Hash:    base64.RawURLEncoding.EncodeToString(hash),
+		})
+	}
+	m.Logger = NewLogger(DEFAULT_LOG_LEVEL)
+	m.Entries = make(map[string]*bcgo.BlockEntry)
+	m.Heights = make(map[string]uint64)
+	m.BlockTimestamps = make(map[string]uint64)
+	m.Moderations = NewModerations(canvas)
+	m.events = make(chan *Event, EVENT_BUFFER_SIZE)
+	m.ctx, m.stop = context.WithCancel(context.Background())
+	m.watching = make(map[string]bool)
+	m.miners = make(map[string]*Miner)
+}
+
+// SetVerifier sets the verifier used to check records when reading and before mining.
+func (m *BaseModel) SetVerifier(verifier *Verifier) {
+	m.Verifier = verifier
+	m.Miner.Verifier = verifier
+}
+
+// SetModerationChannel sets the channel from which moderation actions are read into moderations, it must be called before Bind.
+func (m *BaseModel) SetModerationChannel(channel *bcgo.Channel, moderations *Moderations) {
+	m.Moderation = channel
+	m.Moderations = moderations
+	m.AddCompanion(channel)
+}
+
+// AddCompanion adds another channel read by the model, it must be called before Bind.
+func (m *BaseModel) AddCompanion(channel *bcgo.Channel) {
+	m.Companions = append(m.Companions, channel)
+}
+
+// ReadModerations reads the moderation actions, if the model has a moderation channel.
+func (m *BaseModel) ReadModerations(ctx context.Context) {
+	if m.Moderation == nil || ctx.Err() != nil {
+		return
+	}
+	if err := m.Moderations.Read(m.Moderation.Name, m.GetHeadOf(m.Moderation), m.Node.Cache, m.Node.Network, m.Verifier, func(entry *bcgo.BlockEntry, err error) {
+		m.Emit(&Event{
+			Type:    EVENT_CORRUPT_RECORD,
+			Channel: m.Moderation.Name,
+			Hash:    base64.RawURLEncoding.EncodeToString(entry.RecordHash),
+			Error:   err,
+		})
+	}); err != nil {
+		m.Emit(&Event{
+			Type:    EVENT_READ_FAILED,
+			Channel: m.Moderation.Name,
+			Error:   err,
+		})
+	}
+}
+
+// SetAmendments sets the amendments to the canvas, which are read along with Channel.
+func (m *BaseModel) SetAmendments(amendments *Amendments) {
+	m.Amendments = amendments
+	m.AddCompanion(amendments.Channel)
+}
+
+// ReadAmendments reads the amendments to the canvas and resizes the canvas to the latest.
+func (m *BaseModel) ReadAmendments(ctx context.Context) {
+	if m.Amendments == nil || ctx.Err() != nil {
+		return
+	}
+	if err := m.Amendments.Update(m.GetHeadOf(m.Amendments.Channel), m.Node.Cache, m.Node.Network, m.Verifier); err != nil {
+		m.Emit(&Event{
+			Type:    EVENT_READ_FAILED,
+			Channel: m.Amendments.Channel.Name,
+			Error:   err,
+		})
+		return
+	}
+	canvas := m.Amendments.GetCanvas(math.MaxUint64)
+	m.Lock()
+	m.Canvas = canvas
+	m.Unlock()
+}
+
+// SetPin fixes the block from which Channel is read, so the model shows the canvas as it was when the block was mined.
+// Every other channel is read as of its latest block mined no later than the pinned block, the channels must be refreshed first.
+func (m *BaseModel) SetPin(hash []byte) error {
+	block, err := bcgo.GetBlock(m.Channel.Name, m.Node.Cache, m.Node.Network, hash)
+	if err != nil {
+		return err
+	}
+	m.pinLock.Lock()
+	defer m.pinLock.Unlock()
+	m.pinned = true
+	m.pinTimestamp = block.Timestamp
+	m.pins = map[string][]byte{
+		m.Channel.Name: hash,
+	}
+	return nil
+}
+
+// GetHead returns the block from which Channel is read.
+func (m *BaseModel) GetHead() []byte {
+	return m.GetHeadOf(m.Channel)
+}
+
+// GetHeadOf returns the block from which the channel is read, which is its latest block unless the model is pinned.
+func (m *BaseModel) GetHeadOf(channel *bcgo.Channel) []byte {
+	m.pinLock.RLock()
+	pinned := m.pinned
+	head, ok := m.pins[channel.Name]
+	timestamp := m.pinTimestamp
+	m.pinLock.RUnlock()
+	if !pinned {
+		return channel.Head
+	}
+	if ok {
+		return head
+	}
+	// Find the latest block mined no later than the pinned block
+	if err := bcgo.Iterate(channel.Name, channel.Head, nil, m.Node.Cache, m.Node.Network, func(h []byte, b *bcgo.Block) error {
+		if b.Timestamp <= timestamp {
+			head = h
+			return bcgo.StopIterationError{}
+		}
+		return nil
+	}); err != nil {
+		if _, ok := err.(bcgo.StopIterationError); !ok {
+			m.Logger.Debug(err)
+		}
+	}
+	m.pinLock.Lock()
+	m.pins[channel.Name] = head
+	m.pinLock.Unlock()
+	return head
+}
+
+// ReadBase loads the state of the parent canvas if the canvas is a fork and it has not already been loaded.
+func (m *BaseModel) ReadBase(ctx context.Context) {
+	if !IsFork(m.Canvas) {
+		return
+	}
+	m.Lock()
+	loaded := m.Base != nil
+	m.Unlock()
+	if loaded {
+		return
+	}
+	base, err := GetBaseImage(ctx, m.Node, m.Canvas)
+	if err != nil {
+		m.Emit(&Event{
+			Type:    EVENT_READ_FAILED,
+			Channel: m.Channel.Name,
+			Error:   err,
+		})
+		return
+	}
+	m.Lock()
+	m.Base = base
+	m.Unlock()
+}
+
+// DrawBase calls the callback with each location of the parent canvas' state which is within the canvas.
+func (m *BaseModel) DrawBase(callback func(*Location, *Colour)) {
+	m.Lock()
+	base := m.Base
+	m.Unlock()
+	for _, p := range SortPoints(base) {
+		l := p.Location()
+		if IsInBounds(m.Canvas, l) {
+			callback(l, base[p])
+		}
+	}
+}
+
+// CheckContributor returns an error if the alias is banned from contributing to the canvas.
+func (m *BaseModel) CheckContributor(alias string) error {
+	if m.Moderations.IsBanned(alias, 0) {
+		return fmt.Errorf(ERROR_ALIAS_BANNED, alias)
+	}
+	return nil
+}
+
+// IsBanned returns true if the entry's creator was banned before the entry was mined.
+func (m *BaseModel) IsBanned(id string) bool {
+	entry, ok := m.Entries[id]
+	if !ok {
+		return false
+	}
+	return m.Moderations.IsBanned(entry.Record.Creator, m.BlockTimestamps[id])
+}
+
+// IsHidden returns true if the entry's contribution at the location has been hidden by moderation.
+func (m *BaseModel) IsHidden(id string, l *Location) bool {
+	entry, ok := m.Entries[id]
+	if !ok {
+		return false
+	}
+	return m.Moderations.IsHidden(entry.Record, m.Heights[id], m.BlockTimestamps[id], l)
+}
+
+// Events returns the channel on which the model reports events and errors.
+// Events are dropped if the channel is full, and the channel is closed when the model is.
+func (m *BaseModel) Events() <-chan *Event {
+	return m.events
+}
+
+// Emit reports the event to the model's events channel and logger.
+func (m *BaseModel) Emit(event *Event) {
+	if event.Error != nil {
+		m.Logger.Error(event)
+	} else {
+		m.Logger.Debug(event)
+	}
+	m.eventLock.RLock()
+	defer m.eventLock.RUnlock()
+	if m.closed {
+		return
+	}
+	select {
+	case m.events <- event:
+	default:
+		m.Logger.Warn("Event dropped:", event)
+	}
+}
+
+func (m *BaseModel) Bind(context.Context) {
 	// Do nothing
 }
 
+// bind triggers read whenever the channel is updated, starts the miner, then refreshes and reads the channel.
+// All are cancelled when the returned context is done, which happens when the given context is done or the model is closed.
+func (m *BaseModel) bind(ctx context.Context, read func(context.Context)) context.Context {
+	ctx, cancel := context.WithCancel(ctx)
+	m.Lock()
+	m.cancel = cancel
+	m.trigger = func() {
+		read(ctx)
+	}
+	for _, c := range append([]*bcgo.Channel{m.Channel}, m.Companions...) {
+		m.watch(c)
+	}
+	m.Unlock()
+	m.Miner.Start(ctx)
+	m.Go(func() {
+		if err := m.Refresh(ctx); err != nil {
+			m.Emit(&Event{
+				Type:    EVENT_REFRESH_FAILED,
+				Channel: m.Channel.Name,
+				Error:   err,
+			})
+		}
+		read(ctx)
+	})
+	return ctx
+}
+
+// Watch triggers a read whenever the channel is updated, such as for channels discovered while reading.
+// It does nothing if the model isn't bound or the channel is already watched.
+func (m *BaseModel) Watch(channel *bcgo.Channel) {
+	m.Lock()
+	defer m.Unlock()
+	m.watch(channel)
+}
+
+func (m *BaseModel) watch(channel *bcgo.Channel) {
+	if m.trigger == nil || m.watching[channel.Name] {
+		return
+	}
+	m.watching[channel.Name] = true
+	m.removes = append(m.removes, AddTrigger(channel, m.trigger))
+}
+
+// WriteRecord writes the record to the channel and schedules it to be mined, a miner is created for channels other than the model's own.
+func (m *BaseModel) WriteRecord(channel *bcgo.Channel, record *bcgo.Record) error {
+	reference, err := bcgo.WriteRecord(channel.Name, m.Node.Cache, record)
+	if err != nil {
+		return err
+	}
+	m.Emit(&Event{
+		Type:    EVENT_WRITE,
+		Channel: channel.Name,
+		Hash:    base64.RawURLEncoding.EncodeToString(reference.RecordHash),
+	})
+	if channel == m.Channel {
+		m.Miner.Request()
+		return nil
+	}
+	m.Lock()
+	miner, ok := m.miners[channel.Name]
+	if !ok {
+		miner = NewMiner(m.Node, channel, COLOUR_THRESHOLD, m.Listener, MINE_ON_WRITE)
+		miner.Verifier = m.Verifier
+		miner.OnError = func(err error) {
+			m.Emit(&Event{
+				Type:    EVENT_MINING_FAILED,
+				Channel: channel.Name,
+				Error:   err,
+			})
+		}
+		miner.OnRejected = func(entry *bcgo.BlockEntry, err error) {
+			m.Emit(&Event{
+				Type:    EVENT_REJECTED_RECORD,
+				Channel: channel.Name,
+				Hash:    base64.RawURLEncoding.EncodeToString(entry.RecordHash),
+				Error:   err,
+			})
+		}
+		m.miners[channel.Name] = miner
+	}
+	m.Unlock()
+	miner.Request()
+	return nil
+}
+
+// Close removes the channel triggers, cancels any in-flight refresh or mining, and waits for the model's goroutines to finish.
+func (m *BaseModel) Close() error {
+	m.Lock()
+	removes, cancel := m.removes, m.cancel
+	m.removes, m.cancel, m.trigger = nil, nil, nil
+	m.watching = make(map[string]bool)
+	var miners []*Miner
+	for _, miner := range m.miners {
+		miners = append(miners, miner)
+	}
+	m.Unlock()
+	for _, r := range removes {
+		r()
+	}
+	if cancel != nil {
+		cancel()
+	}
+	m.stop()
+	m.Miner.Stop()
+	for _, miner := range miners {
+		miner.Stop()
+	}
+	m.group.Wait()
+	m.eventLock.Lock()
+	if !m.closed {
+		m.closed = true
+		close(m.events)
+	}
+	m.eventLock.Unlock()
+	return nil
+}
+
+// Context returns a context which is done once the model is closed, such as to cancel work done before writing.
+func (m *BaseModel) Context() context.Context {
+	return m.ctx
+}
+
+// Go runs the function in a goroutine which Close will wait for.
+func (m *BaseModel) Go(f func()) {
+	m.group.Add(1)
+	go func() {
+		defer m.group.Done()
+		f()
+	}()
+}
+
 func (m *BaseModel) Draw(func(*Location, *Colour)) {
 	// Do nothing
 }
 
-func (m *BaseModel) Read() {
+func (m *BaseModel) DrawLayers(func(*Location, *Colour)) {
+	// Do nothing
+}
+
+func (m *BaseModel) Read(context.Context) {
 	// Do nothing
 }
 
@@ -117,21 +742,53 @@ func (m *BaseModel) Write(*Location, *Colour) error {
 	return nil
 }
 
-func (m *BaseModel) Refresh() error {
-	return m.Channel.Refresh(m.Node.Cache, m.Node.Network)
+// Refresh loads the head of the channel and each companion from the cache, then pulls them from the network.
+// Channels without a head are empty, other failures are returned together once every channel has been refreshed.
+func (m *BaseModel) Refresh(ctx context.Context) error {
+	var errs []string
+	for _, c := range append([]*bcgo.Channel{m.Channel}, m.Companions...) {
+		if err := m.refresh(ctx, c); err != nil {
+			if e := ctx.Err(); e != nil {
+				return e
+			}
+			errs = append(errs, c.Name+": "+err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf(ERROR_REFRESH_FAILED, strings.Join(errs, ", "))
+	}
+	return nil
 }
 
-func (m *BaseModel) Mine() error {
-	// Mine Channel
-	if _, _, err := m.Node.Mine(m.Channel, COLOUR_THRESHOLD, m.Listener); err != nil {
+func (m *BaseModel) refresh(ctx context.Context, channel *bcgo.Channel) error {
+	// Load Channel
+	if err := channel.LoadCachedHead(m.Node.Cache); err != nil && !IsHeadNotFound(channel.Name, err) {
 		return err
 	}
-
+	if e := ctx.Err(); e != nil {
+		return e
+	}
 	if m.Node.Network != nil {
-		// Push Update to Peers
-		if err := m.Channel.Push(m.Node.Cache, m.Node.Network); err != nil {
+		if err := channel.Pull(m.Node.Cache, m.Node.Network); err != nil && !IsHeadNotFound(channel.Name, err) {
 			return err
 		}
 	}
 	return nil
 }
+
+// IsHeadNotFound returns true if the error reports that the cache or network has no head for the channel, such as when nothing has been mined to it.
+func IsHeadNotFound(channel string, err error) bool {
+	if os.IsNotExist(err) {
+		// File cache
+		return true
+	}
+	switch err.Error() {
+	case fmt.Sprintf(bcgo.ERROR_HEAD_NOT_FOUND, channel), "Could not get " + channel + " head from peers":
+		return true
+	}
+	return false
+}
+
+func (m *BaseModel) Mine(ctx context.Context) error {
+	return m.Miner.Mine(ctx)
+}
@@ -0,0 +1,237 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"fmt"
+	"github.com/AletheiaWareLLC/bcgo"
+	"github.com/golang/protobuf/proto"
+	"sort"
+	"sync"
+)
+
+const (
+	ERROR_MODERATION_INVALID = "Moderation invalid: %s"
+)
+
+func UnmarshalModeration(data []byte) (*Moderation, error) {
+	moderation := &Moderation{}
+	if err := proto.Unmarshal(data, moderation); err != nil {
+		return nil, err
+	}
+	return moderation, nil
+}
+
+// CreateMask creates a moderation which hides all contributions in the region made before it.
+func CreateMask(from, to *Location, reason string) *Moderation {
+	return &Moderation{
+		Action: Action_MASK,
+		From:   from,
+		To:     to,
+		Reason: reason,
+	}
+}
+
+// CreateRevert creates a moderation which hides all contributions in the region mined after the given block height and made before it.
+func CreateRevert(from, to *Location, height uint64, reason string) *Moderation {
+	return &Moderation{
+		Action: Action_REVERT,
+		From:   from,
+		To:     to,
+		Height: height,
+		Reason: reason,
+	}
+}
+
+// CreateBan creates a moderation which hides all contributions by the alias made after it.
+func CreateBan(alias, reason string) *Moderation {
+	return &Moderation{
+		Action: Action_BAN,
+		Alias:  alias,
+		Reason: reason,
+	}
+}
+
+func CreateModerationRecord(alias string, key *rsa.PrivateKey, moderation *Moderation) (*bcgo.Record, error) {
+	data, err := proto.Marshal(moderation)
+	if err != nil {
+		return nil, err
+	}
+	return CreateRecord(alias, key, data)
+}
+
+// ValidateModeration checks the moderation is well formed.
+func ValidateModeration(moderation *Moderation) error {
+	switch moderation.Action {
+	case Action_MASK, Action_REVERT:
+		if moderation.From == nil {
+			return fmt.Errorf(ERROR_MODERATION_INVALID, "missing region")
+		}
+	case Action_BAN:
+		if moderation.Alias == "" {
+			return fmt.Errorf(ERROR_MODERATION_INVALID, "missing alias")
+		}
+	default:
+		return fmt.Errorf(ERROR_MODERATION_INVALID, "unrecognized action "+moderation.Action.String())
+	}
+	return nil
+}
+
+// InRegion returns true if the location is within the moderation's region, inclusive.
+// A moderation without a To location covers only its From location.
+func InRegion(moderation *Moderation, l *Location) bool {
+	from, to := moderation.From, moderation.To
+	if from == nil {
+		return false
+	}
+	if to == nil {
+		to = from
+	}
+	within := func(v, a, b uint32) bool {
+		if a > b {
+			a, b = b, a
+		}
+		return a <= v && v <= b
+	}
+	return within(l.W, from.W, to.W) && within(l.X, from.X, to.X) && within(l.Y, from.Y, to.Y) && within(l.Z, from.Z, to.Z)
+}
+
+// ModerationAction is a moderation along with the record which enacted it.
+type ModerationAction struct {
+	Entry *bcgo.BlockEntry
+	// Timestamp of the block containing the action
+	BlockTimestamp uint64
+	Moderation     *Moderation
+}
+
+// Moderations holds the actions taken by a canvas' moderators.
+// Whether a contribution is hidden depends only on the contribution and each action, not the order actions are read, so all nodes agree.
+type Moderations struct {
+	sync.RWMutex
+	Canvas  *Canvas
+	Actions []*ModerationAction
+}
+
+func NewModerations(canvas *Canvas) *Moderations {
+	return &Moderations{
+		Canvas: canvas,
+	}
+}
+
+// Read replaces the actions with those in the channel, from the given head, which were created by moderators of the canvas.
+// Records which cannot be parsed are skipped and passed to corrupt, if set.
+func (m *Moderations) Read(channel string, head []byte, cache bcgo.Cache, network bcgo.Network, verifier *Verifier, corrupt func(*bcgo.BlockEntry, error)) error {
+	var actions []*ModerationAction
+	if err := bcgo.Iterate(channel, head, nil, cache, network, func(hash []byte, block *bcgo.Block) error {
+		for _, entry := range block.Entry {
+			if ok, err := verifier.Accept(entry); err != nil {
+				return err
+			} else if !ok {
+				continue
+			}
+			if !IsModerator(m.Canvas, entry.Record.Creator) {
+				continue
+			}
+			moderation, err := UnmarshalModeration(entry.Record.Payload)
+			if err != nil {
+				if corrupt != nil {
+					corrupt(entry, err)
+				}
+				continue
+			}
+			if ValidateModeration(moderation) != nil {
+				continue
+			}
+			actions = append(actions, &ModerationAction{
+				Entry:          entry,
+				BlockTimestamp: block.Timestamp,
+				Moderation:     moderation,
+			})
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	// Sort for auditing, oldest first
+	sort.Slice(actions, func(i, j int) bool {
+		a, b := actions[i], actions[j]
+		if a.BlockTimestamp != b.BlockTimestamp {
+			return a.BlockTimestamp < b.BlockTimestamp
+		}
+		return bytes.Compare(a.Entry.RecordHash, b.Entry.RecordHash) < 0
+	})
+	m.Lock()
+	m.Actions = actions
+	m.Unlock()
+	return nil
+}
+
+// Audit calls the callback with each action in the order they were taken.
+func (m *Moderations) Audit(callback func(*bcgo.BlockEntry, *Moderation)) {
+	m.RLock()
+	defer m.RUnlock()
+	for _, a := range m.Actions {
+		callback(a.Entry, a.Moderation)
+	}
+}
+
+// IsBanned returns true if the alias was banned from the canvas, or by a moderator in a block mined before the block timestamp.
+// Block timestamps are used as record timestamps are set by their creator, a block timestamp of zero means not yet mined.
+func (m *Moderations) IsBanned(alias string, blockTimestamp uint64) bool {
+	if m == nil {
+		return false
+	}
+	if IsBanned(m.Canvas, alias) {
+		return true
+	}
+	m.RLock()
+	defer m.RUnlock()
+	for _, a := range m.Actions {
+		if a.Moderation.Action == Action_BAN && a.Moderation.Alias == alias && (blockTimestamp == 0 || a.BlockTimestamp < blockTimestamp) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsHidden returns true if the contribution at the location, in the record mined at the height and block timestamp, should not be drawn.
+func (m *Moderations) IsHidden(record *bcgo.Record, height, blockTimestamp uint64, l *Location) bool {
+	if m == nil {
+		return false
+	}
+	if m.IsBanned(record.Creator, blockTimestamp) {
+		return true
+	}
+	m.RLock()
+	defer m.RUnlock()
+	for _, a := range m.Actions {
+		if blockTimestamp == 0 || blockTimestamp > a.BlockTimestamp || !InRegion(a.Moderation, l) {
+			continue
+		}
+		switch a.Moderation.Action {
+		case Action_MASK:
+			return true
+		case Action_REVERT:
+			if height > a.Moderation.Height {
+				return true
+			}
+		}
+	}
+	return false
+}
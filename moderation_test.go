@@ -0,0 +1,176 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"github.com/AletheiaWareLLC/bcgo"
+	"github.com/AletheiaWareLLC/colourgo"
+	"github.com/AletheiaWareLLC/testinggo"
+	"testing"
+)
+
+func writeAndMine(t *testing.T, node *bcgo.Node, channel *bcgo.Channel, record *bcgo.Record) []byte {
+	t.Helper()
+	reference, err := bcgo.WriteRecord(channel.Name, node.Cache, record)
+	testinggo.AssertNoError(t, err)
+	miner := colourgo.NewMiner(node, channel, colourgo.COLOUR_THRESHOLD, nil, colourgo.MINE_MANUAL)
+	testinggo.AssertNoError(t, miner.Mine(context.Background()))
+	return reference.RecordHash
+}
+
+func makeModerationAction(creator string, timestamp uint64, moderation *colourgo.Moderation) *colourgo.ModerationAction {
+	return &colourgo.ModerationAction{
+		Entry: &bcgo.BlockEntry{
+			Record: &bcgo.Record{
+				Creator:   creator,
+				Timestamp: timestamp,
+			},
+		},
+		BlockTimestamp: timestamp,
+		Moderation:     moderation,
+	}
+}
+
+func TestModerations_IsHidden(t *testing.T) {
+	canvas := &colourgo.Canvas{
+		Owner: "Moderator",
+	}
+	moderations := colourgo.NewModerations(canvas)
+	moderations.Actions = []*colourgo.ModerationAction{
+		makeModerationAction("Moderator", 10, colourgo.CreateMask(&colourgo.Location{X: 0, Y: 0}, &colourgo.Location{X: 1, Y: 1}, "Offensive")),
+		makeModerationAction("Moderator", 20, colourgo.CreateRevert(&colourgo.Location{X: 5, Y: 5}, nil, 3, "Vandalism")),
+		makeModerationAction("Moderator", 30, colourgo.CreateBan("Vandal", "Repeated vandalism")),
+	}
+	for name, test := range map[string]struct {
+		creator   string
+		timestamp uint64
+		height    uint64
+		location  *colourgo.Location
+		hidden    bool
+	}{
+		"MaskedBefore":      {"Alice", 5, 1, &colourgo.Location{X: 1, Y: 0}, true},
+		"MaskedAfter":       {"Alice", 15, 4, &colourgo.Location{X: 1, Y: 0}, false},
+		"OutsideMask":       {"Alice", 5, 1, &colourgo.Location{X: 2, Y: 0}, false},
+		"RevertedAbove":     {"Alice", 15, 4, &colourgo.Location{X: 5, Y: 5}, true},
+		"RevertedAtOrBelow": {"Alice", 15, 3, &colourgo.Location{X: 5, Y: 5}, false},
+		"RevertedAfter":     {"Alice", 25, 5, &colourgo.Location{X: 5, Y: 5}, false},
+		"BannedBefore":      {"Vandal", 25, 5, &colourgo.Location{X: 9, Y: 9}, false},
+		"BannedAfter":       {"Vandal", 35, 6, &colourgo.Location{X: 9, Y: 9}, true},
+		"BannedUnmined":     {"Vandal", 0, 0, &colourgo.Location{X: 9, Y: 9}, true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// Backdated, only the block timestamp counts
+			record := &bcgo.Record{
+				Creator:   test.creator,
+				Timestamp: 1,
+			}
+			if got := moderations.IsHidden(record, test.height, test.timestamp, test.location); got != test.hidden {
+				t.Errorf("Expected hidden %t, got %t", test.hidden, got)
+			}
+		})
+	}
+}
+
+func TestModerations_Read(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	testinggo.AssertNoError(t, err)
+	node := &bcgo.Node{
+		Alias:    "Moderator",
+		Key:      key,
+		Cache:    bcgo.NewMemoryCache(10),
+		Channels: make(map[string]*bcgo.Channel),
+	}
+	channel := node.GetOrOpenChannel("TEST_MODERATION", func() *bcgo.Channel {
+		return &bcgo.Channel{
+			Name: "TEST_MODERATION",
+		}
+	})
+	ban, err := colourgo.CreateModerationRecord(node.Alias, node.Key, colourgo.CreateBan("Vandal", "Repeated vandalism"))
+	testinggo.AssertNoError(t, err)
+	_, err = bcgo.WriteRecord(channel.Name, node.Cache, ban)
+	testinggo.AssertNoError(t, err)
+	corrupt, err := colourgo.CreateRecord(node.Alias, node.Key, []byte{0xff})
+	testinggo.AssertNoError(t, err)
+	writeAndMine(t, node, channel, corrupt)
+
+	moderations := colourgo.NewModerations(&colourgo.Canvas{
+		Owner: "Moderator",
+	})
+	var corrupted []*bcgo.BlockEntry
+	testinggo.AssertNoError(t, moderations.Read(channel.Name, channel.Head, node.Cache, nil, nil, func(entry *bcgo.BlockEntry, err error) {
+		corrupted = append(corrupted, entry)
+	}))
+	if len(corrupted) != 1 {
+		t.Fatalf("Expected 1 corrupt record, got %d", len(corrupted))
+	}
+	if len(moderations.Actions) != 1 {
+		t.Fatalf("Expected 1 action, got %d", len(moderations.Actions))
+	}
+	if moderations.Actions[0].BlockTimestamp == 0 {
+		t.Error("Expected block timestamp")
+	}
+}
+
+func TestRoleValidator_Banned(t *testing.T) {
+	canvas := &colourgo.Canvas{
+		Owner: "Moderator",
+	}
+	moderations := colourgo.NewModerations(canvas)
+	moderations.Actions = []*colourgo.ModerationAction{
+		makeModerationAction("Moderator", 10, colourgo.CreateBan("Vandal", "Repeated vandalism")),
+	}
+	validator := &colourgo.RoleValidator{
+		Canvas:      canvas,
+		Moderations: moderations,
+	}
+	channel := &bcgo.Channel{
+		Name: "TEST_CHANNEL",
+	}
+	for name, test := range map[string]struct {
+		timestamp uint64
+		err       string
+	}{
+		"MinedBefore": {5, ""},
+		"MinedAfter":  {20, "Alias banned from canvas: Vandal"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			block := &bcgo.Block{
+				Timestamp:   test.timestamp,
+				ChannelName: channel.Name,
+				Length:      1,
+				Entry: []*bcgo.BlockEntry{
+					&bcgo.BlockEntry{
+						Record: &bcgo.Record{
+							// Backdated, only the block timestamp counts
+							Timestamp: 1,
+							Creator:   "Vandal",
+						},
+					},
+				},
+			}
+			err := validator.Validate(channel, bcgo.NewMemoryCache(1), nil, []byte("hash"), block)
+			if test.err == "" {
+				testinggo.AssertNoError(t, err)
+			} else {
+				testinggo.AssertError(t, test.err, err)
+			}
+		})
+	}
+}
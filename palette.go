@@ -0,0 +1,192 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo
+
+import (
+	"encoding/base64"
+	"fmt"
+	"github.com/AletheiaWareLLC/bcgo"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+const (
+	ERROR_CHANNEL_OUT_OF_RANGE = "Colour channel out of range: %s %d"
+	ERROR_HEX_COLOUR_INVALID   = "Hex colour invalid: %s"
+	ERROR_NOT_IN_PALETTE       = "Colour not in palette: %s"
+	ERROR_RECORD_COLOUR        = "Record %s: %s"
+)
+
+// ParseHexColour parses a colour of the form #RRGGBB or #RRGGBBAA, the leading # is optional and alpha defaults to opaque.
+func ParseHexColour(s string) (*Colour, error) {
+	h := strings.TrimPrefix(s, "#")
+	if len(h) != 6 && len(h) != 8 {
+		return nil, fmt.Errorf(ERROR_HEX_COLOUR_INVALID, s)
+	}
+	if len(h) == 6 {
+		h += "ff"
+	}
+	v, err := strconv.ParseUint(h, 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf(ERROR_HEX_COLOUR_INVALID, s)
+	}
+	return &Colour{
+		Red:   uint32(v>>24) & 0xff,
+		Green: uint32(v>>16) & 0xff,
+		Blue:  uint32(v>>8) & 0xff,
+		Alpha: uint32(v) & 0xff,
+	}, nil
+}
+
+// FormatHexColour formats the colour as #rrggbb if it is opaque, otherwise #rrggbbaa.
+func FormatHexColour(c *Colour) string {
+	n := nrgba(c)
+	if n.A == MAX_CHANNEL {
+		return fmt.Sprintf("#%02x%02x%02x", n.R, n.G, n.B)
+	}
+	return fmt.Sprintf("#%02x%02x%02x%02x", n.R, n.G, n.B, n.A)
+}
+
+// ToRGBA converts the colour to an alpha-premultiplied color.RGBA.
+func ToRGBA(c *Colour) color.RGBA {
+	return color.RGBAModel.Convert(nrgba(c)).(color.RGBA)
+}
+
+// FromColor converts any color.Color to a Colour.
+func FromColor(c color.Color) *Colour {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return &Colour{
+		Red:   uint32(n.R),
+		Green: uint32(n.G),
+		Blue:  uint32(n.B),
+		Alpha: uint32(n.A),
+	}
+}
+
+// ToPalette converts the colours to a color.Palette.
+func ToPalette(colours []*Colour) color.Palette {
+	var p color.Palette
+	for _, c := range colours {
+		p = append(p, nrgba(c))
+	}
+	return p
+}
+
+// NearestColour returns the colour in the palette with the smallest distance to the given colour.
+func NearestColour(palette []*Colour, c *Colour) *Colour {
+	var nearest *Colour
+	var min int64
+	for _, p := range palette {
+		var d int64
+		for _, v := range [][2]uint32{
+			{p.Red, c.Red},
+			{p.Green, c.Green},
+			{p.Blue, c.Blue},
+			{p.Alpha, c.Alpha},
+		} {
+			delta := int64(v[0]) - int64(v[1])
+			d += delta * delta
+		}
+		if nearest == nil || d < min {
+			nearest = p
+			min = d
+		}
+	}
+	return nearest
+}
+
+func IsInPalette(palette []*Colour, c *Colour) bool {
+	for _, p := range palette {
+		if EqualColour(p, c) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateColour ensures each channel is in the range 0-255, and the colour is in the canvas palette if one is declared.
+func ValidateColour(canvas *Canvas, c *Colour) error {
+	for _, channel := range []struct {
+		name  string
+		value uint32
+	}{
+		{"red", c.GetRed()},
+		{"green", c.GetGreen()},
+		{"blue", c.GetBlue()},
+		{"alpha", c.GetAlpha()},
+	} {
+		if channel.value > MAX_CHANNEL {
+			return fmt.Errorf(ERROR_CHANNEL_OUT_OF_RANGE, channel.name, channel.value)
+		}
+	}
+	if len(canvas.Palette) > 0 && !IsInPalette(canvas.Palette, c) {
+		return fmt.Errorf(ERROR_NOT_IN_PALETTE, FormatHexColour(c))
+	}
+	return nil
+}
+
+// ColourValidator ensures the colour of each record in a channel is valid for the canvas.
+type ColourValidator struct {
+	Canvas    *Canvas
+	GetColour func([]byte) (*Colour, error)
+}
+
+func NewVoteColourValidator(canvas *Canvas) *ColourValidator {
+	return &ColourValidator{
+		Canvas: canvas,
+		GetColour: func(data []byte) (*Colour, error) {
+			vote, err := UnmarshalVote(data)
+			if err != nil {
+				return nil, err
+			}
+			return vote.Colour, nil
+		},
+	}
+}
+
+func NewPurchaseColourValidator(canvas *Canvas) *ColourValidator {
+	return &ColourValidator{
+		Canvas: canvas,
+		GetColour: func(data []byte) (*Colour, error) {
+			purchase, err := UnmarshalPurchase(data)
+			if err != nil {
+				return nil, err
+			}
+			return purchase.Colour, nil
+		},
+	}
+}
+
+func (v *ColourValidator) Validate(channel *bcgo.Channel, cache bcgo.Cache, network bcgo.Network, hash []byte, block *bcgo.Block) error {
+	return bcgo.Iterate(channel.Name, hash, block, cache, network, func(h []byte, b *bcgo.Block) error {
+		for _, entry := range b.Entry {
+			c, err := v.GetColour(entry.Record.Payload)
+			if err != nil {
+				return err
+			}
+			if c == nil {
+				// Record doesn't set a colour, such as a delegation
+				continue
+			}
+			if err := ValidateColour(v.Canvas, c); err != nil {
+				return fmt.Errorf(ERROR_RECORD_COLOUR, base64.RawURLEncoding.EncodeToString(entry.RecordHash), err)
+			}
+		}
+		return nil
+	})
+}
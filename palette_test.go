@@ -0,0 +1,66 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo_test
+
+import (
+	"github.com/AletheiaWareLLC/colourgo"
+	"github.com/AletheiaWareLLC/testinggo"
+	"testing"
+)
+
+func TestParseHexColour(t *testing.T) {
+	t.Run("RGB", func(t *testing.T) {
+		c, err := colourgo.ParseHexColour("#ff8000")
+		testinggo.AssertNoError(t, err)
+		testinggo.AssertProtobufEqual(t, &colourgo.Colour{Red: 255, Green: 128, Alpha: 255}, c)
+		if s := colourgo.FormatHexColour(c); s != "#ff8000" {
+			t.Errorf("Incorrect format; expected '#ff8000', got '%s'", s)
+		}
+	})
+	t.Run("RGBA", func(t *testing.T) {
+		c, err := colourgo.ParseHexColour("00ff0080")
+		testinggo.AssertNoError(t, err)
+		testinggo.AssertProtobufEqual(t, &colourgo.Colour{Green: 255, Alpha: 128}, c)
+		if s := colourgo.FormatHexColour(c); s != "#00ff0080" {
+			t.Errorf("Incorrect format; expected '#00ff0080', got '%s'", s)
+		}
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := colourgo.ParseHexColour("#fff")
+		testinggo.AssertError(t, "Hex colour invalid: #fff", err)
+	})
+}
+
+func TestValidateColour(t *testing.T) {
+	black := &colourgo.Colour{Alpha: 255}
+	white := &colourgo.Colour{Red: 255, Green: 255, Blue: 255, Alpha: 255}
+	canvas, err := colourgo.CreateCanvas("TEST_CANVAS", 1, 1, 1, colourgo.Mode_FREE_FOR_ALL)
+	testinggo.AssertNoError(t, err)
+	t.Run("OutOfRange", func(t *testing.T) {
+		testinggo.AssertError(t, "Colour channel out of range: red 256", colourgo.ValidateColour(canvas, &colourgo.Colour{Red: 256, Alpha: 255}))
+	})
+	canvas.Palette = []*colourgo.Colour{black, white}
+	t.Run("InPalette", func(t *testing.T) {
+		testinggo.AssertNoError(t, colourgo.ValidateColour(canvas, white))
+	})
+	t.Run("NotInPalette", func(t *testing.T) {
+		testinggo.AssertError(t, "Colour not in palette: #808080", colourgo.ValidateColour(canvas, &colourgo.Colour{Red: 128, Green: 128, Blue: 128, Alpha: 255}))
+	})
+	t.Run("Nearest", func(t *testing.T) {
+		testinggo.AssertProtobufEqual(t, white, colourgo.NearestColour(canvas.Palette, &colourgo.Colour{Red: 200, Green: 200, Blue: 200, Alpha: 255}))
+	})
+}
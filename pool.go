@@ -0,0 +1,402 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/AletheiaWareLLC/bcgo"
+	"math/bits"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	ERROR_POOL_ID_INVALID  = "Pool ID invalid: %s"
+	ERROR_POOL_UNKNOWN     = "Pool not open: %s"
+	ERROR_NOT_CONTRIBUTOR  = "Alias has not contributed to pool: %s"
+	ERROR_OUTSIDE_POOL     = "Location outside pool region: %d,%d,%d,%d"
+	ERROR_CONTRIBUTION_NIL = "Contribution must be greater than zero"
+	ERROR_POOL_ALLOWANCE   = "Pool allowance exceeded: %s can spend %d, needs %d"
+
+	// Prefix of the ledger account holding a pool's funds
+	POOL_ACCOUNT_PREFIX = "Pool:"
+
+	// Fractional bits of the square roots weighting pool shares
+	POOL_SHARE_BITS = 16
+)
+
+// GetPoolID returns the ID of the pool which buys the region of the canvas, inclusive.
+// Contributions are purchases on the pool's purchase channel, and contributors choose colours with votes on the pool's vote channel.
+func GetPoolID(canvasID string, from, to *Location) string {
+	return fmt.Sprintf("%s.%d.%d.%d.%d.%d.%d.%d.%d", canvasID, from.W, from.X, from.Y, from.Z, to.W, to.X, to.Y, to.Z)
+}
+
+// ParsePoolID returns the canvas ID and region of the pool.
+func ParsePoolID(id string) (string, *Location, *Location, error) {
+	parts := strings.Split(id, ".")
+	if len(parts) < 9 {
+		return "", nil, nil, fmt.Errorf(ERROR_POOL_ID_INVALID, id)
+	}
+	n := len(parts) - 8
+	var values [8]uint32
+	for i, p := range parts[n:] {
+		v, err := strconv.ParseUint(p, 10, 32)
+		if err != nil {
+			return "", nil, nil, fmt.Errorf(ERROR_POOL_ID_INVALID, id)
+		}
+		values[i] = uint32(v)
+	}
+	from := &Location{W: values[0], X: values[1], Y: values[2], Z: values[3]}
+	to := &Location{W: values[4], X: values[5], Y: values[6], Z: values[7]}
+	return strings.Join(parts[:n], "."), from, to, nil
+}
+
+func GetPoolAccount(id string) string {
+	return POOL_ACCOUNT_PREFIX + id
+}
+
+func IsPoolAccount(alias string) bool {
+	return strings.HasPrefix(alias, POOL_ACCOUNT_PREFIX)
+}
+
+// InPool returns true if the location is within the pool's region.
+func InPool(id string, l *Location) bool {
+	_, from, to, err := ParsePoolID(id)
+	if err != nil {
+		return false
+	}
+	return InRegion(&Moderation{From: from, To: to}, l)
+}
+
+// GetPoolRegion returns every location in the pool's region.
+func GetPoolRegion(canvas *Canvas, id string) ([]*Location, error) {
+	_, from, to, err := ParsePoolID(id)
+	if err != nil {
+		return nil, err
+	}
+	return GetRegion(canvas, from, to)
+}
+
+func minUint32(a, b uint32) uint32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxUint32(a, b uint32) uint32 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// CreatePoolOpening creates a purchase which opens the pool on the canvas' purchase channel so its contributions are counted.
+func CreatePoolOpening(id string) *Purchase {
+	return &Purchase{
+		Pool: id,
+	}
+}
+
+// CreateContribution creates a purchase which contributes the amount to a pool when written to the pool's purchase channel.
+func CreateContribution(amount uint32) *Purchase {
+	return &Purchase{
+		Price: amount,
+	}
+}
+
+// GetPoolIDs returns the IDs of the pools opened by the entries.
+func GetPoolIDs(entries []*LedgerEntry) []string {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, e := range entries {
+		if p := e.Purchase; p != nil && e.Pool == "" && p.Pool != "" && p.Location == nil && !seen[p.Pool] {
+			seen[p.Pool] = true
+			ids = append(ids, p.Pool)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// ReadContributionEntries returns the contributions in the pool's purchase channel from the given block back.
+func ReadContributionEntries(id string, hash []byte, cache bcgo.Cache, network bcgo.Network, verifier *Verifier) ([]*LedgerEntry, error) {
+	entries, err := ReadPurchaseEntries(GetPurchaseChannelName(id), hash, nil, cache, network, verifier)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		e.Pool = id
+	}
+	return entries, nil
+}
+
+func (l *Ledger) openPool(id string, blockTimestamp uint64) error {
+	_, from, to, err := ParsePoolID(id)
+	if err != nil {
+		return err
+	}
+	canvas := l.getCanvas(blockTimestamp)
+	if err := CheckBounds(canvas, from); err != nil {
+		return err
+	}
+	if err := CheckBounds(canvas, to); err != nil {
+		return err
+	}
+	if _, ok := l.Pools[id]; !ok {
+		l.Pools[id] = make(map[string]uint64)
+		l.Spent[id] = make(map[string]uint64)
+	}
+	return nil
+}
+
+func (l *Ledger) contribute(e *LedgerEntry) error {
+	contributions, ok := l.Pools[e.Pool]
+	if !ok {
+		return fmt.Errorf(ERROR_POOL_UNKNOWN, e.Pool)
+	}
+	creator := e.Entry.Record.Creator
+	if IsBanned(l.Canvas, creator) || l.Moderations.IsBanned(creator, e.BlockTimestamp) {
+		return fmt.Errorf(ERROR_ALIAS_BANNED, creator)
+	}
+	amount := uint64(e.Purchase.Price)
+	if amount == 0 {
+		return fmt.Errorf(ERROR_CONTRIBUTION_NIL)
+	}
+	if b := l.balance(creator); b < amount {
+		return fmt.Errorf(ERROR_INSUFFICIENT_BALANCE, creator, b, amount)
+	}
+	account := GetPoolAccount(e.Pool)
+	l.Balances[creator] = l.balance(creator) - amount
+	l.Balances[account] = l.balance(account) + amount
+	contributions[creator] += amount
+	return nil
+}
+
+// checkPoolPurchase returns an error if the contributor cannot spend the cost from the pool.
+// Each contributor may spend up to their share of everything contributed to the pool, less what they have already spent.
+func (l *Ledger) checkPoolPurchase(creator string, p *Purchase, cost uint64) error {
+	contributions, ok := l.Pools[p.Pool]
+	if !ok {
+		return fmt.Errorf(ERROR_POOL_UNKNOWN, p.Pool)
+	}
+	if contributions[creator] == 0 {
+		return fmt.Errorf(ERROR_NOT_CONTRIBUTOR, creator)
+	}
+	if !InPool(p.Pool, p.Location) {
+		return fmt.Errorf(ERROR_OUTSIDE_POOL, p.Location.W, p.Location.X, p.Location.Y, p.Location.Z)
+	}
+	var total uint64
+	for _, c := range contributions {
+		total += c
+	}
+	allowance := SharePool(contributions, total)[creator]
+	if spent := l.Spent[p.Pool][creator]; spent < allowance {
+		allowance -= spent
+	} else {
+		allowance = 0
+	}
+	if allowance < cost {
+		return fmt.Errorf(ERROR_POOL_ALLOWANCE, creator, allowance, cost)
+	}
+	return nil
+}
+
+// credit pays the owner of a location, the proceeds of a location owned by a pool are shared between its contributors as they can't be spent from the pool account.
+func (l *Ledger) credit(o *Ownership, amount uint64) {
+	if o.Pool != "" {
+		if shares := SharePool(l.Pools[o.Pool], amount); len(shares) > 0 {
+			for a, s := range shares {
+				l.Balances[a] = l.balance(a) + s
+			}
+			return
+		}
+	}
+	l.Balances[o.Alias] = l.balance(o.Alias) + amount
+}
+
+// GetContributions returns the amount contributed to the pool by each alias.
+func (l *Ledger) GetContributions(id string) map[string]uint64 {
+	l.RLock()
+	defer l.RUnlock()
+	contributions := make(map[string]uint64)
+	for a, c := range l.Pools[id] {
+		contributions[a] = c
+	}
+	return contributions
+}
+
+// GetPoolShares returns each contributor's share of a pool, proportional to the square root of their contribution so influence grows quadratically slower than spend.
+// Shares are integers, the square root of the contribution with POOL_SHARE_BITS fractional bits, rounded down.
+func GetPoolShares(contributions map[string]uint64) map[string]uint64 {
+	shares := make(map[string]uint64)
+	for a, c := range contributions {
+		if c < 1<<(64-2*POOL_SHARE_BITS) {
+			shares[a] = isqrt(c << (2 * POOL_SHARE_BITS))
+		} else {
+			shares[a] = isqrt(c) << POOL_SHARE_BITS
+		}
+	}
+	return shares
+}
+
+// SharePool divides the amount between the contributors of a pool in proportion to their shares.
+// Each receives their proportion rounded down, then the remainder is given out one at a time to the largest fractions left over, ties going to the alias which sorts first.
+func SharePool(contributions map[string]uint64, amount uint64) map[string]uint64 {
+	shares := GetPoolShares(contributions)
+	var total uint64
+	var aliases []string
+	for a, s := range shares {
+		total += s
+		aliases = append(aliases, a)
+	}
+	result := make(map[string]uint64)
+	if total == 0 {
+		return result
+	}
+	remainders := make(map[string]uint64)
+	remaining := amount
+	for _, a := range aliases {
+		// amount * share < total * 2^64, so the quotient fits
+		hi, lo := bits.Mul64(amount, shares[a])
+		result[a], remainders[a] = bits.Div64(hi, lo, total)
+		remaining -= result[a]
+	}
+	sort.Slice(aliases, func(i, j int) bool {
+		a, b := aliases[i], aliases[j]
+		if remainders[a] != remainders[b] {
+			return remainders[a] > remainders[b]
+		}
+		return a < b
+	})
+	for i := uint64(0); i < remaining; i++ {
+		result[aliases[i]]++
+	}
+	return result
+}
+
+// isqrt returns the greatest integer whose square is no greater than n.
+func isqrt(n uint64) uint64 {
+	var root uint64
+	bit := uint64(1) << 62
+	for bit > n {
+		bit >>= 2
+	}
+	for bit != 0 {
+		if n >= root+bit {
+			n -= root + bit
+			root = root>>1 + bit
+		} else {
+			root >>= 1
+		}
+		bit >>= 2
+	}
+	return root
+}
+
+// PoolVote is a vote by a contributor on the colour of a location owned by the pool.
+type PoolVote struct {
+	Entry *bcgo.BlockEntry
+	Vote  *Vote
+}
+
+// ReadPoolVotes returns the votes in the pool's vote channel from the given block back.
+func ReadPoolVotes(id string, hash []byte, cache bcgo.Cache, network bcgo.Network, verifier *Verifier) ([]*PoolVote, error) {
+	var votes []*PoolVote
+	if err := GetVotes(&bcgo.Channel{Name: GetVoteChannelName(id), Head: hash}, cache, network, verifier, func(entry *bcgo.BlockEntry, vote *Vote) error {
+		for _, v := range ExpandVote(vote) {
+			votes = append(votes, &PoolVote{
+				Entry: entry,
+				Vote:  v,
+			})
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return votes, nil
+}
+
+// TallyPool returns the colour chosen by the contributors for each location in the pool's region.
+// Each contributor's latest vote for a location counts with the weight of their share, the colour with the greatest weight wins and ties go to the earliest vote.
+func TallyPool(id string, contributions map[string]uint64, votes []*PoolVote) map[Point]*Colour {
+	shares := GetPoolShares(contributions)
+	sort.Slice(votes, func(i, j int) bool {
+		a, b := votes[i].Entry, votes[j].Entry
+		if a.Record.Timestamp != b.Record.Timestamp {
+			return a.Record.Timestamp < b.Record.Timestamp
+		}
+		return bytes.Compare(a.RecordHash, b.RecordHash) < 0
+	})
+	type key struct {
+		point Point
+		alias string
+	}
+	latest := make(map[key]int)
+	for i, v := range votes {
+		if v.Vote.Location == nil || !InPool(id, v.Vote.Location) || shares[v.Entry.Record.Creator] == 0 {
+			continue
+		}
+		latest[key{NewPoint(v.Vote.Location), v.Entry.Record.Creator}] = i
+	}
+	type tally struct {
+		colour *Colour
+		weight uint64
+		first  int
+	}
+	// Count in vote order so weights are summed identically on every node
+	counted := make(map[int]key, len(latest))
+	var indices []int
+	for k, i := range latest {
+		counted[i] = k
+		indices = append(indices, i)
+	}
+	sort.Ints(indices)
+	tallies := make(map[Point]map[string]*tally)
+	for _, i := range indices {
+		k := counted[i]
+		c := votes[i].Vote.Colour
+		hex := FormatHexColour(c)
+		ts, ok := tallies[k.point]
+		if !ok {
+			ts = make(map[string]*tally)
+			tallies[k.point] = ts
+		}
+		t, ok := ts[hex]
+		if !ok {
+			t = &tally{
+				colour: c,
+				first:  i,
+			}
+			ts[hex] = t
+		}
+		t.weight += shares[k.alias]
+	}
+	result := make(map[Point]*Colour)
+	for p, ts := range tallies {
+		var best *tally
+		for _, t := range ts {
+			if best == nil || t.weight > best.weight || (t.weight == best.weight && t.first < best.first) {
+				best = t
+			}
+		}
+		result[p] = best.colour
+	}
+	return result
+}
@@ -0,0 +1,167 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo_test
+
+import (
+	"github.com/AletheiaWareLLC/bcgo"
+	"github.com/AletheiaWareLLC/colourgo"
+	"github.com/AletheiaWareLLC/testinggo"
+	"testing"
+)
+
+func TestParsePoolID(t *testing.T) {
+	from := &colourgo.Location{X: 1, Y: 2}
+	to := &colourgo.Location{X: 3, Y: 4}
+	id := colourgo.GetPoolID("Canvas.ID", from, to)
+	canvasID, f, g, err := colourgo.ParsePoolID(id)
+	testinggo.AssertNoError(t, err)
+	if canvasID != "Canvas.ID" {
+		t.Errorf("Incorrect canvas ID; expected Canvas.ID, got %s", canvasID)
+	}
+	testinggo.AssertProtobufEqual(t, from, f)
+	testinggo.AssertProtobufEqual(t, to, g)
+	_, _, _, err = colourgo.ParsePoolID("Canvas.1.2")
+	testinggo.AssertError(t, "Pool ID invalid: Canvas.1.2", err)
+}
+
+func TestLedger_Pool(t *testing.T) {
+	canvas := &colourgo.Canvas{
+		Width:           2,
+		Height:          2,
+		Faucet:          10,
+		FaucetRecipient: []string{"Alice", "Bob", "Carol"},
+	}
+	id := colourgo.GetPoolID("TEST", &colourgo.Location{}, &colourgo.Location{X: 1})
+	contribution := func(creator string, timestamp uint64, amount uint32) *colourgo.LedgerEntry {
+		e := makeLedgerEntry(creator, timestamp, nil, colourgo.CreateContribution(amount))
+		e.Pool = id
+		return e
+	}
+	pooled := func(creator string, timestamp uint64, x, price uint32) *colourgo.LedgerEntry {
+		p := colourgo.CreatePurchase(0, x, 0, 0, 255, 0, 0, 255, price, 0)
+		p.Pool = id
+		return makeLedgerEntry(creator, timestamp, nil, p)
+	}
+	entries := []*colourgo.LedgerEntry{
+		makeLedgerEntry("Alice", 1, nil, colourgo.CreatePoolOpening(id)),
+		contribution("Alice", 2, 6),
+		contribution("Bob", 3, 4),
+		// Alice's share lets her spend 6 of the 10 contributed, 5.5 rounded down plus the larger remainder
+		pooled("Alice", 4, 0, 5),
+		pooled("Alice", 5, 1, 5),
+		// Bob's share lets him spend 4
+		pooled("Bob", 6, 1, 4),
+		// Not a contributor
+		pooled("Carol", 7, 0, 6),
+	}
+	var rejected []uint64
+	ledger := colourgo.NewLedger(canvas)
+	testinggo.AssertNoError(t, ledger.Replay(entries, func(e *colourgo.LedgerEntry, err error) error {
+		rejected = append(rejected, e.Entry.Record.Timestamp)
+		return nil
+	}))
+	if len(rejected) != 2 || rejected[0] != 5 || rejected[1] != 7 {
+		t.Errorf("Incorrect rejections; expected [5 7], got %v", rejected)
+	}
+	account := colourgo.GetPoolAccount(id)
+	if b := ledger.GetBalance(account); b != 1 {
+		t.Errorf("Incorrect pool balance; expected 1, got %d", b)
+	}
+	if b := ledger.GetBalance("Alice"); b != 4 {
+		t.Errorf("Incorrect balance; expected 4, got %d", b)
+	}
+	if o := ledger.GetOwnership(&colourgo.Location{X: 1}); o == nil || o.Alias != account || o.Pool != id {
+		t.Errorf("Expected pool to own location, got %v", o)
+	}
+
+	// Proceeds of resale are shared between the contributors, 5 split 2.75 and 2.25
+	testinggo.AssertNoError(t, ledger.Apply(makeLedgerEntry("Carol", 8, nil, colourgo.CreatePurchase(0, 1, 0, 0, 0, 0, 255, 255, 5, 0))))
+	for alias, balance := range map[string]uint64{
+		account: 1,
+		"Alice": 7,
+		"Bob":   8,
+		"Carol": 5,
+	} {
+		if b := ledger.GetBalance(alias); b != balance {
+			t.Errorf("Incorrect balance of %s; expected %d, got %d", alias, balance, b)
+		}
+	}
+
+	// Contributions from banned aliases are rejected
+	ledger.Moderations = colourgo.NewModerations(canvas)
+	ledger.Moderations.Actions = []*colourgo.ModerationAction{
+		makeModerationAction("Owner", 9, colourgo.CreateBan("Bob", "Vandalism")),
+	}
+	testinggo.AssertError(t, "Alias banned from canvas: Bob", ledger.Apply(contribution("Bob", 10, 1)))
+}
+
+func TestSharePool(t *testing.T) {
+	for name, test := range map[string]struct {
+		contributions map[string]uint64
+		amount        uint64
+		expected      map[string]uint64
+	}{
+		"Empty":  {map[string]uint64{}, 10, map[string]uint64{}},
+		"Square": {map[string]uint64{"Alice": 9, "Bob": 1}, 8, map[string]uint64{"Alice": 6, "Bob": 2}},
+		// Equal remainders go to the alias which sorts first
+		"Tie":   {map[string]uint64{"Bob": 4, "Alice": 4, "Carol": 4}, 10, map[string]uint64{"Alice": 4, "Bob": 3, "Carol": 3}},
+		"Large": {map[string]uint64{"Alice": 1 << 40, "Bob": 1 << 40}, 1<<64 - 1, map[string]uint64{"Alice": 1 << 63, "Bob": 1<<63 - 1}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			shares := colourgo.SharePool(test.contributions, test.amount)
+			if len(shares) != len(test.expected) {
+				t.Fatalf("Incorrect shares; expected %v, got %v", test.expected, shares)
+			}
+			for a, e := range test.expected {
+				if shares[a] != e {
+					t.Errorf("Incorrect share of %s; expected %d, got %d", a, e, shares[a])
+				}
+			}
+		})
+	}
+}
+
+func TestTallyPool(t *testing.T) {
+	id := colourgo.GetPoolID("TEST", &colourgo.Location{}, &colourgo.Location{})
+	contributions := map[string]uint64{
+		"Alice": 9,
+		"Bob":   4,
+		"Carol": 4,
+	}
+	vote := func(creator string, timestamp uint64, vote *colourgo.Vote) *colourgo.PoolVote {
+		return &colourgo.PoolVote{
+			Entry: &bcgo.BlockEntry{
+				RecordHash: []byte(creator),
+				Record: &bcgo.Record{
+					Creator:   creator,
+					Timestamp: timestamp,
+				},
+			},
+			Vote: vote,
+		}
+	}
+	votes := []*colourgo.PoolVote{
+		vote("Alice", 1, colourgo.CreateVote(0, 0, 0, 0, 255, 0, 0, 255)),
+		vote("Bob", 2, colourgo.CreateVote(0, 0, 0, 0, 0, 0, 255, 255)),
+		vote("Carol", 3, colourgo.CreateVote(0, 0, 0, 0, 0, 0, 255, 255)),
+		// Non-contributor
+		vote("Mallory", 4, colourgo.CreateVote(0, 0, 0, 0, 0, 255, 0, 255)),
+	}
+	colours := colourgo.TallyPool(id, contributions, votes)
+	// Bob and Carol contributed less in total, but outweigh Alice quadratically
+	testinggo.AssertProtobufEqual(t, &colourgo.Colour{Blue: 255, Alpha: 255}, colours[colourgo.Point{}])
+}
@@ -0,0 +1,520 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/AletheiaWareLLC/bcgo"
+	"github.com/golang/protobuf/proto"
+	"sort"
+)
+
+const (
+	ERROR_PROPOSAL_INVALID = "Proposal invalid: %s"
+	ERROR_PROPOSAL_UNKNOWN = "Unknown proposal: %s"
+	ERROR_PROPOSAL_WRITE   = "Proposal canvases are painted by voting on proposals"
+)
+
+func UnmarshalProposal(data []byte) (*Proposal, error) {
+	proposal := &Proposal{}
+	if err := proto.Unmarshal(data, proposal); err != nil {
+		return nil, err
+	}
+	return proposal, nil
+}
+
+// CreateProposal creates a proposal to paint the region between the two corners with the pixels, ordered as by GetRegion.
+func CreateProposal(from, to *Location, pixels []*Colour, description string) *Proposal {
+	return &Proposal{
+		Location:    from,
+		To:          to,
+		Pixel:       pixels,
+		Description: description,
+	}
+}
+
+func CreateProposalRecord(alias string, key *rsa.PrivateKey, proposal *Proposal) (*bcgo.Record, error) {
+	data, err := proto.Marshal(proposal)
+	if err != nil {
+		return nil, err
+	}
+	return CreateRecord(alias, key, data)
+}
+
+// CreateProposalVote creates a vote for the proposal with the given record hash.
+func CreateProposalVote(hash []byte) *Vote {
+	return &Vote{
+		Proposal: hash,
+	}
+}
+
+// IsProposalVote returns true if the vote is for a proposal rather than a location.
+func IsProposalVote(vote *Vote) bool {
+	return len(vote.Proposal) > 0
+}
+
+func getProposalTo(proposal *Proposal) *Location {
+	if proposal.To == nil {
+		return proposal.Location
+	}
+	return proposal.To
+}
+
+// ValidateProposal returns an error if the proposal's region is outside the canvas or its pixels don't fill the region.
+func ValidateProposal(canvas *Canvas, proposal *Proposal) error {
+	if proposal.Location == nil {
+		return fmt.Errorf(ERROR_PROPOSAL_INVALID, "missing location")
+	}
+	region, err := GetRegion(canvas, proposal.Location, getProposalTo(proposal))
+	if err != nil {
+		return err
+	}
+	if len(proposal.Pixel) != len(region) {
+		return fmt.Errorf(ERROR_PROPOSAL_INVALID, fmt.Sprintf("expected %d pixels, got %d", len(region), len(proposal.Pixel)))
+	}
+	for _, c := range proposal.Pixel {
+		if c == nil {
+			return fmt.Errorf(ERROR_PROPOSAL_INVALID, "missing pixel")
+		}
+		if err := ValidateColour(canvas, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetProposalPixels returns the colour the proposal paints at each location in its region.
+func GetProposalPixels(canvas *Canvas, proposal *Proposal) (map[Point]*Colour, error) {
+	if err := ValidateProposal(canvas, proposal); err != nil {
+		return nil, err
+	}
+	region, err := GetRegion(canvas, proposal.Location, getProposalTo(proposal))
+	if err != nil {
+		return nil, err
+	}
+	pixels := make(map[Point]*Colour)
+	for i, l := range region {
+		pixels[NewPoint(l)] = proposal.Pixel[i]
+	}
+	return pixels, nil
+}
+
+// Overlaps returns true if the regions of the two proposals intersect.
+func Overlaps(a, b *Proposal) bool {
+	overlap := func(a1, a2, b1, b2 uint32) bool {
+		return minUint32(a1, a2) <= maxUint32(b1, b2) && minUint32(b1, b2) <= maxUint32(a1, a2)
+	}
+	af, at := a.Location, getProposalTo(a)
+	bf, bt := b.Location, getProposalTo(b)
+	return overlap(af.W, at.W, bf.W, bt.W) && overlap(af.X, at.X, bf.X, bt.X) && overlap(af.Y, at.Y, bf.Y, bt.Y) && overlap(af.Z, at.Z, bf.Z, bt.Z)
+}
+
+// ProposalEntry is a proposal along with the record which made it.
+type ProposalEntry struct {
+	Entry *bcgo.BlockEntry
+	// Height of the block containing the proposal
+	Height uint64
+	// Timestamp of the block containing the proposal
+	BlockTimestamp uint64
+	Proposal       *Proposal
+}
+
+func (p *ProposalEntry) ID() string {
+	return base64.RawURLEncoding.EncodeToString(p.Entry.RecordHash)
+}
+
+// Before returns true if the proposal was made before the other, ties are broken by record hash.
+func (p *ProposalEntry) Before(o *ProposalEntry) bool {
+	if p.Entry.Record.Timestamp != o.Entry.Record.Timestamp {
+		return p.Entry.Record.Timestamp < o.Entry.Record.Timestamp
+	}
+	return bytes.Compare(p.Entry.RecordHash, o.Entry.RecordHash) < 0
+}
+
+// TallyProposals returns the proposals which win the votes mined from and until the block timestamps.
+// Each alias's latest vote which hasn't been retracted counts, proposals are ranked by weight with ties going to the earliest proposal, and a proposal overlapping a higher ranked one is rejected.
+func TallyProposals(proposals map[string]*ProposalEntry, ballots []*Ballot, from, until uint64, weighter VoteWeighter) []*ProposalEntry {
+	latest := make(map[string]*Ballot)
+	for _, b := range RemoveRetracted(ballots, until) {
+		if !IsProposalVote(b.Vote) {
+			continue
+		}
+		if t := b.BlockTimestamp; t < from || t >= until {
+			continue
+		}
+		p, ok := proposals[base64.RawURLEncoding.EncodeToString(b.Vote.Proposal)]
+		if !ok || p.BlockTimestamp >= until {
+			continue
+		}
+		if existing, ok := latest[b.Alias()]; !ok || existing.Before(b) {
+			latest[b.Alias()] = b
+		}
+	}
+	var aliases []string
+	for a := range latest {
+		aliases = append(aliases, a)
+	}
+	// Sum weights in alias order so results are identical on every node
+	sort.Strings(aliases)
+	weights := make(map[string]float64)
+	for _, a := range aliases {
+		weights[base64.RawURLEncoding.EncodeToString(latest[a].Vote.Proposal)] += weighter.Weight(a, latest[a])
+	}
+	var ranked []*ProposalEntry
+	for id, w := range weights {
+		if w > 0 {
+			ranked = append(ranked, proposals[id])
+		}
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		wi, wj := weights[ranked[i].ID()], weights[ranked[j].ID()]
+		if wi != wj {
+			return wi > wj
+		}
+		return ranked[i].Before(ranked[j])
+	})
+	var winners []*ProposalEntry
+	for _, p := range ranked {
+		conflict := false
+		for _, w := range winners {
+			if Overlaps(p.Proposal, w.Proposal) {
+				conflict = true
+				break
+			}
+		}
+		if !conflict {
+			winners = append(winners, p)
+		}
+	}
+	return winners
+}
+
+// ProposalValidator ensures every proposal in a channel fits the canvas.
+// Proposals which cannot be parsed are skipped, as they are by the model.
+type ProposalValidator struct {
+	Canvas     *Canvas
+	Amendments *Amendments
+}
+
+func (v *ProposalValidator) Validate(channel *bcgo.Channel, cache bcgo.Cache, network bcgo.Network, hash []byte, block *bcgo.Block) error {
+	if v.Amendments != nil {
+		if err := v.Amendments.Update(v.Amendments.Channel.Head, cache, network, nil); err != nil {
+			return err
+		}
+	}
+	return bcgo.Iterate(channel.Name, hash, block, cache, network, func(h []byte, b *bcgo.Block) error {
+		for _, entry := range b.Entry {
+			proposal, err := UnmarshalProposal(entry.Record.Payload)
+			if err != nil {
+				continue
+			}
+			if err := ValidateProposal(GetAmendedCanvas(v.Amendments, v.Canvas, b.Timestamp), proposal); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ProposalModel draws the proposals which won each closed voting round, aliases vote on whole proposals rather than individual locations.
+type ProposalModel struct {
+	DemocracyModel
+	Proposals       *bcgo.Channel
+	ProposalEntries map[string]*ProposalEntry
+}
+
+func NewProposalModel(node *bcgo.Node, listener bcgo.MiningListener, id string, canvas *Canvas, channel, proposals *bcgo.Channel, callback func()) *ProposalModel {
+	m := &ProposalModel{
+		DemocracyModel: DemocracyModel{
+			VoteModel: VoteModel{
+				Votes: make(map[string]*Vote),
+			},
+		},
+		Proposals:       proposals,
+		ProposalEntries: make(map[string]*ProposalEntry),
+	}
+	m.initialize(node, listener, id, canvas, channel, callback)
+	m.AddCompanion(proposals)
+	return m
+}
+
+// Bind reads the channels, and reads again as each voting round closes so the winning proposals are drawn.
+func (m *ProposalModel) Bind(ctx context.Context) {
+	m.readEachRound(m.bind(ctx, m.Read), m.Read)
+}
+
+func (m *ProposalModel) Read(ctx context.Context) {
+	m.readProposals(ctx)
+	m.DemocracyModel.Read(ctx)
+}
+
+func (m *ProposalModel) readProposals(ctx context.Context) {
+	m.Lock()
+	defer m.Unlock()
+	if err := bcgo.Iterate(m.Proposals.Name, m.GetHeadOf(m.Proposals), nil, m.Node.Cache, m.Node.Network, func(hash []byte, block *bcgo.Block) error {
+		for _, entry := range block.Entry {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			id := base64.RawURLEncoding.EncodeToString(entry.RecordHash)
+			if _, ok := m.ProposalEntries[id]; ok {
+				return bcgo.StopIterationError{}
+			}
+			if ok, err := m.Verifier.Accept(entry); err != nil {
+				return err
+			} else if !ok {
+				m.Emit(&Event{
+					Type:    EVENT_UNVERIFIED_RECORD,
+					Channel: m.Proposals.Name,
+					Hash:    id,
+				})
+				continue
+			}
+			if IsBanned(m.Canvas, entry.Record.Creator) {
+				m.Emit(&Event{
+					Type:    EVENT_BANNED_RECORD,
+					Channel: m.Proposals.Name,
+					Hash:    id,
+				})
+				continue
+			}
+			proposal, err := UnmarshalProposal(entry.Record.Payload)
+			if err != nil {
+				m.Emit(&Event{
+					Type:    EVENT_CORRUPT_RECORD,
+					Channel: m.Proposals.Name,
+					Hash:    id,
+					Error:   err,
+				})
+				continue
+			}
+			if err := ValidateProposal(GetAmendedCanvas(m.Amendments, m.Canvas, block.Timestamp), proposal); err != nil {
+				m.Emit(&Event{
+					Type:    EVENT_REJECTED_RECORD,
+					Channel: m.Proposals.Name,
+					Hash:    id,
+					Error:   err,
+				})
+				continue
+			}
+			m.ProposalEntries[id] = &ProposalEntry{
+				Entry:          entry,
+				Height:         block.Length,
+				BlockTimestamp: block.Timestamp,
+				Proposal:       proposal,
+			}
+		}
+		return nil
+	}); err != nil {
+		switch err.(type) {
+		case bcgo.StopIterationError:
+			// Do nothing
+		default:
+			m.Emit(&Event{
+				Type:    EVENT_READ_FAILED,
+				Channel: m.Proposals.Name,
+				Error:   err,
+			})
+		}
+	}
+}
+
+// Propose writes a proposal to paint the region between the two corners with the pixels.
+func (m *ProposalModel) Propose(from, to *Location, pixels []*Colour, description string) error {
+	if err := m.CheckContributor(m.Node.Alias); err != nil {
+		return err
+	}
+	proposal := CreateProposal(from, to, pixels, description)
+	if err := ValidateProposal(m.Canvas, proposal); err != nil {
+		return err
+	}
+	record, err := CreateProposalRecord(m.Node.Alias, m.Node.Key, proposal)
+	if err != nil {
+		return err
+	}
+	return m.WriteRecord(m.Proposals, record)
+}
+
+// VoteProposal writes a vote for the proposal with the given ID in the current round, replacing any earlier vote in the round.
+func (m *ProposalModel) VoteProposal(id string) error {
+	if err := m.CheckContributor(m.Node.Alias); err != nil {
+		return err
+	}
+	hash, err := base64.RawURLEncoding.DecodeString(id)
+	if err != nil {
+		return err
+	}
+	m.Lock()
+	_, ok := m.ProposalEntries[id]
+	m.Unlock()
+	if !ok {
+		return fmt.Errorf(ERROR_PROPOSAL_UNKNOWN, id)
+	}
+	record, err := m.createVoteRecord(m.Context(), CreateProposalVote(hash))
+	if err != nil {
+		return err
+	}
+	return m.WriteRecord(m.Channel, record)
+}
+
+// Write is not supported as aliases vote on proposals rather than locations.
+func (m *ProposalModel) Write(l *Location, c *Colour) error {
+	return errors.New(ERROR_PROPOSAL_WRITE)
+}
+
+// WriteBatch is not supported as aliases vote on proposals rather than locations.
+func (m *ProposalModel) WriteBatch(ls []*Location, c *Colour) error {
+	return m.Write(nil, c)
+}
+
+// GetProposals returns the proposals read in the order they were made.
+func (m *ProposalModel) GetProposals() []*ProposalEntry {
+	m.Lock()
+	defer m.Unlock()
+	var proposals []*ProposalEntry
+	for _, p := range m.ProposalEntries {
+		proposals = append(proposals, p)
+	}
+	sort.Slice(proposals, func(i, j int) bool {
+		return proposals[i].Before(proposals[j])
+	})
+	return proposals
+}
+
+// getProposalBallots returns the votes for proposals and retractions, omitting those cast while the creator was banned.
+func (m *ProposalModel) getProposalBallots() ([]*Ballot, map[string]*ProposalEntry) {
+	m.Lock()
+	defer m.Unlock()
+	var ballots []*Ballot
+	for _, id := range m.Order {
+		vote, ok := m.Votes[id]
+		if !ok || !(IsProposalVote(vote) || IsRetraction(vote)) {
+			continue
+		}
+		entry := m.Entries[id]
+		if m.IsBanned(id) {
+			continue
+		}
+		ballots = append(ballots, &Ballot{
+			Entry:          entry,
+			BlockTimestamp: m.BlockTimestamps[id],
+			Vote:           vote,
+		})
+	}
+	proposals := make(map[string]*ProposalEntry)
+	for id, p := range m.ProposalEntries {
+		if !m.Moderations.IsBanned(p.Entry.Record.Creator, p.BlockTimestamp) {
+			proposals[id] = p
+		}
+	}
+	return ballots, proposals
+}
+
+// GetRoundWinners returns the proposals which won the round.
+func (m *ProposalModel) GetRoundWinners(round uint64) ([]*ProposalEntry, error) {
+	start, end, err := GetRoundWindow(m.Canvas, round)
+	if err != nil {
+		return nil, err
+	}
+	if round == 0 {
+		// Votes cast before the first round count towards it
+		start = 0
+	}
+	ballots, proposals := m.getProposalBallots()
+	return TallyProposals(proposals, ballots, start, end, m.GetWeighter()), nil
+}
+
+// GetProvisionalWinners returns the proposals winning the current round so far.
+func (m *ProposalModel) GetProvisionalWinners() ([]*ProposalEntry, error) {
+	round, err := m.GetCurrentRound()
+	if err != nil {
+		return nil, err
+	}
+	return m.GetRoundWinners(round)
+}
+
+// GetLockedResults returns the colour of each location during the round, painted by the winning proposals of earlier rounds in order.
+func (m *ProposalModel) GetLockedResults(round uint64) map[Point]*Colour {
+	ballots, _ := m.getProposalBallots()
+	// Only rounds with votes can have winners
+	rounds := make(map[uint64]bool)
+	for _, b := range ballots {
+		if r, err := GetRound(m.Canvas, b.BlockTimestamp); err == nil && r < round {
+			rounds[r] = true
+		}
+	}
+	var order []uint64
+	for r := range rounds {
+		order = append(order, r)
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return order[i] < order[j]
+	})
+	locked := make(map[Point]*Colour)
+	for _, r := range order {
+		winners, err := m.GetRoundWinners(r)
+		if err != nil {
+			continue
+		}
+		for _, w := range winners {
+			// Pixels outside the current bounds are kept but not drawn
+			pixels, err := GetProposalPixels(GetAmendedCanvas(m.Amendments, m.Canvas, w.BlockTimestamp), w.Proposal)
+			if err != nil {
+				continue
+			}
+			for p, c := range pixels {
+				if m.Moderations.IsHidden(w.Entry.Record, w.Height, w.BlockTimestamp, p.Location()) {
+					// Masked or reverted by a moderator
+					continue
+				}
+				locked[p] = c
+			}
+		}
+	}
+	return locked
+}
+
+// GetResults returns the colour of each location painted by the proposals which won the closed rounds.
+func (m *ProposalModel) GetResults() map[Point]*Colour {
+	round, err := m.GetCurrentRound()
+	if err != nil {
+		return nil
+	}
+	return m.GetLockedResults(round)
+}
+
+// Draw calls the callback with the final opaque colour of each pixel, layers are flattened and frames are iterated in order.
+func (m *ProposalModel) Draw(callback func(*Location, *Colour)) {
+	pixels := Composite(m.Canvas, GetState(m.Canvas, m))
+	for _, p := range SortPoints(pixels) {
+		callback(p.Location(), pixels[p])
+	}
+}
+
+// DrawLayers calls the callback with the colour of each location painted by a winning proposal.
+func (m *ProposalModel) DrawLayers(callback func(*Location, *Colour)) {
+	m.DrawBase(callback)
+	results := m.GetResults()
+	for _, p := range SortPoints(results) {
+		callback(p.Location(), results[p])
+	}
+}
@@ -0,0 +1,153 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo_test
+
+import (
+	"encoding/base64"
+	"github.com/AletheiaWareLLC/bcgo"
+	"github.com/AletheiaWareLLC/colourgo"
+	"github.com/AletheiaWareLLC/testinggo"
+	"testing"
+)
+
+func makeProposal(timestamp uint64, from, to *colourgo.Location, colour *colourgo.Colour) *colourgo.ProposalEntry {
+	canvas := &colourgo.Canvas{Width: 4, Height: 4}
+	region, _ := colourgo.GetRegion(canvas, from, to)
+	var pixels []*colourgo.Colour
+	for range region {
+		pixels = append(pixels, colour)
+	}
+	return &colourgo.ProposalEntry{
+		Entry: &bcgo.BlockEntry{
+			RecordHash: []byte{'P', byte(timestamp)},
+			Record: &bcgo.Record{
+				Creator:   "Owner",
+				Timestamp: timestamp,
+			},
+		},
+		BlockTimestamp: timestamp,
+		Proposal:       colourgo.CreateProposal(from, to, pixels, ""),
+	}
+}
+
+func TestValidateProposal(t *testing.T) {
+	canvas, err := colourgo.CreateCanvas("Test", 4, 4, 1, colourgo.Mode_PROPOSAL)
+	testinggo.AssertNoError(t, err)
+	red := &colourgo.Colour{Red: 255, Alpha: 255}
+	from := &colourgo.Location{X: 1, Y: 1}
+	to := &colourgo.Location{X: 2, Y: 2}
+	testinggo.AssertNoError(t, colourgo.ValidateProposal(canvas, makeProposal(1, from, to, red).Proposal))
+	short := colourgo.CreateProposal(from, to, []*colourgo.Colour{red}, "")
+	testinggo.AssertError(t, "Proposal invalid: expected 4 pixels, got 1", colourgo.ValidateProposal(canvas, short))
+	outside := colourgo.CreateProposal(from, &colourgo.Location{X: 4, Y: 1}, []*colourgo.Colour{red, red, red, red}, "")
+	if err := colourgo.ValidateProposal(canvas, outside); err == nil {
+		t.Error("Expected error for proposal outside canvas")
+	}
+}
+
+func TestTallyProposals(t *testing.T) {
+	red := &colourgo.Colour{Red: 255, Alpha: 255}
+	blue := &colourgo.Colour{Blue: 255, Alpha: 255}
+	green := &colourgo.Colour{Green: 255, Alpha: 255}
+	left := makeProposal(1, &colourgo.Location{}, &colourgo.Location{X: 1, Y: 1}, red)
+	overlap := makeProposal(2, &colourgo.Location{X: 1, Y: 1}, &colourgo.Location{X: 2, Y: 2}, blue)
+	corner := makeProposal(3, &colourgo.Location{X: 3, Y: 3}, &colourgo.Location{X: 3, Y: 3}, green)
+	proposals := make(map[string]*colourgo.ProposalEntry)
+	for _, p := range []*colourgo.ProposalEntry{left, overlap, corner} {
+		proposals[p.ID()] = p
+	}
+	vote := func(p *colourgo.ProposalEntry) *colourgo.Vote {
+		hash, _ := base64.RawURLEncoding.DecodeString(p.ID())
+		return colourgo.CreateProposalVote(hash)
+	}
+	ballots := []*colourgo.Ballot{
+		makeBallot("Alice", 10, vote(overlap)),
+		// Alice changes her vote
+		makeBallot("Alice", 11, vote(left)),
+		makeBallot("Bob", 12, vote(left)),
+		makeBallot("Carol", 13, vote(overlap)),
+		makeBallot("Dan", 14, vote(corner)),
+		// Outside the round
+		makeBallot("Erin", 30, vote(overlap)),
+		makeBallot("Frank", 31, vote(overlap)),
+	}
+	winners := colourgo.TallyProposals(proposals, ballots, 10, 20, &colourgo.EqualWeighter{})
+	// Overlap loses to left, corner doesn't conflict
+	if len(winners) != 2 || winners[0] != left || winners[1] != corner {
+		t.Errorf("Expected left and corner to win, got %v", winners)
+	}
+}
+
+func TestProposalModel_Moderation(t *testing.T) {
+	node := &bcgo.Node{
+		Alias:    "Alice",
+		Cache:    bcgo.NewMemoryCache(10),
+		Channels: make(map[string]*bcgo.Channel),
+	}
+	canvas, err := colourgo.CreateCanvas("TEST_CANVAS", 4, 4, 1, colourgo.Mode_PROPOSAL)
+	testinggo.AssertNoError(t, err)
+	canvas.Owner = "Owner"
+	canvas.RoundLength = 10
+	model := colourgo.NewProposalModel(node, nil, "TEST_ID", canvas, colourgo.OpenVoteChannel("TEST_ID"), colourgo.OpenProposalChannel("TEST_ID"), nil)
+	defer model.Close()
+	red := &colourgo.Colour{Red: 255, Alpha: 255}
+	proposal := makeProposal(1*second, &colourgo.Location{}, &colourgo.Location{X: 1, Y: 1}, red)
+	proposal.Height = 2
+	model.ProposalEntries[proposal.ID()] = proposal
+	hash, _ := base64.RawURLEncoding.DecodeString(proposal.ID())
+	ballot := makeBallot("Alice", 2*second, colourgo.CreateProposalVote(hash))
+	model.Votes["A"] = ballot.Vote
+	model.Entries["A"] = ballot.Entry
+	model.BlockTimestamps["A"] = ballot.BlockTimestamp
+	model.Order = []string{"A"}
+	model.Moderations.Actions = []*colourgo.ModerationAction{
+		makeModerationAction("Owner", 20*second, colourgo.CreateMask(&colourgo.Location{}, nil, "Offensive")),
+		makeModerationAction("Owner", 20*second, colourgo.CreateRevert(&colourgo.Location{X: 1, Y: 1}, nil, 1, "Vandalism")),
+	}
+	results := model.GetLockedResults(1)
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 locations drawn, got %d", len(results))
+	}
+	for _, p := range []colourgo.Point{{X: 0, Y: 0}, {X: 1, Y: 1}} {
+		if c, ok := results[p]; ok {
+			t.Errorf("Expected %v to be hidden, got %v", p, c)
+		}
+	}
+}
+
+func TestProposalValidator_Corrupt(t *testing.T) {
+	canvas, err := colourgo.CreateCanvas("TEST_CANVAS", 4, 4, 1, colourgo.Mode_PROPOSAL)
+	testinggo.AssertNoError(t, err)
+	block := &bcgo.Block{
+		Timestamp:   1,
+		ChannelName: "TEST_CHANNEL",
+		Length:      1,
+		Entry: []*bcgo.BlockEntry{
+			&bcgo.BlockEntry{
+				RecordHash: []byte{1},
+				Record: &bcgo.Record{
+					Creator: "Alice",
+					Payload: []byte{0xff},
+				},
+			},
+		},
+	}
+	validator := &colourgo.ProposalValidator{
+		Canvas: canvas,
+	}
+	testinggo.AssertNoError(t, validator.Validate(&bcgo.Channel{Name: "TEST_CHANNEL"}, bcgo.NewMemoryCache(1), nil, []byte("hash"), block))
+}
@@ -30,15 +30,23 @@ func UnmarshalPurchase(data []byte) (*Purchase, error) {
 	return purchase, nil
 }
 
-func GetPurchases(purchases *bcgo.Channel, cache bcgo.Cache, network bcgo.Network, callback func(*bcgo.BlockEntry, *Purchase) error) error {
+// GetPurchases calls the callback with each purchase in the channel, records are checked by the verifier if not nil.
+func GetPurchases(purchases *bcgo.Channel, cache bcgo.Cache, network bcgo.Network, verifier *Verifier, callback func(*bcgo.BlockEntry, *Purchase) error) error {
 	return bcgo.Iterate(purchases.Name, purchases.Head, nil, cache, network, func(hash []byte, block *bcgo.Block) error {
 		for _, entry := range block.Entry {
+			if ok, err := verifier.Accept(entry); err != nil {
+				return err
+			} else if !ok {
+				continue
+			}
 			record := entry.Record
 			p, err := UnmarshalPurchase(record.Payload)
 			if err != nil {
 				return err
 			}
-			callback(entry, p)
+			if err := callback(entry, p); err != nil {
+				return err
+			}
 		}
 		return nil
 	})
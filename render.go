@@ -0,0 +1,164 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo
+
+import (
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"io"
+	"sort"
+)
+
+func clampChannel(v uint32) uint8 {
+	if v > 255 {
+		return 255
+	}
+	return uint8(v)
+}
+
+func nrgba(c *Colour) color.NRGBA {
+	return color.NRGBA{
+		R: clampChannel(c.GetRed()),
+		G: clampChannel(c.GetGreen()),
+		B: clampChannel(c.GetBlue()),
+		A: clampChannel(c.GetAlpha()),
+	}
+}
+
+// SortPoints returns the points of the given map ordered by W, Z, Y, then X.
+func SortPoints(pixels map[Point]*Colour) []Point {
+	var points []Point
+	for p := range pixels {
+		points = append(points, p)
+	}
+	sort.Slice(points, func(i, j int) bool {
+		a, b := points[i], points[j]
+		if a.W != b.W {
+			return a.W < b.W
+		}
+		if a.Z != b.Z {
+			return a.Z < b.Z
+		}
+		if a.Y != b.Y {
+			return a.Y < b.Y
+		}
+		return a.X < b.X
+	})
+	return points
+}
+
+// Composite converts the per-layer state into the final opaque pixels of the canvas.
+// Layered canvases are flattened into W=0 by blending each layer over the one below in ascending W order.
+// Framed canvases keep each frame separate.
+// The result is blended over the canvas background.
+// Locations outside the canvas bounds are ignored.
+func Composite(canvas *Canvas, state map[Point]*Colour) map[Point]*Colour {
+	layers := make(map[Point]*Colour)
+	for _, p := range SortPoints(state) {
+		if !IsInBounds(canvas, p.Location()) {
+			continue
+		}
+		switch canvas.Dimension {
+		case Dimension_FRAME:
+			layers[p] = state[p]
+		case Dimension_LAYER:
+			fallthrough
+		default:
+			flat := Point{
+				X: p.X,
+				Y: p.Y,
+				Z: p.Z,
+			}
+			layers[flat] = BlendColour(canvas.Blend, layers[flat], state[p])
+		}
+	}
+	background := GetBackground(canvas)
+	mode := canvas.Blend
+	if mode == Blend_REPLACE {
+		// Replaced pixels may still be transparent so are drawn source-over the background
+		mode = Blend_SOURCE_OVER
+	}
+	pixels := make(map[Point]*Colour)
+	for p, c := range layers {
+		pixels[p] = BlendColour(mode, background, c)
+	}
+	return pixels
+}
+
+// GetPixels returns the composited pixels drawn by the model.
+func GetPixels(model Model) map[Point]*Colour {
+	pixels := make(map[Point]*Colour)
+	model.Draw(func(l *Location, c *Colour) {
+		pixels[NewPoint(l)] = c
+	})
+	return pixels
+}
+
+// RenderImage returns an image of the given frame and Z plane of the pixels.
+func RenderImage(canvas *Canvas, pixels map[Point]*Colour, w, z uint32) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, int(canvas.Width), int(canvas.Height)))
+	draw.Draw(img, img.Bounds(), image.NewUniform(nrgba(GetBackground(canvas))), image.Point{}, draw.Src)
+	for p, c := range pixels {
+		if p.W == w && p.Z == z {
+			img.SetNRGBA(int(p.X), int(p.Y), nrgba(c))
+		}
+	}
+	return img
+}
+
+// EncodePNG writes the given frame and Z plane of the model as a PNG image.
+func EncodePNG(writer io.Writer, canvas *Canvas, model Model, w, z uint32) error {
+	return png.Encode(writer, RenderImage(canvas, GetPixels(model), w, z))
+}
+
+// GetGIFPalette returns the canvas palette and background, or Plan9 if the canvas has no palette or it has more colours than a GIF can hold.
+func GetGIFPalette(canvas *Canvas) color.Palette {
+	if len(canvas.Palette) == 0 {
+		return palette.Plan9
+	}
+	colours := canvas.Palette
+	if background := GetBackground(canvas); !IsInPalette(colours, background) {
+		colours = append([]*Colour{background}, colours...)
+	}
+	if len(colours) > 256 {
+		return palette.Plan9
+	}
+	return ToPalette(colours)
+}
+
+// EncodeGIF writes the given Z plane of the model as a GIF image, animated if the canvas has frames.
+func EncodeGIF(writer io.Writer, canvas *Canvas, model Model, z uint32) error {
+	pixels := GetPixels(model)
+	frames := uint32(1)
+	if canvas.Dimension == Dimension_FRAME {
+		frames = GetCanvasExtent(canvas)
+	}
+	animation := &gif.GIF{}
+	for w := uint32(0); w < frames; w++ {
+		img := RenderImage(canvas, pixels, w, z)
+		paletted := image.NewPaletted(img.Bounds(), GetGIFPalette(canvas))
+		draw.Draw(paletted, paletted.Bounds(), img, image.Point{}, draw.Src)
+		animation.Image = append(animation.Image, paletted)
+		// FrameDuration is measured in milliseconds, GIF delays in hundredths of a second
+		animation.Delay = append(animation.Delay, int(canvas.FrameDuration/10))
+	}
+	return gif.EncodeAll(writer, animation)
+}
@@ -0,0 +1,162 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo_test
+
+import (
+	"bytes"
+	"context"
+	"github.com/AletheiaWareLLC/colourgo"
+	"github.com/AletheiaWareLLC/testinggo"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"testing"
+)
+
+// fakeModel draws the colours written to it in order.
+type fakeModel struct {
+	canvas    *colourgo.Canvas
+	locations []*colourgo.Location
+	colours   []*colourgo.Colour
+}
+
+func (m *fakeModel) Bind(context.Context)           {}
+func (m *fakeModel) Close() error                   { return nil }
+func (m *fakeModel) Refresh(context.Context) error  { return nil }
+func (m *fakeModel) Read(context.Context)           {}
+func (m *fakeModel) Mine(context.Context) error     { return nil }
+func (m *fakeModel) Events() <-chan *colourgo.Event { return nil }
+
+func (m *fakeModel) Draw(callback func(*colourgo.Location, *colourgo.Colour)) {
+	pixels := colourgo.Composite(m.canvas, colourgo.GetState(m.canvas, m))
+	for _, p := range colourgo.SortPoints(pixels) {
+		callback(p.Location(), pixels[p])
+	}
+}
+
+func (m *fakeModel) DrawLayers(callback func(*colourgo.Location, *colourgo.Colour)) {
+	for i, l := range m.locations {
+		callback(l, m.colours[i])
+	}
+}
+
+func (m *fakeModel) Write(l *colourgo.Location, c *colourgo.Colour) error {
+	m.locations = append(m.locations, l)
+	m.colours = append(m.colours, c)
+	return nil
+}
+
+var (
+	black = &colourgo.Colour{Alpha: 255}
+	red   = &colourgo.Colour{Red: 255, Alpha: 255}
+	blue  = &colourgo.Colour{Blue: 255, Alpha: 255}
+)
+
+func TestRenderImage(t *testing.T) {
+	canvas, err := colourgo.CreateCanvas("TEST_CANVAS", 2, 1, 2, colourgo.Mode_FREE_FOR_ALL)
+	testinggo.AssertNoError(t, err)
+	canvas.Fill = black
+	pixels := map[colourgo.Point]*colourgo.Colour{
+		{X: 1}:       red,
+		{X: 0, Z: 1}: blue,
+	}
+	img := colourgo.RenderImage(canvas, pixels, 0, 0)
+	if b := img.Bounds(); b.Dx() != 2 || b.Dy() != 1 {
+		t.Fatalf("Incorrect bounds; expected 2x1, got '%v'", b)
+	}
+	// Unset pixels are the background and other planes are omitted
+	if c := img.NRGBAAt(0, 0); c != (color.NRGBA{A: 255}) {
+		t.Errorf("Incorrect colour; expected black, got '%v'", c)
+	}
+	if c := img.NRGBAAt(1, 0); c != (color.NRGBA{R: 255, A: 255}) {
+		t.Errorf("Incorrect colour; expected red, got '%v'", c)
+	}
+}
+
+func TestEncodePNG(t *testing.T) {
+	canvas, err := colourgo.CreateCanvas("TEST_CANVAS", 2, 2, 1, colourgo.Mode_FREE_FOR_ALL)
+	testinggo.AssertNoError(t, err)
+	canvas.Fill = black
+	model := &fakeModel{canvas: canvas}
+	testinggo.AssertNoError(t, model.Write(&colourgo.Location{X: 1, Y: 1}, red))
+	var buffer bytes.Buffer
+	testinggo.AssertNoError(t, colourgo.EncodePNG(&buffer, canvas, model, 0, 0))
+	img, err := png.Decode(&buffer)
+	testinggo.AssertNoError(t, err)
+	if r, g, b, a := img.At(1, 1).RGBA(); r != 0xffff || g != 0 || b != 0 || a != 0xffff {
+		t.Errorf("Incorrect colour; expected red, got '%v'", img.At(1, 1))
+	}
+	if r, g, b, a := img.At(0, 0).RGBA(); r != 0 || g != 0 || b != 0 || a != 0xffff {
+		t.Errorf("Incorrect colour; expected black, got '%v'", img.At(0, 0))
+	}
+}
+
+func TestEncodeGIF(t *testing.T) {
+	canvas, err := colourgo.CreateCanvas("TEST_CANVAS", 1, 1, 1, colourgo.Mode_FREE_FOR_ALL)
+	testinggo.AssertNoError(t, err)
+	canvas.Fill = black
+	canvas.Dimension = colourgo.Dimension_FRAME
+	canvas.Extent = 2
+	canvas.FrameDuration = 500
+	model := &fakeModel{canvas: canvas}
+	testinggo.AssertNoError(t, model.Write(&colourgo.Location{W: 0}, red))
+	testinggo.AssertNoError(t, model.Write(&colourgo.Location{W: 1}, blue))
+	var buffer bytes.Buffer
+	testinggo.AssertNoError(t, colourgo.EncodeGIF(&buffer, canvas, model, 0))
+	animation, err := gif.DecodeAll(&buffer)
+	testinggo.AssertNoError(t, err)
+	if len(animation.Image) != 2 {
+		t.Fatalf("Incorrect frames; expected 2, got '%d'", len(animation.Image))
+	}
+	for i, expected := range []color.RGBA{{R: 255, A: 255}, {B: 255, A: 255}} {
+		if animation.Delay[i] != 50 {
+			t.Errorf("Incorrect delay; expected 50, got '%d'", animation.Delay[i])
+		}
+		if c := color.RGBAModel.Convert(animation.Image[i].At(0, 0)); c != expected {
+			t.Errorf("Incorrect colour in frame %d; expected '%v', got '%v'", i, expected, c)
+		}
+	}
+}
+
+func TestEncodeGIF_Palette(t *testing.T) {
+	canvas, err := colourgo.CreateCanvas("TEST_CANVAS", 2, 1, 1, colourgo.Mode_FREE_FOR_ALL)
+	testinggo.AssertNoError(t, err)
+	// Not in Plan9
+	orange := &colourgo.Colour{Red: 200, Green: 100, Blue: 50, Alpha: 255}
+	canvas.Palette = []*colourgo.Colour{orange, red}
+	model := &fakeModel{canvas: canvas}
+	testinggo.AssertNoError(t, model.Write(&colourgo.Location{}, orange))
+	var buffer bytes.Buffer
+	testinggo.AssertNoError(t, colourgo.EncodeGIF(&buffer, canvas, model, 0))
+	animation, err := gif.DecodeAll(&buffer)
+	testinggo.AssertNoError(t, err)
+	frame := animation.Image[0]
+	// White background and the canvas palette, padded to a power of two
+	if len(frame.Palette) != 4 {
+		t.Fatalf("Incorrect palette; expected 4 colours, got '%d'", len(frame.Palette))
+	}
+	for i, expected := range []color.RGBA{{R: 255, G: 255, B: 255, A: 255}, {R: 200, G: 100, B: 50, A: 255}, {R: 255, A: 255}} {
+		if c := color.RGBAModel.Convert(frame.Palette[i]); c != expected {
+			t.Errorf("Incorrect palette colour %d; expected '%v', got '%v'", i, expected, c)
+		}
+	}
+	for x, expected := range []color.RGBA{{R: 200, G: 100, B: 50, A: 255}, {R: 255, G: 255, B: 255, A: 255}} {
+		if c := color.RGBAModel.Convert(frame.At(x, 0)); c != expected {
+			t.Errorf("Incorrect colour at %d; expected '%v', got '%v'", x, expected, c)
+		}
+	}
+}
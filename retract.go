@@ -0,0 +1,156 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo
+
+import (
+	"encoding/base64"
+	"fmt"
+	"github.com/AletheiaWareLLC/bcgo"
+	"math"
+)
+
+const (
+	ERROR_RETRACTION_INVALID = "Retraction invalid: %s"
+	ERROR_NOT_VOTE_CREATOR   = "Alias %s did not cast vote %s"
+	ERROR_UNKNOWN_VOTE       = "Unknown vote: %s"
+)
+
+// CreateRetraction creates a vote which retracts the earlier vote with the given record hash.
+func CreateRetraction(hash []byte) *Vote {
+	return &Vote{
+		Retract: hash,
+	}
+}
+
+// IsRetraction returns true if the vote retracts an earlier vote.
+func IsRetraction(vote *Vote) bool {
+	return len(vote.Retract) > 0
+}
+
+// GetRetractions returns the retraction of each ballot retracted in blocks mined until the timestamp, keyed by the retracted ballot's record hash.
+// Retractions by an alias other than the ballot's creator, or made before the ballot was cast, are ignored.
+func GetRetractions(ballots []*Ballot, until uint64) map[string]*Ballot {
+	cast := make(map[string]*Ballot)
+	for _, b := range ballots {
+		cast[base64.RawURLEncoding.EncodeToString(b.Entry.RecordHash)] = b
+	}
+	retractions := make(map[string]*Ballot)
+	for _, b := range ballots {
+		if !IsRetraction(b.Vote) || b.BlockTimestamp >= until {
+			continue
+		}
+		id := base64.RawURLEncoding.EncodeToString(b.Vote.Retract)
+		original, ok := cast[id]
+		if !ok || original.Alias() != b.Alias() || b.Before(original) {
+			continue
+		}
+		if existing, ok := retractions[id]; !ok || b.Before(existing) {
+			retractions[id] = b
+		}
+	}
+	return retractions
+}
+
+// RemoveRetracted returns the ballots which were not retracted until the timestamp, omitting the retractions themselves.
+func RemoveRetracted(ballots []*Ballot, until uint64) []*Ballot {
+	retractions := GetRetractions(ballots, until)
+	var remaining []*Ballot
+	for _, b := range ballots {
+		if IsRetraction(b.Vote) {
+			continue
+		}
+		if _, ok := retractions[base64.RawURLEncoding.EncodeToString(b.Entry.RecordHash)]; ok {
+			continue
+		}
+		remaining = append(remaining, b)
+	}
+	return remaining
+}
+
+// RetractionValidator ensures every retraction in a channel references an earlier vote cast by the same alias.
+type RetractionValidator struct{}
+
+func (v *RetractionValidator) Validate(channel *bcgo.Channel, cache bcgo.Cache, network bcgo.Network, hash []byte, block *bcgo.Block) error {
+	creators := make(map[string]string)
+	var retractions []*bcgo.BlockEntry
+	var targets [][]byte
+	if err := bcgo.Iterate(channel.Name, hash, block, cache, network, func(h []byte, b *bcgo.Block) error {
+		for _, entry := range b.Entry {
+			creators[base64.RawURLEncoding.EncodeToString(entry.RecordHash)] = entry.Record.Creator
+			vote, err := UnmarshalVote(entry.Record.Payload)
+			if err != nil {
+				return err
+			}
+			if IsRetraction(vote) {
+				retractions = append(retractions, entry)
+				targets = append(targets, vote.Retract)
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	for i, entry := range retractions {
+		id := base64.RawURLEncoding.EncodeToString(targets[i])
+		creator, ok := creators[id]
+		if !ok {
+			return fmt.Errorf(ERROR_RETRACTION_INVALID, fmt.Sprintf(ERROR_UNKNOWN_VOTE, id))
+		}
+		if creator != entry.Record.Creator {
+			return fmt.Errorf(ERROR_RETRACTION_INVALID, fmt.Sprintf(ERROR_NOT_VOTE_CREATOR, entry.Record.Creator, id))
+		}
+	}
+	return nil
+}
+
+// Retract writes a retraction of the node's earlier vote with the given ID.
+func (m *VoteModel) Retract(id string) error {
+	m.Lock()
+	entry, ok := m.Entries[id]
+	m.Unlock()
+	if !ok {
+		return fmt.Errorf(ERROR_UNKNOWN_VOTE, id)
+	}
+	if entry.Record.Creator != m.Node.Alias {
+		return fmt.Errorf(ERROR_NOT_VOTE_CREATOR, m.Node.Alias, id)
+	}
+	record, err := m.createVoteRecord(m.Context(), CreateRetraction(entry.RecordHash))
+	if err != nil {
+		return err
+	}
+	return m.WriteRecord(m.Channel, record)
+}
+
+// History calls the callback with each ballot in the order they were cast, along with the retraction which retracted it, if any.
+func (m *VoteModel) History(callback func(ballot, retraction *Ballot)) {
+	m.Lock()
+	var ballots []*Ballot
+	for _, id := range m.Order {
+		if vote, ok := m.Votes[id]; ok {
+			ballots = append(ballots, &Ballot{
+				Entry:          m.Entries[id],
+				BlockTimestamp: m.BlockTimestamps[id],
+				Vote:           vote,
+			})
+		}
+	}
+	m.Unlock()
+	retractions := GetRetractions(ballots, math.MaxUint64)
+	for _, b := range ballots {
+		callback(b, retractions[base64.RawURLEncoding.EncodeToString(b.Entry.RecordHash)])
+	}
+}
@@ -0,0 +1,49 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo_test
+
+import (
+	"github.com/AletheiaWareLLC/colourgo"
+	"github.com/AletheiaWareLLC/testinggo"
+	"testing"
+)
+
+func TestRetraction(t *testing.T) {
+	red := colourgo.CreateVote(0, 0, 0, 0, 255, 0, 0, 255)
+	blue := colourgo.CreateVote(0, 0, 0, 0, 0, 0, 255, 255)
+	origin := colourgo.Point{}
+	alice := makeBallot("Alice", 1, red)
+	bob := makeBallot("Bob", 2, blue)
+	carol := makeBallot("Carol", 3, blue)
+	ballots := []*colourgo.Ballot{
+		alice,
+		bob,
+		carol,
+		// Only Bob can retract his vote
+		makeBallot("Alice", 5, colourgo.CreateRetraction(bob.Entry.RecordHash)),
+		makeBallot("Carol", 6, colourgo.CreateRetraction(carol.Entry.RecordHash)),
+	}
+	equal := &colourgo.EqualWeighter{}
+	// Carol's vote counts until she retracts it
+	testinggo.AssertProtobufEqual(t, blue.Colour, colourgo.TallyBallots(ballots, 0, 6).Results(equal)[origin])
+	testinggo.AssertProtobufEqual(t, red.Colour, colourgo.TallyBallots(ballots, 0, 7).Results(equal)[origin])
+
+	retractions := colourgo.GetRetractions(ballots, 7)
+	if len(retractions) != 1 {
+		t.Errorf("Expected 1 retraction, got %d", len(retractions))
+	}
+}
@@ -0,0 +1,61 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	ERROR_ROUNDS_NOT_CONFIGURED = "Voting rounds not configured"
+)
+
+// HasRounds returns true if votes on the canvas are counted in time-boxed rounds.
+func HasRounds(canvas *Canvas) bool {
+	return canvas.RoundLength > 0
+}
+
+func getRoundLength(canvas *Canvas) (uint64, error) {
+	if !HasRounds(canvas) {
+		return 0, errors.New(ERROR_ROUNDS_NOT_CONFIGURED)
+	}
+	return uint64(canvas.RoundLength) * uint64(time.Second), nil
+}
+
+// GetRound returns the voting round at the timestamp.
+// Rounds last RoundLength seconds from the canvas' RoundStart, timestamps before the start are in the first round.
+func GetRound(canvas *Canvas, timestamp uint64) (uint64, error) {
+	length, err := getRoundLength(canvas)
+	if err != nil {
+		return 0, err
+	}
+	if timestamp < canvas.RoundStart {
+		return 0, nil
+	}
+	return (timestamp - canvas.RoundStart) / length, nil
+}
+
+// GetRoundWindow returns the timestamps at which the round opens and closes.
+func GetRoundWindow(canvas *Canvas, round uint64) (uint64, uint64, error) {
+	length, err := getRoundLength(canvas)
+	if err != nil {
+		return 0, 0, err
+	}
+	start := canvas.RoundStart + round*length
+	return start, start + length, nil
+}
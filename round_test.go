@@ -0,0 +1,76 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo_test
+
+import (
+	"github.com/AletheiaWareLLC/colourgo"
+	"github.com/AletheiaWareLLC/testinggo"
+	"testing"
+)
+
+func TestGetRound(t *testing.T) {
+	canvas := &colourgo.Canvas{
+		RoundStart:  100 * second,
+		RoundLength: 10,
+	}
+	for name, test := range map[string]struct {
+		timestamp uint64
+		round     uint64
+	}{
+		"BeforeStart": {50 * second, 0},
+		"Start":       {100 * second, 0},
+		"Second":      {110 * second, 1},
+		"Third":       {125 * second, 2},
+	} {
+		t.Run(name, func(t *testing.T) {
+			round, err := colourgo.GetRound(canvas, test.timestamp)
+			testinggo.AssertNoError(t, err)
+			if round != test.round {
+				t.Errorf("Expected round %d, got %d", test.round, round)
+			}
+		})
+	}
+	start, end, err := colourgo.GetRoundWindow(canvas, 2)
+	testinggo.AssertNoError(t, err)
+	if start != 120*second || end != 130*second {
+		t.Errorf("Expected window [%d, %d), got [%d, %d)", 120*second, 130*second, start, end)
+	}
+	_, err = colourgo.GetRound(&colourgo.Canvas{}, 0)
+	testinggo.AssertError(t, colourgo.ERROR_ROUNDS_NOT_CONFIGURED, err)
+}
+
+func TestTallyBallots(t *testing.T) {
+	red := colourgo.CreateVote(0, 0, 0, 0, 255, 0, 0, 255)
+	blue := colourgo.CreateVote(0, 0, 0, 0, 0, 0, 255, 255)
+	origin := colourgo.Point{}
+	ballots := []*colourgo.Ballot{
+		makeBallot("Alice", 1, red),
+		makeBallot("Bob", 2, colourgo.CreateDelegation("Alice", nil, nil)),
+		makeBallot("Carol", 11, blue),
+	}
+	weight := &colourgo.EqualWeighter{}
+	// First round: Bob's delegation carries over to Alice's vote
+	results := colourgo.TallyBallots(ballots, 0, 10).Results(weight)
+	if c := results[origin]; c == nil || c.Red != 255 {
+		t.Errorf("Expected red in first round, got %v", c)
+	}
+	// Second round: Alice didn't vote so Carol's vote wins
+	results = colourgo.TallyBallots(ballots, 10, 20).Results(weight)
+	if c := results[origin]; c == nil || c.Blue != 255 {
+		t.Errorf("Expected blue in second round, got %v", c)
+	}
+}
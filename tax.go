@@ -0,0 +1,471 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"github.com/AletheiaWareLLC/bcgo"
+	"github.com/golang/protobuf/proto"
+	"time"
+)
+
+const (
+	ERROR_SETTLEMENT_INVALID = "Settlement invalid at %d: %s"
+	ERROR_RECORD_IN_FUTURE   = "Record timestamp %d after %d"
+
+	// Tax rates are expressed in parts per million of the price per period
+	TAX_RATE_SCALE = 1000000
+)
+
+func UnmarshalSettlement(data []byte) (*Settlement, error) {
+	settlement := &Settlement{}
+	if err := proto.Unmarshal(data, settlement); err != nil {
+		return nil, err
+	}
+	return settlement, nil
+}
+
+func CreateSettlementRecord(alias string, key *rsa.PrivateKey, settlement *Settlement) (*bcgo.Record, error) {
+	data, err := proto.Marshal(settlement)
+	if err != nil {
+		return nil, err
+	}
+	return CreateRecord(alias, key, data)
+}
+
+// IsTaxed returns true if owned locations in the canvas are taxed.
+func IsTaxed(canvas *Canvas) bool {
+	return canvas.TaxRate > 0 && canvas.TaxPeriod > 0
+}
+
+func getTaxPeriod(canvas *Canvas) uint64 {
+	return uint64(canvas.TaxPeriod) * uint64(time.Second)
+}
+
+// GetSettlementTime returns the first settlement at or after the timestamp, settlements occur at every multiple of the canvas' TaxPeriod since the epoch.
+func GetSettlementTime(canvas *Canvas, timestamp uint64) uint64 {
+	period := getTaxPeriod(canvas)
+	return (timestamp + period - 1) / period * period
+}
+
+// GetTaxOwed returns the tax owed on the ownership for each whole period between its last payment and the timestamp, and the time it is then paid until.
+func GetTaxOwed(canvas *Canvas, o *Ownership, timestamp uint64) (uint64, uint64) {
+	if !IsTaxed(canvas) || timestamp <= o.PaidUntil {
+		return 0, o.PaidUntil
+	}
+	period := getTaxPeriod(canvas)
+	periods := (timestamp - o.PaidUntil) / period
+	return periods * o.Price * uint64(canvas.TaxRate) / TAX_RATE_SCALE, o.PaidUntil + periods*period
+}
+
+// Settle collects the tax owed on every owned location at the timestamp, using prepaid tax first.
+// Locations whose owner cannot pay are foreclosed; the owner's remaining balance is collected and the location becomes unowned.
+func (l *Ledger) Settle(timestamp uint64) *Settlement {
+	l.Lock()
+	defer l.Unlock()
+	settlement := &Settlement{
+		Timestamp: timestamp,
+	}
+	pixels := make(map[Point]*Colour, len(l.Owners))
+	for p, o := range l.Owners {
+		pixels[p] = o.Colour
+	}
+	for _, p := range SortPoints(pixels) {
+		o := l.Owners[p]
+		owed, paidUntil := GetTaxOwed(l.Canvas, o, timestamp)
+		if owed == 0 {
+			o.PaidUntil = paidUntil
+			continue
+		}
+		prepaid := o.Prepaid
+		if prepaid > owed {
+			prepaid = owed
+		}
+		o.Prepaid -= prepaid
+		owed -= prepaid
+		settlement.Collected += prepaid
+		balance := l.balance(o.Alias)
+		if balance < owed {
+			// Foreclose
+			l.Balances[o.Alias] = 0
+			settlement.Collected += balance
+			settlement.Foreclosed = append(settlement.Foreclosed, p.Location())
+			delete(l.Owners, p)
+			continue
+		}
+		l.Balances[o.Alias] = balance - owed
+		settlement.Collected += owed
+		o.PaidUntil = paidUntil
+	}
+	return settlement
+}
+
+// ReplayTaxed applies the entries in order, settling tax at every settlement time before each entry's block and up to the timestamp.
+// Entries are applied as of their block, or the timestamp if earlier, and entries whose record is stamped after the timestamp are rejected.
+// The callback is called for each invalid entry, the settlements are returned in order.
+func ReplayTaxed(ledger *Ledger, entries []*LedgerEntry, timestamp uint64, callback func(*LedgerEntry, error)) []*Settlement {
+	SortLedgerEntries(entries)
+	var settlements []*Settlement
+	var next uint64
+	settle := func(until uint64) {
+		if !IsTaxed(ledger.Canvas) {
+			return
+		}
+		period := getTaxPeriod(ledger.Canvas)
+		for next > 0 && next <= until {
+			settlements = append(settlements, ledger.Settle(next))
+			ledger.RLock()
+			owned := len(ledger.Owners) > 0
+			ledger.RUnlock()
+			if owned {
+				next += period
+			} else {
+				// Nothing to settle until the next purchase
+				next = 0
+			}
+		}
+	}
+	for _, e := range entries {
+		if r := e.Entry.Record.Timestamp; r > timestamp {
+			callback(e, fmt.Errorf(ERROR_RECORD_IN_FUTURE, r, timestamp))
+			continue
+		}
+		t := e.BlockTimestamp
+		if t == 0 || t > timestamp {
+			clamped := *e
+			clamped.BlockTimestamp = timestamp
+			e, t = &clamped, timestamp
+		}
+		settle(t)
+		if err := ledger.Apply(e); err != nil {
+			callback(e, err)
+			continue
+		}
+		if e.Purchase != nil && next == 0 && IsTaxed(ledger.Canvas) {
+			next = GetSettlementTime(ledger.Canvas, t+1)
+		}
+	}
+	settle(timestamp)
+	return settlements
+}
+
+// EqualSettlement returns true if both settlements collected the same tax and foreclosed the same locations.
+func EqualSettlement(a, b *Settlement) bool {
+	if a.Timestamp != b.Timestamp || a.Collected != b.Collected || len(a.Foreclosed) != len(b.Foreclosed) {
+		return false
+	}
+	for i, l := range a.Foreclosed {
+		if NewPoint(l) != NewPoint(b.Foreclosed[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// SettlementValidator ensures every settlement in a tax channel matches the settlement computed from the grant, purchase and pool channels as of the block.
+type SettlementValidator struct {
+	Canvas      *Canvas
+	Grants      *bcgo.Channel
+	Purchases   *bcgo.Channel
+	Amendments  *Amendments
+	Moderations *Moderations
+}
+
+func (v *SettlementValidator) Validate(channel *bcgo.Channel, cache bcgo.Cache, network bcgo.Network, hash []byte, block *bcgo.Block) error {
+	var recorded []*Settlement
+	if err := bcgo.Iterate(channel.Name, hash, block, cache, network, func(h []byte, b *bcgo.Block) error {
+		for _, entry := range b.Entry {
+			s, err := UnmarshalSettlement(entry.Record.Payload)
+			if err != nil {
+				return err
+			}
+			recorded = append(recorded, s)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	if len(recorded) == 0 {
+		return nil
+	}
+	computed, err := v.compute(cache, network, block.Timestamp)
+	if err != nil {
+		return err
+	}
+	for _, r := range recorded {
+		c, ok := computed[r.Timestamp]
+		if !ok {
+			return fmt.Errorf(ERROR_SETTLEMENT_INVALID, r.Timestamp, "no settlement due")
+		}
+		if !EqualSettlement(r, c) {
+			return fmt.Errorf(ERROR_SETTLEMENT_INVALID, r.Timestamp, "does not match ledger")
+		}
+	}
+	return nil
+}
+
+func (v *SettlementValidator) compute(cache bcgo.Cache, network bcgo.Network, timestamp uint64) (map[uint64]*Settlement, error) {
+	if v.Amendments != nil {
+		if err := v.Amendments.Update(v.Amendments.Channel.Head, cache, network, nil); err != nil {
+			return nil, err
+		}
+	}
+	source := &LedgerSource{
+		Canvas:      v.Canvas,
+		Grants:      v.Grants,
+		Amendments:  v.Amendments,
+		Moderations: v.Moderations,
+	}
+	entries, err := source.Read(v.Purchases.Name, v.Purchases.Head, nil, cache, network, nil)
+	if err != nil {
+		return nil, err
+	}
+	settlements := make(map[uint64]*Settlement)
+	for _, s := range ReplayTaxed(source.NewLedger(), entries, timestamp, func(*LedgerEntry, error) {}) {
+		settlements[s.Timestamp] = s
+	}
+	return settlements, nil
+}
+
+// RadicalMarketModel is a MarketModel in which owners pay tax on the price of their locations, and lose locations they can no longer afford.
+// The canvas owner's node records each settlement on the tax channel so it can be audited.
+type RadicalMarketModel struct {
+	MarketModel
+	Tax         *bcgo.Channel
+	TaxMiner    *Miner
+	Settlements []*Settlement
+}
+
+func NewRadicalMarketModel(node *bcgo.Node, listener bcgo.MiningListener, id string, canvas *Canvas, channel, grants, tax *bcgo.Channel, callback func()) *RadicalMarketModel {
+	m := &RadicalMarketModel{
+		MarketModel: MarketModel{
+			Grants: grants,
+			Ledger: NewLedger(canvas),
+		},
+		Tax: tax,
+	}
+	m.initialize(node, listener, id, canvas, channel, callback)
+	if grants != nil {
+		m.AddCompanion(grants)
+	}
+	if tax != nil {
+		m.AddCompanion(tax)
+		m.TaxMiner = NewMiner(node, tax, COLOUR_THRESHOLD, listener, MINE_ON_WRITE)
+		m.TaxMiner.OnError = func(err error) {
+			m.Emit(&Event{
+				Type:    EVENT_MINING_FAILED,
+				Channel: tax.Name,
+				Error:   err,
+			})
+		}
+		m.TaxMiner.OnRejected = func(entry *bcgo.BlockEntry, err error) {
+			m.Emit(&Event{
+				Type:    EVENT_REJECTED_RECORD,
+				Channel: tax.Name,
+				Hash:    base64.RawURLEncoding.EncodeToString(entry.RecordHash),
+				Error:   err,
+			})
+		}
+	}
+	return m
+}
+
+// SetVerifier sets the verifier used to check records when reading and before mining, including settlements.
+func (m *RadicalMarketModel) SetVerifier(verifier *Verifier) {
+	m.MarketModel.SetVerifier(verifier)
+	if m.TaxMiner != nil {
+		m.TaxMiner.Verifier = verifier
+	}
+}
+
+// Bind reads the channels, and reads again at each settlement so foreclosures take effect.
+func (m *RadicalMarketModel) Bind(ctx context.Context) {
+	ctx = m.bind(ctx, m.Read)
+	if m.TaxMiner != nil {
+		m.TaxMiner.Start(ctx)
+	}
+	if !IsTaxed(m.Canvas) {
+		return
+	}
+	m.Go(func() {
+		for {
+			var wait time.Duration
+			now := bcgo.Timestamp()
+			if next := GetSettlementTime(m.Canvas, now+1); next > now {
+				wait = time.Duration(next - now)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(wait):
+				m.Read(ctx)
+			}
+		}
+	})
+}
+
+// Read replays the grant and purchase channels, settling tax up to now.
+func (m *RadicalMarketModel) Read(ctx context.Context) {
+	m.Logger.Debug("Read:", m.Channel.Name, len(m.Order))
+	m.ReadBase(ctx)
+	m.ReadAmendments(ctx)
+	m.ReadModerations(ctx)
+	source := m.ledgerSource()
+	entries, err := m.readEntries(source)
+	if err != nil {
+		m.Emit(&Event{
+			Type:    EVENT_READ_FAILED,
+			Channel: m.Channel.Name,
+			Error:   err,
+		})
+		return
+	}
+	if ctx.Err() != nil {
+		// Model closed
+		return
+	}
+	ledger := source.NewLedger()
+	settlements := ReplayTaxed(ledger, entries, bcgo.Timestamp(), func(e *LedgerEntry, err error) {
+		m.Emit(&Event{
+			Type:    EVENT_REJECTED_RECORD,
+			Channel: m.Channel.Name,
+			Hash:    base64.RawURLEncoding.EncodeToString(e.Entry.RecordHash),
+			Error:   err,
+		})
+	})
+	poolColours := m.readPoolColours(ledger)
+	m.Lock()
+	var order []string
+	for _, e := range entries {
+		if e.Purchase == nil {
+			continue
+		}
+		id := base64.RawURLEncoding.EncodeToString(e.Entry.RecordHash)
+		m.Entries[id] = e.Entry
+		m.Heights[id] = e.Height
+		m.BlockTimestamps[id] = e.BlockTimestamp
+		order = append(order, id)
+	}
+	m.Order = order
+	m.Ledger = ledger
+	m.PoolColours = poolColours
+	m.Settlements = settlements
+	m.Logger.Debug("Read Complete:", m.Channel.Name, len(m.Order), len(m.Settlements))
+	m.Unlock()
+	if IsOwner(m.Canvas, m.Node.Alias) {
+		m.recordSettlements(settlements)
+	}
+	m.Emit(&Event{
+		Type:    EVENT_READ,
+		Channel: m.Channel.Name,
+	})
+	m.Go(func() {
+		if f := m.OnUpdate; f != nil {
+			f()
+		}
+	})
+}
+
+// recordSettlements writes any settlements not yet in the tax channel.
+func (m *RadicalMarketModel) recordSettlements(settlements []*Settlement) {
+	if m.Tax == nil || len(settlements) == 0 {
+		return
+	}
+	recorded := make(map[uint64]bool)
+	if err := bcgo.Iterate(m.Tax.Name, m.GetHeadOf(m.Tax), nil, m.Node.Cache, m.Node.Network, func(h []byte, b *bcgo.Block) error {
+		for _, entry := range b.Entry {
+			if s, err := UnmarshalSettlement(entry.Record.Payload); err == nil {
+				recorded[s.Timestamp] = true
+			}
+		}
+		return nil
+	}); err != nil {
+		m.Emit(&Event{
+			Type:    EVENT_READ_FAILED,
+			Channel: m.Tax.Name,
+			Error:   err,
+		})
+		return
+	}
+	// Include settlements written but not yet mined
+	if entries, err := m.Node.Cache.GetBlockEntries(m.Tax.Name, 0); err == nil {
+		for _, entry := range entries {
+			if s, err := UnmarshalSettlement(entry.Record.Payload); err == nil {
+				recorded[s.Timestamp] = true
+			}
+		}
+	}
+	var written bool
+	for _, s := range settlements {
+		if recorded[s.Timestamp] {
+			continue
+		}
+		record, err := CreateSettlementRecord(m.Node.Alias, m.Node.Key, s)
+		if err == nil {
+			_, err = bcgo.WriteRecord(m.Tax.Name, m.Node.Cache, record)
+		}
+		if err != nil {
+			m.Emit(&Event{
+				Type:    EVENT_WRITE_FAILED,
+				Channel: m.Tax.Name,
+				Error:   err,
+			})
+			return
+		}
+		written = true
+	}
+	if written {
+		m.TaxMiner.Request()
+	}
+}
+
+// Close stops the tax miner along with the model.
+func (m *RadicalMarketModel) Close() error {
+	if m.TaxMiner != nil {
+		m.TaxMiner.Stop()
+	}
+	return m.MarketModel.Close()
+}
+
+// GetTaxOwed returns the tax currently owed on the location, beyond any prepaid tax.
+func (m *RadicalMarketModel) GetTaxOwed(l *Location) uint64 {
+	ledger := m.getLedger()
+	ledger.RLock()
+	defer ledger.RUnlock()
+	o, ok := ledger.Owners[NewPoint(l)]
+	if !ok {
+		return 0
+	}
+	owed, _ := GetTaxOwed(m.Canvas, o, bcgo.Timestamp())
+	if owed <= o.Prepaid {
+		return 0
+	}
+	return owed - o.Prepaid
+}
+
+// Audit calls the callback with each settlement in order.
+func (m *RadicalMarketModel) Audit(callback func(*Settlement)) {
+	m.Lock()
+	settlements := m.Settlements
+	m.Unlock()
+	for _, s := range settlements {
+		callback(s)
+	}
+}
@@ -0,0 +1,198 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo_test
+
+import (
+	"github.com/AletheiaWareLLC/bcgo"
+	"github.com/AletheiaWareLLC/colourgo"
+	"github.com/AletheiaWareLLC/testinggo"
+	"github.com/golang/protobuf/proto"
+	"testing"
+)
+
+func TestReplayTaxed(t *testing.T) {
+	canvas := &colourgo.Canvas{
+		Width:           1,
+		Height:          1,
+		Faucet:          100,
+		FaucetRecipient: []string{"Alice", "Bob", "Carol"},
+		TaxRate:         100000, // 10%
+		TaxPeriod:       10,
+	}
+	entries := []*colourgo.LedgerEntry{
+		makeLedgerEntry("Alice", 5*second, nil, colourgo.CreatePurchase(0, 0, 0, 0, 255, 0, 0, 255, 50, 5)),
+	}
+	ledger := colourgo.NewLedger(canvas)
+	settlements := colourgo.ReplayTaxed(ledger, entries, 125*second, func(e *colourgo.LedgerEntry, err error) {
+		t.Error(err)
+	})
+	if len(settlements) != 12 {
+		t.Fatalf("Incorrect settlements; expected 12, got %d", len(settlements))
+	}
+	// Prepaid tax covers the first period
+	if s := settlements[1]; s.Timestamp != 20*second || s.Collected != 5 {
+		t.Errorf("Incorrect settlement; expected 5 collected at 20s, got %d at %d", s.Collected, s.Timestamp)
+	}
+	for _, s := range settlements[:11] {
+		if len(s.Foreclosed) != 0 {
+			t.Errorf("Unexpected foreclosure at %d", s.Timestamp)
+		}
+	}
+	if s := settlements[11]; len(s.Foreclosed) != 1 {
+		t.Errorf("Expected foreclosure at %d", s.Timestamp)
+	}
+	if o := ledger.GetOwnership(&colourgo.Location{}); o != nil {
+		t.Errorf("Expected location to be foreclosed, owned by %s", o.Alias)
+	}
+	if b := ledger.GetBalance("Alice"); b != 0 {
+		t.Errorf("Incorrect balance; expected 0, got %d", b)
+	}
+}
+
+func TestLedgerValidator_Taxed(t *testing.T) {
+	canvas := &colourgo.Canvas{
+		Width:           1,
+		Height:          1,
+		Faucet:          100,
+		FaucetRecipient: []string{"Alice", "Bob", "Carol"},
+		TaxRate:         100000, // 10%
+		TaxPeriod:       10,
+	}
+	channel := &bcgo.Channel{
+		Name: "TEST_CHANNEL",
+	}
+	cache := bcgo.NewMemoryCache(2)
+	var block *bcgo.Block
+	var previous []byte
+	for i, p := range []struct {
+		creator   string
+		timestamp uint64
+		purchase  *colourgo.Purchase
+	}{
+		{"Alice", 5 * second, colourgo.CreatePurchase(0, 0, 0, 0, 255, 0, 0, 255, 50, 5)},
+		// Bought cheaply after Alice's location is foreclosed at 120s
+		{"Bob", 130 * second, colourgo.CreatePurchase(0, 0, 0, 0, 0, 0, 255, 255, 10, 0)},
+	} {
+		data, err := proto.Marshal(p.purchase)
+		testinggo.AssertNoError(t, err)
+		// Each purchase is mined in its own block
+		block = &bcgo.Block{
+			Timestamp:   p.timestamp,
+			ChannelName: channel.Name,
+			Length:      uint64(i + 1),
+			Previous:    previous,
+			Entry: []*bcgo.BlockEntry{{
+				RecordHash: []byte{byte(i)},
+				Record: &bcgo.Record{
+					Creator:   p.creator,
+					Timestamp: p.timestamp,
+					Payload:   data,
+				},
+			}},
+		}
+		previous = []byte{'h', byte(i)}
+		testinggo.AssertNoError(t, cache.PutBlock(previous, block))
+	}
+	t.Run("Taxed", func(t *testing.T) {
+		validator := &colourgo.LedgerValidator{
+			Canvas: canvas,
+			Taxed:  true,
+		}
+		testinggo.AssertNoError(t, validator.Validate(channel, cache, nil, previous, block))
+	})
+	t.Run("Untaxed", func(t *testing.T) {
+		validator := &colourgo.LedgerValidator{
+			Canvas: canvas,
+		}
+		if err := validator.Validate(channel, cache, nil, previous, block); err == nil {
+			t.Error("Expected untaxed replay to reject the purchase")
+		}
+	})
+}
+
+func TestReplayTaxed_Timestamps(t *testing.T) {
+	canvas := &colourgo.Canvas{
+		Width:           2,
+		Height:          1,
+		Faucet:          100,
+		FaucetRecipient: []string{"Alice", "Mallory"},
+		TaxRate:         100000, // 10%
+		TaxPeriod:       10,
+	}
+	alice := makeLedgerEntry("Alice", 5*second, nil, colourgo.CreatePurchase(0, 0, 0, 0, 255, 0, 0, 255, 50, 5))
+	// Stamped far in the future to trigger settlements which aren't due
+	mallory := makeLedgerEntry("Mallory", 100000*second, nil, colourgo.CreatePurchase(0, 1, 0, 0, 0, 0, 255, 255, 10, 0))
+	mallory.BlockTimestamp = 10 * second
+	// Mined after the replay time
+	late := makeLedgerEntry("Mallory", 25*second, nil, colourgo.CreatePurchase(0, 1, 0, 0, 0, 255, 0, 255, 10, 0))
+	late.BlockTimestamp = 50 * second
+	ledger := colourgo.NewLedger(canvas)
+	var errs []error
+	settlements := colourgo.ReplayTaxed(ledger, []*colourgo.LedgerEntry{alice, mallory, late}, 30*second, func(e *colourgo.LedgerEntry, err error) {
+		errs = append(errs, err)
+	})
+	if len(errs) != 1 {
+		t.Fatalf("Expected 1 rejection, got %v", errs)
+	}
+	testinggo.AssertError(t, "Record timestamp 100000000000000 after 30000000000", errs[0])
+	if len(settlements) != 3 {
+		t.Fatalf("Incorrect settlements; expected 3, got %d", len(settlements))
+	}
+	if o := ledger.GetOwnership(&colourgo.Location{}); o == nil || o.Alias != "Alice" {
+		t.Errorf("Expected Alice to keep her location, got %v", o)
+	}
+	// Paid from when it is applied, no later than the replay time
+	if o := ledger.GetOwnership(&colourgo.Location{X: 1}); o == nil || o.PaidUntil != 30*second {
+		t.Errorf("Expected location paid until 30s, got %v", o)
+	}
+}
+
+func TestSettlementValidator_Pool(t *testing.T) {
+	canvas := &colourgo.Canvas{
+		Width:           2,
+		Height:          1,
+		Faucet:          100,
+		FaucetRecipient: []string{"Alice"},
+		TaxRate:         100000, // 10%
+		TaxPeriod:       10,
+	}
+	cache := bcgo.NewMemoryCache(10)
+	id := colourgo.GetPoolID("TEST_ID", &colourgo.Location{}, &colourgo.Location{X: 1})
+	purchases := colourgo.GetPurchaseChannelName("TEST_ID")
+	opening, _ := makeBlock(t, cache, purchases, 1*second, nil, "Alice", colourgo.CreatePoolOpening(id))
+	contribution, _ := makeBlock(t, cache, colourgo.GetPurchaseChannelName(id), 2*second, nil, "Alice", colourgo.CreateContribution(50))
+	testinggo.AssertNoError(t, cache.PutHead(colourgo.GetPurchaseChannelName(id), &bcgo.Reference{
+		ChannelName: colourgo.GetPurchaseChannelName(id),
+		BlockHash:   contribution,
+	}))
+	purchase := colourgo.CreatePurchase(0, 0, 0, 0, 255, 0, 0, 255, 10, 0)
+	purchase.Pool = id
+	head, _ := makeBlock(t, cache, purchases, 3*second, opening, "Alice", purchase)
+	// The pool pays 1 at 20s, for the period since its purchase at 3s
+	hash, block := makeBlock(t, cache, "TEST_TAX", 25*second, nil, "Alice", &colourgo.Settlement{
+		Timestamp: 20 * second,
+		Collected: 1,
+	})
+	validator := &colourgo.SettlementValidator{
+		Canvas: canvas,
+		Purchases: &bcgo.Channel{
+			Name: purchases,
+			Head: head,
+		},
+	}
+	testinggo.AssertNoError(t, validator.Validate(&bcgo.Channel{Name: "TEST_TAX"}, cache, nil, hash, block))
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo
+
+import (
+	"github.com/AletheiaWareLLC/bcgo"
+	"sort"
+	"sync"
+)
+
+// bcgo.Channel has no way to remove a trigger, so a single dispatcher is added to each channel which calls the triggers registered here.
+// The dispatcher is detached from the channel when its last trigger is removed.
+type triggerSet struct {
+	sync.Mutex
+	next     int
+	triggers map[int]func()
+	// Position of the dispatcher in the channel's triggers
+	index int
+}
+
+func (s *triggerSet) dispatch() {
+	s.Lock()
+	var ids []int
+	for id := range s.triggers {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	var triggers []func()
+	for _, id := range ids {
+		triggers = append(triggers, s.triggers[id])
+	}
+	s.Unlock()
+	for _, t := range triggers {
+		t()
+	}
+}
+
+var (
+	triggerLock sync.Mutex
+	triggerSets = make(map[*bcgo.Channel]*triggerSet)
+)
+
+// AddTrigger adds a trigger to the channel and returns a function which removes it.
+func AddTrigger(channel *bcgo.Channel, trigger func()) func() {
+	triggerLock.Lock()
+	defer triggerLock.Unlock()
+	set, ok := triggerSets[channel]
+	if !ok {
+		set = &triggerSet{
+			triggers: make(map[int]func()),
+			index:    len(channel.Triggers),
+		}
+		triggerSets[channel] = set
+		channel.AddTrigger(set.dispatch)
+	}
+	set.Lock()
+	defer set.Unlock()
+	id := set.next
+	set.next++
+	set.triggers[id] = trigger
+	return func() {
+		triggerLock.Lock()
+		defer triggerLock.Unlock()
+		set.Lock()
+		defer set.Unlock()
+		delete(set.triggers, id)
+		if len(set.triggers) == 0 && triggerSets[channel] == set {
+			delete(triggerSets, channel)
+			detach(channel, set.index)
+		}
+	}
+}
+
+// detach removes the dispatcher at the given position from the channel's triggers.
+func detach(channel *bcgo.Channel, index int) {
+	if index >= len(channel.Triggers) {
+		return
+	}
+	channel.Triggers = append(channel.Triggers[:index:index], channel.Triggers[index+1:]...)
+}
@@ -0,0 +1,61 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo_test
+
+import (
+	"github.com/AletheiaWareLLC/bcgo"
+	"github.com/AletheiaWareLLC/colourgo"
+	"testing"
+)
+
+func fire(channel *bcgo.Channel) {
+	for _, trigger := range channel.Triggers {
+		trigger()
+	}
+}
+
+func TestAddTrigger(t *testing.T) {
+	channel := &bcgo.Channel{
+		Name: "TEST_CHANNEL",
+	}
+	var a, b int
+	removeA := colourgo.AddTrigger(channel, func() { a++ })
+	removeB := colourgo.AddTrigger(channel, func() { b++ })
+	if len(channel.Triggers) != 1 {
+		t.Fatalf("Incorrect triggers; expected 1 dispatcher, got '%d'", len(channel.Triggers))
+	}
+	fire(channel)
+	if a != 1 || b != 1 {
+		t.Fatalf("Incorrect calls; expected 1 and 1, got '%d' and '%d'", a, b)
+	}
+	removeA()
+	fire(channel)
+	if a != 1 || b != 2 {
+		t.Fatalf("Incorrect calls; expected 1 and 2, got '%d' and '%d'", a, b)
+	}
+	// Dispatcher is detached with the last trigger
+	removeB()
+	if len(channel.Triggers) != 0 {
+		t.Fatalf("Incorrect triggers; expected 0, got '%d'", len(channel.Triggers))
+	}
+	removeC := colourgo.AddTrigger(channel, func() { a++ })
+	defer removeC()
+	fire(channel)
+	if a != 2 || b != 2 {
+		t.Fatalf("Incorrect calls; expected 2 and 2, got '%d' and '%d'", a, b)
+	}
+}
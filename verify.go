@@ -0,0 +1,166 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"github.com/AletheiaWareLLC/bcgo"
+	"github.com/AletheiaWareLLC/cryptogo"
+	"sync"
+)
+
+const (
+	ERROR_UNKNOWN_ALIAS     = "Unknown alias: %s"
+	ERROR_SIGNATURE_INVALID = "Signature invalid for record %s: %s"
+	ERROR_HASH_MISMATCH     = "Record hash mismatch: expected %s, got %s"
+)
+
+// AliasResolver maps an alias to its public key, such as by reading the alias channel.
+type AliasResolver interface {
+	GetPublicKey(alias string) (*rsa.PublicKey, error)
+}
+
+// MemoryAliasResolver is an AliasResolver backed by a map.
+type MemoryAliasResolver struct {
+	sync.RWMutex
+	Keys map[string]*rsa.PublicKey
+}
+
+func NewMemoryAliasResolver() *MemoryAliasResolver {
+	return &MemoryAliasResolver{
+		Keys: make(map[string]*rsa.PublicKey),
+	}
+}
+
+func (r *MemoryAliasResolver) AddKey(alias string, key *rsa.PublicKey) {
+	r.Lock()
+	defer r.Unlock()
+	r.Keys[alias] = key
+}
+
+func (r *MemoryAliasResolver) GetPublicKey(alias string) (*rsa.PublicKey, error) {
+	r.RLock()
+	defer r.RUnlock()
+	key, ok := r.Keys[alias]
+	if !ok {
+		return nil, fmt.Errorf(ERROR_UNKNOWN_ALIAS, alias)
+	}
+	return key, nil
+}
+
+// VerifyRecord checks the record's signature of its payload against the given public key.
+func VerifyRecord(key *rsa.PublicKey, record *bcgo.Record) error {
+	return cryptogo.VerifySignature(key, cryptogo.Hash(record.Payload), record.Signature, record.SignatureAlgorithm)
+}
+
+type VerificationPolicy int
+
+const (
+	// Records are not verified
+	VERIFY_NONE VerificationPolicy = iota
+	// Records which cannot be verified are ignored
+	VERIFY_SKIP
+	// Records which cannot be verified cause an error
+	VERIFY_REJECT
+)
+
+// Verifier checks record signatures against the creator's public key, caching the result per record hash.
+type Verifier struct {
+	sync.Mutex
+	Resolver AliasResolver
+	Policy   VerificationPolicy
+	results  map[string]error
+}
+
+func NewVerifier(resolver AliasResolver, policy VerificationPolicy) *Verifier {
+	return &Verifier{
+		Resolver: resolver,
+		Policy:   policy,
+		results:  make(map[string]error),
+	}
+}
+
+// NewAliasVerifier returns a verifier which skips records not signed by the key registered for their creator on the node's alias channel.
+func NewAliasVerifier(node *bcgo.Node) *Verifier {
+	aliases := node.GetOrOpenChannel(ALIAS, OpenAliasChannel)
+	return NewVerifier(NewAliasChannelResolver(aliases, node.Cache, node.Network), VERIFY_SKIP)
+}
+
+// Verify returns nil if the entry's record hashes to its record hash and was signed by its creator.
+func (v *Verifier) Verify(entry *bcgo.BlockEntry) error {
+	// Results are cached by the computed hash so a forged record can't claim a verified record's hash
+	hash, err := cryptogo.HashProtobuf(entry.Record)
+	if err != nil {
+		return err
+	}
+	id := base64.RawURLEncoding.EncodeToString(hash)
+	if !bytes.Equal(hash, entry.RecordHash) {
+		return fmt.Errorf(ERROR_HASH_MISMATCH, base64.RawURLEncoding.EncodeToString(entry.RecordHash), id)
+	}
+	v.Lock()
+	result, ok := v.results[id]
+	v.Unlock()
+	if ok {
+		return result
+	}
+	key, err := v.Resolver.GetPublicKey(entry.Record.Creator)
+	if err != nil {
+		// Not cached as the alias may be registered later
+		return err
+	}
+	if err := VerifyRecord(key, entry.Record); err != nil {
+		result = fmt.Errorf(ERROR_SIGNATURE_INVALID, id, err)
+	}
+	v.Lock()
+	v.results[id] = result
+	v.Unlock()
+	return result
+}
+
+// Accept returns true if the entry should be ingested according to the policy.
+// An error is returned if the entry cannot be verified and the policy is VERIFY_REJECT.
+func (v *Verifier) Accept(entry *bcgo.BlockEntry) (bool, error) {
+	if v == nil || v.Policy == VERIFY_NONE {
+		return true, nil
+	}
+	if err := v.Verify(entry); err != nil {
+		if v.Policy == VERIFY_REJECT {
+			return false, err
+		}
+		return false, nil
+	}
+	return true, nil
+}
+
+// SignatureValidator ensures every record in a channel was signed by its creator.
+type SignatureValidator struct {
+	Verifier *Verifier
+}
+
+func (s *SignatureValidator) Validate(channel *bcgo.Channel, cache bcgo.Cache, network bcgo.Network, hash []byte, block *bcgo.Block) error {
+	return bcgo.Iterate(channel.Name, hash, block, cache, network, func(h []byte, b *bcgo.Block) error {
+		for _, entry := range b.Entry {
+			if err := s.Verifier.Verify(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,132 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"github.com/AletheiaWareLLC/bcgo"
+	"github.com/AletheiaWareLLC/colourgo"
+	"github.com/AletheiaWareLLC/cryptogo"
+	"github.com/AletheiaWareLLC/testinggo"
+	"testing"
+)
+
+func makeVoteEntry(t *testing.T, alias string, key *rsa.PrivateKey) *bcgo.BlockEntry {
+	t.Helper()
+	record, err := colourgo.CreateVoteRecord(alias, key, colourgo.CreateVote(0, 1, 2, 3, 255, 0, 0, 255))
+	testinggo.AssertNoError(t, err)
+	hash, err := cryptogo.HashProtobuf(record)
+	testinggo.AssertNoError(t, err)
+	return &bcgo.BlockEntry{
+		RecordHash: hash,
+		Record:     record,
+	}
+}
+
+func TestVerifier(t *testing.T) {
+	alice, err := rsa.GenerateKey(rand.Reader, 2048)
+	testinggo.AssertNoError(t, err)
+	mallory, err := rsa.GenerateKey(rand.Reader, 2048)
+	testinggo.AssertNoError(t, err)
+	resolver := colourgo.NewMemoryAliasResolver()
+	resolver.AddKey("Alice", &alice.PublicKey)
+
+	valid := makeVoteEntry(t, "Alice", alice)
+	forged := makeVoteEntry(t, "Alice", mallory)
+	unknown := makeVoteEntry(t, "Bob", mallory)
+
+	t.Run("Reject", func(t *testing.T) {
+		verifier := colourgo.NewVerifier(resolver, colourgo.VERIFY_REJECT)
+		ok, err := verifier.Accept(valid)
+		testinggo.AssertNoError(t, err)
+		if !ok {
+			t.Error("Expected valid record to be accepted")
+		}
+		_, err = verifier.Accept(forged)
+		if err == nil {
+			t.Error("Expected forged record to be rejected")
+		}
+		_, err = verifier.Accept(unknown)
+		testinggo.AssertError(t, "Unknown alias: Bob", err)
+	})
+	t.Run("Skip", func(t *testing.T) {
+		verifier := colourgo.NewVerifier(resolver, colourgo.VERIFY_SKIP)
+		ok, err := verifier.Accept(forged)
+		testinggo.AssertNoError(t, err)
+		if ok {
+			t.Error("Expected forged record to be skipped")
+		}
+	})
+	t.Run("None", func(t *testing.T) {
+		verifier := colourgo.NewVerifier(resolver, colourgo.VERIFY_NONE)
+		ok, err := verifier.Accept(forged)
+		testinggo.AssertNoError(t, err)
+		if !ok {
+			t.Error("Expected forged record to be accepted without verification")
+		}
+	})
+	t.Run("ReusedHash", func(t *testing.T) {
+		verifier := colourgo.NewVerifier(resolver, colourgo.VERIFY_REJECT)
+		ok, err := verifier.Accept(valid)
+		testinggo.AssertNoError(t, err)
+		if !ok {
+			t.Error("Expected valid record to be accepted")
+		}
+		// Claims the verified record's hash
+		reused := &bcgo.BlockEntry{
+			RecordHash: valid.RecordHash,
+			Record:     forged.Record,
+		}
+		if _, err := verifier.Accept(reused); err == nil {
+			t.Error("Expected record with reused hash to be rejected")
+		}
+	})
+}
+
+func TestGetModel_Verifier(t *testing.T) {
+	node := &bcgo.Node{
+		Alias:    "Alice",
+		Cache:    bcgo.NewMemoryCache(10),
+		Channels: make(map[string]*bcgo.Channel),
+	}
+	canvas, err := colourgo.CreateCanvas("TEST_CANVAS", 1, 1, 1, colourgo.Mode_FREE_FOR_ALL)
+	testinggo.AssertNoError(t, err)
+	model, err := colourgo.GetModel(node, nil, "TEST_ID", canvas, nil)
+	testinggo.AssertNoError(t, err)
+	defer model.Close()
+	if model.(*colourgo.FreeForAllModel).Verifier == nil {
+		t.Error("Expected model to have a verifier")
+	}
+	for _, name := range []string{
+		colourgo.GetVoteChannelName("TEST_ID"),
+		colourgo.GetModerationChannelName("TEST_ID"),
+		colourgo.GetAmendmentChannelName("TEST_ID"),
+	} {
+		channel, err := node.GetChannel(name)
+		testinggo.AssertNoError(t, err)
+		found := false
+		for _, v := range channel.Validators {
+			if _, ok := v.(*colourgo.SignatureValidator); ok {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Expected %s to validate signatures", name)
+		}
+	}
+}
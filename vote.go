@@ -17,56 +17,99 @@
 package colourgo
 
 import (
+	"context"
 	"crypto/rsa"
 	"encoding/base64"
 	"github.com/AletheiaWareLLC/bcgo"
 	"github.com/golang/protobuf/proto"
-	"log"
 	"sort"
 )
 
 type VoteModel struct {
 	BaseModel
-	Votes map[string]*Vote
+	Votes    map[string]*Vote
+	Weighter VoteWeighter
 }
 
 func NewVoteModel(node *bcgo.Node, listener bcgo.MiningListener, id string, canvas *Canvas, channel *bcgo.Channel, callback func()) *VoteModel {
-	return &VoteModel{
-		BaseModel: BaseModel{
-			Node:     node,
-			Listener: listener,
-			ID:       id,
-			Canvas:   canvas,
-			Channel:  channel,
-			OnUpdate: callback,
-			Entries:  make(map[string]*bcgo.BlockEntry),
-		},
+	m := &VoteModel{
 		Votes: make(map[string]*Vote),
 	}
+	m.initialize(node, listener, id, canvas, channel, callback)
+	return m
+}
+
+// GetWeighter returns the model's weighter, or that declared by the canvas if not set.
+func (m *VoteModel) GetWeighter() VoteWeighter {
+	if m.Weighter == nil {
+		return GetVoteWeighter(m.ID, m.Canvas)
+	}
+	return m.Weighter
+}
+
+// createVoteRecord creates a record of the vote, first proving work for the current round if required by the canvas, which is abandoned if the context is done.
+func (m *VoteModel) createVoteRecord(ctx context.Context, vote *Vote) (*bcgo.Record, error) {
+	if m.Canvas.Weighting == Weighting_PROOF_OF_WORK {
+		if err := ProveWork(ctx, m.Node.Alias, m.ID, GetWorkRound(m.Canvas, bcgo.Timestamp()), vote, GetWorkThreshold(m.Canvas)); err != nil {
+			return nil, err
+		}
+	}
+	return CreateVoteRecord(m.Node.Alias, m.Node.Key, vote)
 }
 
-func (m *VoteModel) Bind() {
-	m.Channel.AddTrigger(m.Read)
-	go func() {
-		m.Refresh()
-		m.Read()
-	}()
+func (m *VoteModel) Bind(ctx context.Context) {
+	m.bind(ctx, m.Read)
 }
 
-func (m *VoteModel) Read() {
-	log.Println("Read:", m.Channel.Name, len(m.Order), len(m.Votes))
+func (m *VoteModel) Read(ctx context.Context) {
+	m.Logger.Debug("Read:", m.Channel.Name, len(m.Order), len(m.Votes))
+	m.ReadBase(ctx)
+	m.ReadAmendments(ctx)
+	m.ReadModerations(ctx)
 	m.Lock()
-	if err := GetVotes(m.Channel, m.Node.Cache, m.Node.Network, func(entry *bcgo.BlockEntry, vote *Vote) error {
-		id := base64.RawURLEncoding.EncodeToString(entry.RecordHash)
-		log.Println("Got Vote:", id, entry.Record.Timestamp, vote)
-		_, ok := m.Votes[id]
-		if ok {
-			log.Println("Vote already counted")
-			return bcgo.StopIterationError{}
-		} else {
-			log.Println("Counting Vote:", id)
+	if err := bcgo.Iterate(m.Channel.Name, m.GetHead(), nil, m.Node.Cache, m.Node.Network, func(hash []byte, block *bcgo.Block) error {
+		for _, entry := range block.Entry {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			id := base64.RawURLEncoding.EncodeToString(entry.RecordHash)
+			if _, ok := m.Votes[id]; ok {
+				m.Logger.Debug("Vote already counted:", id)
+				return bcgo.StopIterationError{}
+			}
+			if ok, err := m.Verifier.Accept(entry); err != nil {
+				return err
+			} else if !ok {
+				m.Emit(&Event{
+					Type:    EVENT_UNVERIFIED_RECORD,
+					Channel: m.Channel.Name,
+					Hash:    id,
+				})
+				continue
+			}
+			if IsBanned(m.Canvas, entry.Record.Creator) {
+				m.Emit(&Event{
+					Type:    EVENT_BANNED_RECORD,
+					Channel: m.Channel.Name,
+					Hash:    id,
+				})
+				continue
+			}
+			vote, err := UnmarshalVote(entry.Record.Payload)
+			if err != nil {
+				m.Emit(&Event{
+					Type:    EVENT_CORRUPT_RECORD,
+					Channel: m.Channel.Name,
+					Hash:    id,
+					Error:   err,
+				})
+				continue
+			}
+			m.Logger.Debug("Counting Vote:", id, entry.Record.Timestamp, vote)
 			m.Votes[id] = vote
 			m.Entries[id] = entry
+			m.Heights[id] = block.Length
+			m.BlockTimestamps[id] = block.Timestamp
 			m.Order = append(m.Order, id)
 		}
 		return nil
@@ -75,36 +118,90 @@ func (m *VoteModel) Read() {
 		case bcgo.StopIterationError:
 			// Do nothing
 		default:
-			log.Println(err)
+			m.Emit(&Event{
+				Type:    EVENT_READ_FAILED,
+				Channel: m.Channel.Name,
+				Error:   err,
+			})
 		}
 	}
 	sort.Slice(m.Order, func(i, j int) bool {
 		return m.Entries[m.Order[i]].Record.Timestamp < m.Entries[m.Order[j]].Record.Timestamp
 	})
-	log.Println("Read Complete:", m.Channel.Name, len(m.Order), len(m.Votes))
+	m.Logger.Debug("Read Complete:", m.Channel.Name, len(m.Order), len(m.Votes))
 	m.Unlock()
-	go func() {
+	if ctx.Err() != nil {
+		// Model closed
+		return
+	}
+	m.Emit(&Event{
+		Type:    EVENT_READ,
+		Channel: m.Channel.Name,
+	})
+	m.Go(func() {
 		if f := m.OnUpdate; f != nil {
 			f()
 		}
-		if err := m.Mine(); err != nil {
-			log.Println(err)
-		}
-	}()
+	})
 }
 
 func (m *VoteModel) Write(l *Location, c *Colour) error {
-	record, err := CreateVoteRecord(m.Node.Alias, m.Node.Key, &Vote{
+	if err := m.CheckContributor(m.Node.Alias); err != nil {
+		return err
+	}
+	if err := ValidateColour(m.Canvas, c); err != nil {
+		return err
+	}
+	if err := CheckBounds(m.Canvas, l); err != nil {
+		return err
+	}
+	record, err := m.createVoteRecord(m.Context(), &Vote{
 		Colour:   c,
 		Location: l,
 	})
 	if err != nil {
 		return err
 	}
-	_, err = bcgo.WriteRecord(m.Channel.Name, m.Node.Cache, record)
+	reference, err := bcgo.WriteRecord(m.Channel.Name, m.Node.Cache, record)
+	if err != nil {
+		return err
+	}
+	m.Emit(&Event{
+		Type:    EVENT_WRITE,
+		Channel: m.Channel.Name,
+		Hash:    base64.RawURLEncoding.EncodeToString(reference.RecordHash),
+	})
+	m.Miner.Request()
+	return nil
+}
+
+// WriteBatch writes a single vote colouring every location.
+func (m *VoteModel) WriteBatch(ls []*Location, c *Colour) error {
+	if err := m.CheckContributor(m.Node.Alias); err != nil {
+		return err
+	}
+	if err := ValidateColour(m.Canvas, c); err != nil {
+		return err
+	}
+	for _, l := range ls {
+		if err := CheckBounds(m.Canvas, l); err != nil {
+			return err
+		}
+	}
+	record, err := m.createVoteRecord(m.Context(), CreateBatchVote(ls, c))
+	if err != nil {
+		return err
+	}
+	reference, err := bcgo.WriteRecord(m.Channel.Name, m.Node.Cache, record)
 	if err != nil {
 		return err
 	}
+	m.Emit(&Event{
+		Type:    EVENT_WRITE,
+		Channel: m.Channel.Name,
+		Hash:    base64.RawURLEncoding.EncodeToString(reference.RecordHash),
+	})
+	m.Miner.Request()
 	return nil
 }
 
@@ -113,31 +210,51 @@ type FreeForAllModel struct {
 }
 
 func NewFreeForAllModel(node *bcgo.Node, listener bcgo.MiningListener, id string, canvas *Canvas, channel *bcgo.Channel, callback func()) *FreeForAllModel {
-	return &FreeForAllModel{
+	m := &FreeForAllModel{
 		VoteModel: VoteModel{
-			BaseModel: BaseModel{
-				Node:     node,
-				Listener: listener,
-				ID:       id,
-				Canvas:   canvas,
-				Channel:  channel,
-				OnUpdate: callback,
-				Entries:  make(map[string]*bcgo.BlockEntry),
-			},
 			Votes: make(map[string]*Vote),
 		},
 	}
+	m.initialize(node, listener, id, canvas, channel, callback)
+	return m
 }
 
+// Draw calls the callback with the final opaque colour of each pixel, layers are flattened and frames are iterated in order.
 func (m *FreeForAllModel) Draw(callback func(*Location, *Colour)) {
+	pixels := Composite(m.Canvas, GetState(m.Canvas, m))
+	for _, p := range SortPoints(pixels) {
+		callback(p.Location(), pixels[p])
+	}
+}
+
+// DrawLayers calls the callback with each vote in the order they were cast, omitting those retracted, hidden by moderation or without weight.
+func (m *FreeForAllModel) DrawLayers(callback func(*Location, *Colour)) {
+	m.DrawBase(callback)
+	weighter := m.GetWeighter()
+	retracted := make(map[string]bool)
+	m.History(func(ballot, retraction *Ballot) {
+		if retraction != nil {
+			retracted[base64.RawURLEncoding.EncodeToString(ballot.Entry.RecordHash)] = true
+		}
+	})
 	m.Lock()
 	defer m.Unlock()
-	log.Println("Drawing:", len(m.Order), len(m.Votes))
+	m.Logger.Debug("Drawing:", len(m.Order), len(m.Votes))
 	for _, id := range m.Order {
 		vote, ok := m.Votes[id]
-		if ok {
-			log.Println("Drawing Vote:", id, m.Entries[id].Record.Timestamp, vote)
-			callback(vote.Location, vote.Colour)
+		if !ok || (vote.Location == nil && len(vote.Batch) == 0) || IsDelegation(vote) || retracted[id] {
+			continue
+		}
+		entry := m.Entries[id]
+		if weighter.Weight(entry.Record.Creator, &Ballot{Entry: entry, BlockTimestamp: m.BlockTimestamps[id], Vote: vote}) <= 0 {
+			continue
+		}
+		for _, v := range ExpandVote(vote) {
+			if m.IsHidden(id, v.Location) {
+				continue
+			}
+			m.Logger.Debug("Drawing Vote:", id, entry.Record.Timestamp, v)
+			callback(v.Location, v.Colour)
 		}
 	}
 }
@@ -150,9 +267,15 @@ func UnmarshalVote(data []byte) (*Vote, error) {
 	return vote, nil
 }
 
-func GetVotes(votes *bcgo.Channel, cache bcgo.Cache, network bcgo.Network, callback func(*bcgo.BlockEntry, *Vote) error) error {
+// GetVotes calls the callback with each vote in the channel, records are checked by the verifier if not nil.
+func GetVotes(votes *bcgo.Channel, cache bcgo.Cache, network bcgo.Network, verifier *Verifier, callback func(*bcgo.BlockEntry, *Vote) error) error {
 	return bcgo.Iterate(votes.Name, votes.Head, nil, cache, network, func(hash []byte, block *bcgo.Block) error {
 		for _, entry := range block.Entry {
+			if ok, err := verifier.Accept(entry); err != nil {
+				return err
+			} else if !ok {
+				continue
+			}
 			record := entry.Record
 			v, err := UnmarshalVote(record.Payload)
 			if err != nil {
@@ -216,6 +339,29 @@ func CreateVote(w, x, y, z, red, green, blue, alpha uint32) *Vote {
 	}
 }
 
+// CreateBatchVote creates a vote colouring every location.
+func CreateBatchVote(ls []*Location, c *Colour) *Vote {
+	return &Vote{
+		Colour: c,
+		Batch:  ls,
+	}
+}
+
+// ExpandVote returns a vote for each location coloured by a batch vote, or the vote itself if not a batch.
+func ExpandVote(vote *Vote) []*Vote {
+	if len(vote.Batch) == 0 {
+		return []*Vote{vote}
+	}
+	var votes []*Vote
+	for _, l := range vote.Batch {
+		votes = append(votes, &Vote{
+			Colour:   vote.Colour,
+			Location: l,
+		})
+	}
+	return votes
+}
+
 func CreateVoteRecord(alias string, key *rsa.PrivateKey, vote *Vote) (*bcgo.Record, error) {
 	data, err := proto.Marshal(vote)
 	if err != nil {
@@ -17,6 +17,7 @@
 package colourgo_test
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/rsa"
 	"github.com/AletheiaWareLLC/bcgo"
@@ -60,10 +61,47 @@ func TestVoteModel_Read(t *testing.T) {
 		t.Errorf("Unexpected read")
 		return
 	}
-	model.Bind()
+	ctx := context.Background()
+	model.Bind(ctx)
 	awaitRead(t, reads)
-	model.Read()
+	model.Read(ctx)
 	awaitRead(t, reads)
+	testinggo.AssertNoError(t, model.Close())
+}
+
+func TestVoteModel_Close(t *testing.T) {
+	cache := bcgo.NewMemoryCache(1)
+	node := &bcgo.Node{
+		Alias:    "TEST_ALIAS",
+		Key:      nil,
+		Cache:    cache,
+		Network:  nil,
+		Channels: make(map[string]*bcgo.Channel),
+	}
+	channel := &bcgo.Channel{
+		Name: "TEST_CHANNEL",
+	}
+	canvas := &colourgo.Canvas{
+		Name: "TEST_CANVAS",
+	}
+	id := "TEST_ID"
+	reads := make(chan bool, 1)
+	model := colourgo.NewVoteModel(node, nil, id, canvas, channel, func() {
+		reads <- true
+	})
+	model.Bind(context.Background())
+	awaitRead(t, reads)
+	testinggo.AssertNoError(t, model.Close())
+	// Simulate channel update
+	for _, trigger := range channel.Triggers {
+		trigger()
+	}
+	select {
+	case <-reads:
+		t.Fatal("Unexpected read after close")
+	case <-time.After(100 * time.Millisecond):
+		// Pass
+	}
 }
 
 func TestVoteModel_Write(t *testing.T) {
@@ -83,10 +121,14 @@ func TestVoteModel_Write(t *testing.T) {
 		Name: "TEST_CHANNEL",
 	}
 	canvas := &colourgo.Canvas{
-		Name: "TEST_CANVAS",
+		Name:   "TEST_CANVAS",
+		Width:  2,
+		Height: 3,
+		Depth:  4,
 	}
 	id := "TEST_ID"
 	model := colourgo.NewVoteModel(node, nil, id, canvas, channel, nil)
+	testinggo.AssertError(t, "Location out of bounds: 0,2,2,3", model.Write(&colourgo.Location{X: 2, Y: 2, Z: 3}, &colourgo.Colour{}))
 	l := &colourgo.Location{
 		X: 1,
 		Y: 2,
@@ -114,3 +156,152 @@ func TestVoteModel_Write(t *testing.T) {
 
 func TestFreeForAllModel_Draw(t *testing.T) {
 }
+
+func TestVoteModel_Events(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	testinggo.AssertNoError(t, err)
+	node := &bcgo.Node{
+		Alias:    "TEST_ALIAS",
+		Key:      key,
+		Cache:    bcgo.NewMemoryCache(1),
+		Channels: make(map[string]*bcgo.Channel),
+	}
+	channel := &bcgo.Channel{
+		Name: "TEST_CHANNEL",
+	}
+	model := colourgo.NewVoteModel(node, nil, "TEST_ID", &colourgo.Canvas{Width: 1, Height: 1, Depth: 1}, channel, nil)
+	model.Logger = colourgo.NewLogger(colourgo.LOG_NONE)
+	model.Miner.Policy = colourgo.MINE_MANUAL
+	testinggo.AssertNoError(t, model.Write(&colourgo.Location{}, &colourgo.Colour{}))
+	select {
+	case event := <-model.Events():
+		if event.Type != colourgo.EVENT_WRITE {
+			t.Fatalf("Incorrect event; expected '%s', got '%s'", colourgo.EVENT_WRITE, event.Type)
+		}
+		if event.Channel != channel.Name {
+			t.Fatalf("Incorrect channel; expected '%s', got '%s'", channel.Name, event.Channel)
+		}
+	default:
+		t.Fatal("Expected write event")
+	}
+}
+
+func TestVoteModel_WriteBatch(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	testinggo.AssertNoError(t, err)
+	node := &bcgo.Node{
+		Alias:    "TEST_ALIAS",
+		Key:      key,
+		Cache:    bcgo.NewMemoryCache(10),
+		Channels: make(map[string]*bcgo.Channel),
+	}
+	canvas, err := colourgo.CreateCanvas("Batch", 2, 2, 1, colourgo.Mode_FREE_FOR_ALL)
+	testinggo.AssertNoError(t, err)
+	channel := node.GetOrOpenChannel(colourgo.GetVoteChannelName("TEST_ID"), func() *bcgo.Channel {
+		return colourgo.OpenVoteChannel("TEST_ID")
+	})
+	model := colourgo.NewFreeForAllModel(node, nil, "TEST_ID", canvas, channel, nil)
+	model.Logger = colourgo.NewLogger(colourgo.LOG_NONE)
+	model.Miner.Policy = colourgo.MINE_MANUAL
+	red := &colourgo.Colour{Red: 255, Alpha: 255}
+	testinggo.AssertError(t, "Location out of bounds: 0,0,2,0", model.WriteBatch([]*colourgo.Location{{X: 0}, {Y: 2}}, red))
+	locations := []*colourgo.Location{{X: 0}, {X: 1}}
+	testinggo.AssertNoError(t, model.WriteBatch(locations, red))
+	entries, err := node.Cache.GetBlockEntries(channel.Name, 0)
+	testinggo.AssertNoError(t, err)
+	if len(entries) != 1 {
+		t.Fatalf("Incorrect entries; expected 1, got '%d'", len(entries))
+	}
+	vote, err := colourgo.UnmarshalVote(entries[0].Record.Payload)
+	testinggo.AssertNoError(t, err)
+	if len(vote.Batch) != 2 {
+		t.Fatalf("Incorrect batch; expected 2, got '%d'", len(vote.Batch))
+	}
+
+	ctx := context.Background()
+	testinggo.AssertNoError(t, model.Miner.Mine(ctx))
+	model.Read(ctx)
+	state := colourgo.GetState(canvas, model)
+	if len(state) != 2 {
+		t.Fatalf("Incorrect state; expected 2, got '%d'", len(state))
+	}
+	for _, l := range locations {
+		testinggo.AssertProtobufEqual(t, red, state[colourgo.NewPoint(l)])
+	}
+}
+
+func TestVoteModel_EventsClosed(t *testing.T) {
+	node := &bcgo.Node{
+		Alias:    "TEST_ALIAS",
+		Cache:    bcgo.NewMemoryCache(1),
+		Channels: make(map[string]*bcgo.Channel),
+	}
+	channel := &bcgo.Channel{
+		Name: "TEST_CHANNEL",
+	}
+	model := colourgo.NewVoteModel(node, nil, "TEST_ID", &colourgo.Canvas{}, channel, nil)
+	model.Logger = colourgo.NewLogger(colourgo.LOG_NONE)
+	done := make(chan bool)
+	go func() {
+		for range model.Events() {
+		}
+		done <- true
+	}()
+	testinggo.AssertNoError(t, model.Close())
+	select {
+	case <-done:
+	// Pass
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for events to close")
+	}
+	// Emitting after close doesn't panic
+	model.Emit(&colourgo.Event{Type: colourgo.EVENT_READ})
+}
+
+func TestVoteModel_RefreshEmpty(t *testing.T) {
+	node := &bcgo.Node{
+		Alias:    "Alice",
+		Cache:    bcgo.NewMemoryCache(10),
+		Channels: make(map[string]*bcgo.Channel),
+	}
+	canvas, err := colourgo.CreateCanvas("TEST_CANVAS", 1, 1, 1, colourgo.Mode_FREE_FOR_ALL)
+	testinggo.AssertNoError(t, err)
+	model, err := colourgo.GetModel(node, nil, "TEST_ID", canvas, nil)
+	testinggo.AssertNoError(t, err)
+	defer model.Close()
+	// None of the channels have a head yet
+	testinggo.AssertNoError(t, model.Refresh(context.Background()))
+}
+
+func TestVoteModel_WriteClosed(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	testinggo.AssertNoError(t, err)
+	node := &bcgo.Node{
+		Alias:    "TEST_ALIAS",
+		Key:      key,
+		Cache:    bcgo.NewMemoryCache(10),
+		Channels: make(map[string]*bcgo.Channel),
+	}
+	canvas, err := colourgo.CreateCanvas("Work", 2, 2, 1, colourgo.Mode_FREE_FOR_ALL)
+	testinggo.AssertNoError(t, err)
+	canvas.Weighting = colourgo.Weighting_PROOF_OF_WORK
+	// Unreachable, so work only ends when the model is closed
+	canvas.WorkThreshold = 512
+	channel := node.GetOrOpenChannel(colourgo.GetVoteChannelName("TEST_ID"), func() *bcgo.Channel {
+		return colourgo.OpenVoteChannel("TEST_ID")
+	})
+	model := colourgo.NewFreeForAllModel(node, nil, "TEST_ID", canvas, channel, nil)
+	model.Logger = colourgo.NewLogger(colourgo.LOG_NONE)
+	model.Miner.Policy = colourgo.MINE_MANUAL
+	errs := make(chan error, 1)
+	go func() {
+		errs <- model.Write(&colourgo.Location{}, &colourgo.Colour{Red: 255, Alpha: 255})
+	}()
+	testinggo.AssertNoError(t, model.Close())
+	select {
+	case err := <-errs:
+		testinggo.AssertError(t, "context canceled", err)
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for write to be cancelled")
+	}
+}
@@ -0,0 +1,149 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"github.com/AletheiaWareLLC/bcgo"
+	"github.com/AletheiaWareLLC/cryptogo"
+	"github.com/golang/protobuf/proto"
+)
+
+// VoteWeighter decides how much an alias's vote counts, given the ballot the alias cast itself, which may be a delegation.
+type VoteWeighter interface {
+	Weight(alias string, ballot *Ballot) float64
+}
+
+// EqualWeighter gives every vote the same weight.
+type EqualWeighter struct{}
+
+func (w *EqualWeighter) Weight(alias string, ballot *Ballot) float64 {
+	return 1
+}
+
+// ProofOfWorkWeighter only counts votes whose work hash has more ones than the threshold, as with mined blocks.
+type ProofOfWorkWeighter struct {
+	ID        string
+	Canvas    *Canvas
+	Threshold uint64
+}
+
+func (w *ProofOfWorkWeighter) Weight(alias string, ballot *Ballot) float64 {
+	if ballot == nil {
+		return 0
+	}
+	// The work is bound to the creator, canvas and round, so a payload copied by another alias, or into another round, doesn't count
+	hash := GetWorkHash(ballot.Entry.Record.Creator, w.ID, GetWorkRound(w.Canvas, ballot.BlockTimestamp), ballot.Entry.Record.Payload)
+	if bcgo.Ones(hash) <= w.Threshold {
+		return 0
+	}
+	return 1
+}
+
+// AllowListWeighter only counts votes from the listed aliases.
+type AllowListWeighter struct {
+	Aliases map[string]bool
+}
+
+func NewAllowListWeighter(aliases []string) *AllowListWeighter {
+	w := &AllowListWeighter{
+		Aliases: make(map[string]bool),
+	}
+	for _, a := range aliases {
+		w.Aliases[a] = true
+	}
+	return w
+}
+
+func (w *AllowListWeighter) Weight(alias string, ballot *Ballot) float64 {
+	if w.Aliases[alias] {
+		return 1
+	}
+	return 0
+}
+
+// GetWorkThreshold returns the number of ones a vote's payload hash must exceed on a proof of work canvas, by default COLOUR_VOTE_THRESHOLD.
+func GetWorkThreshold(canvas *Canvas) uint64 {
+	if canvas.WorkThreshold == 0 {
+		return COLOUR_VOTE_THRESHOLD
+	}
+	return canvas.WorkThreshold
+}
+
+// GetWorkRound returns the voting round at the timestamp, or 0 if the canvas doesn't have rounds.
+func GetWorkRound(canvas *Canvas, timestamp uint64) uint64 {
+	if canvas == nil || !HasRounds(canvas) {
+		return 0
+	}
+	round, err := GetRound(canvas, timestamp)
+	if err != nil {
+		return 0
+	}
+	return round
+}
+
+// GetWorkHash returns the hash of the creator, canvas ID, round and payload of a vote.
+func GetWorkHash(alias, id string, round uint64, payload []byte) []byte {
+	var buffer bytes.Buffer
+	for _, s := range []string{alias, id} {
+		length := make([]byte, binary.MaxVarintLen64)
+		buffer.Write(length[:binary.PutUvarint(length, uint64(len(s)))])
+		buffer.WriteString(s)
+	}
+	r := make([]byte, 8)
+	binary.BigEndian.PutUint64(r, round)
+	buffer.Write(r)
+	buffer.Write(payload)
+	return cryptogo.Hash(buffer.Bytes())
+}
+
+// GetVoteWeighter returns the weighter declared by the canvas with the given ID.
+func GetVoteWeighter(id string, canvas *Canvas) VoteWeighter {
+	switch canvas.Weighting {
+	case Weighting_PROOF_OF_WORK:
+		return &ProofOfWorkWeighter{
+			ID:        id,
+			Canvas:    canvas,
+			Threshold: GetWorkThreshold(canvas),
+		}
+	case Weighting_ALLOW_LIST:
+		return NewAllowListWeighter(canvas.Voter)
+	default:
+		return &EqualWeighter{}
+	}
+}
+
+// ProveWork increments the vote's nonce until its work hash, for the alias, canvas ID and round, has more ones than the threshold, or the context is done.
+func ProveWork(ctx context.Context, alias, id string, round uint64, vote *Vote, threshold uint64) error {
+	for nonce := uint64(1); nonce > 0; nonce++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		vote.Nonce = nonce
+		data, err := proto.Marshal(vote)
+		if err != nil {
+			return err
+		}
+		if bcgo.Ones(GetWorkHash(alias, id, round, data)) > threshold {
+			return nil
+		}
+	}
+	return errors.New(bcgo.ERROR_NONCE_WRAP_AROUND)
+}
@@ -0,0 +1,132 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo_test
+
+import (
+	"context"
+	"github.com/AletheiaWareLLC/colourgo"
+	"github.com/AletheiaWareLLC/testinggo"
+	"github.com/golang/protobuf/proto"
+	"testing"
+)
+
+func TestGetVoteWeighter(t *testing.T) {
+	if _, ok := colourgo.GetVoteWeighter("TEST_ID", &colourgo.Canvas{}).(*colourgo.EqualWeighter); !ok {
+		t.Error("Expected equal weighter by default")
+	}
+	w, ok := colourgo.GetVoteWeighter("TEST_ID", &colourgo.Canvas{Weighting: colourgo.Weighting_PROOF_OF_WORK}).(*colourgo.ProofOfWorkWeighter)
+	if !ok || w.ID != "TEST_ID" || w.Threshold != colourgo.COLOUR_VOTE_THRESHOLD {
+		t.Errorf("Expected proof of work weighter with vote threshold, got %v", w)
+	}
+}
+
+func TestProofOfWorkWeighter(t *testing.T) {
+	weighter := &colourgo.ProofOfWorkWeighter{
+		ID:        "TEST_ID",
+		Canvas:    &colourgo.Canvas{},
+		Threshold: colourgo.COLOUR_THRESHOLD,
+	}
+	vote := colourgo.CreateVote(0, 0, 0, 0, 255, 0, 0, 255)
+	testinggo.AssertNoError(t, colourgo.ProveWork(context.Background(), "Alice", "TEST_ID", 0, vote, colourgo.COLOUR_THRESHOLD))
+	data, err := proto.Marshal(vote)
+	testinggo.AssertNoError(t, err)
+	ballot := makeBallot("Alice", 1, vote)
+	ballot.Entry.Record.Payload = data
+	if w := weighter.Weight("Alice", ballot); w != 1 {
+		t.Errorf("Expected proven vote to count, got %f", w)
+	}
+	// The proof doesn't carry over to a payload copied by another alias, or to another canvas
+	if w := weighter.Weight("Mallory", makeProvenBallot(t, "Mallory", vote)); w != 0 {
+		t.Errorf("Expected copied vote not to count, got %f", w)
+	}
+	other := &colourgo.ProofOfWorkWeighter{
+		ID:        "OTHER_ID",
+		Canvas:    &colourgo.Canvas{},
+		Threshold: colourgo.COLOUR_THRESHOLD,
+	}
+	if w := other.Weight("Alice", ballot); w != 0 {
+		t.Errorf("Expected vote on another canvas not to count, got %f", w)
+	}
+	if vote.Nonce == 1 {
+		// No earlier nonce to compare against
+		return
+	}
+	// ProveWork stops at the first nonce which meets the threshold
+	vote.Nonce--
+	data, err = proto.Marshal(vote)
+	testinggo.AssertNoError(t, err)
+	ballot.Entry.Record.Payload = data
+	if w := weighter.Weight("Alice", ballot); w != 0 {
+		t.Errorf("Expected unproven vote not to count, got %f", w)
+	}
+}
+
+func TestProofOfWorkWeighter_Rounds(t *testing.T) {
+	canvas := &colourgo.Canvas{
+		RoundLength: 10,
+	}
+	weighter := &colourgo.ProofOfWorkWeighter{
+		ID:        "TEST_ID",
+		Canvas:    canvas,
+		Threshold: colourgo.COLOUR_THRESHOLD,
+	}
+	vote := colourgo.CreateVote(0, 0, 0, 0, 255, 0, 0, 255)
+	testinggo.AssertNoError(t, colourgo.ProveWork(context.Background(), "Alice", "TEST_ID", 1, vote, colourgo.COLOUR_THRESHOLD))
+	ballot := makeProvenBallot(t, "Alice", vote)
+	ballot.BlockTimestamp = 15 * second
+	if w := weighter.Weight("Alice", ballot); w != 1 {
+		t.Errorf("Expected vote mined in its round to count, got %f", w)
+	}
+	// Replayed in a later round
+	ballot.BlockTimestamp = 25 * second
+	if w := weighter.Weight("Alice", ballot); w != 0 {
+		t.Errorf("Expected replayed vote not to count, got %f", w)
+	}
+}
+
+func makeProvenBallot(t *testing.T, creator string, vote *colourgo.Vote) *colourgo.Ballot {
+	t.Helper()
+	data, err := proto.Marshal(vote)
+	testinggo.AssertNoError(t, err)
+	ballot := makeBallot(creator, 1, vote)
+	ballot.Entry.Record.Payload = data
+	return ballot
+}
+
+func TestProveWork_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	vote := colourgo.CreateVote(0, 0, 0, 0, 255, 0, 0, 255)
+	testinggo.AssertError(t, "context canceled", colourgo.ProveWork(ctx, "Alice", "TEST_ID", 0, vote, colourgo.COLOUR_VOTE_THRESHOLD))
+}
+
+func TestAllowListWeighter(t *testing.T) {
+	red := colourgo.CreateVote(0, 0, 0, 0, 255, 0, 0, 255)
+	blue := colourgo.CreateVote(0, 0, 0, 0, 0, 0, 255, 255)
+	origin := colourgo.Point{}
+	tally := colourgo.NewTally()
+	for _, b := range []*colourgo.Ballot{
+		makeBallot("Alice", 1, red),
+		makeBallot("Sock1", 2, blue),
+		makeBallot("Sock2", 3, blue),
+		makeBallot("Sock3", 4, colourgo.CreateDelegation("Sock1", nil, nil)),
+	} {
+		tally.Add(b)
+	}
+	testinggo.AssertProtobufEqual(t, blue.Colour, tally.Results(&colourgo.EqualWeighter{})[origin])
+	testinggo.AssertProtobufEqual(t, red.Colour, tally.Results(colourgo.NewAllowListWeighter([]string{"Alice"}))[origin])
+}
@@ -0,0 +1,98 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ERROR_CANVAS_ID_INVALID = "Canvas ID invalid: %s"
+
+	// Separates the year from the record hash in a canvas ID
+	CANVAS_ID_SEPARATOR = "."
+
+	// Year of the first canvas channel, the earliest searched for canvases with legacy IDs
+	CANVAS_FIRST_YEAR = 2019
+)
+
+// FormatCanvasID returns the ID of the canvas created by the record with the given hash on the given year's canvas channel.
+func FormatCanvasID(year string, hash []byte) string {
+	return year + CANVAS_ID_SEPARATOR + base64.RawURLEncoding.EncodeToString(hash)
+}
+
+// ParseCanvasID returns the year and record hash of the canvas.
+// Legacy IDs are the record hash alone and have an empty year.
+func ParseCanvasID(id string) (string, []byte, error) {
+	year, encoded := "", id
+	if i := strings.Index(id, CANVAS_ID_SEPARATOR); i >= 0 {
+		year, encoded = id[:i], id[i+len(CANVAS_ID_SEPARATOR):]
+		if _, err := strconv.ParseUint(year, 10, 32); err != nil {
+			return "", nil, fmt.Errorf(ERROR_CANVAS_ID_INVALID, id)
+		}
+	}
+	hash, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(hash) == 0 {
+		return "", nil, fmt.Errorf(ERROR_CANVAS_ID_INVALID, id)
+	}
+	return year, hash, nil
+}
+
+// GetCanvasChannelNamesForID returns the names of the canvas channels which may hold the canvas' record, and the record's hash.
+// Legacy IDs don't encode their year so every year's channel from that of now back to CANVAS_FIRST_YEAR is returned, newest first.
+func GetCanvasChannelNamesForID(id string, now time.Time) ([]string, []byte, error) {
+	year, hash, err := ParseCanvasID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if year != "" {
+		return []string{GetCanvasChannelNameForYear(year)}, hash, nil
+	}
+	var names []string
+	for y := now.UTC().Year(); y >= CANVAS_FIRST_YEAR; y-- {
+		names = append(names, GetCanvasChannelNameForYear(strconv.Itoa(y)))
+	}
+	return names, hash, nil
+}
+
+// GetNextYear returns the start of the UTC year after the given time.
+func GetNextYear(t time.Time) time.Time {
+	return time.Date(t.UTC().Year()+1, time.January, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// RunYearRollover calls the callback with the new year each time the UTC year given by now changes, until the context is done.
+func RunYearRollover(ctx context.Context, now func() time.Time, callback func(string)) {
+	year := GetYearOf(now())
+	for {
+		t := now()
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(GetNextYear(t).Sub(t)):
+			// Timers may fire early if the clock is adjusted, so the year is checked again
+			if y := GetYearOf(now()); y != year {
+				year = y
+				callback(year)
+			}
+		}
+	}
+}
@@ -0,0 +1,113 @@
+/*
+ * Copyright 2020 Aletheia Ware LLC
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package colourgo_test
+
+import (
+	"bytes"
+	"context"
+	"github.com/AletheiaWareLLC/bcgo"
+	"github.com/AletheiaWareLLC/colourgo"
+	"github.com/AletheiaWareLLC/testinggo"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetYearOf(t *testing.T) {
+	// Late on 31 December in New York is already 2021 in UTC
+	ny := time.FixedZone("EST", -5*60*60)
+	if y := colourgo.GetYearOf(time.Date(2020, time.December, 31, 20, 0, 0, 0, ny)); y != "2021" {
+		t.Errorf("Expected 2021, got %s", y)
+	}
+	ts := uint64(time.Date(2020, time.December, 31, 23, 59, 59, 0, time.UTC).UnixNano())
+	if y := colourgo.GetYearOfTimestamp(ts); y != "2020" {
+		t.Errorf("Expected 2020, got %s", y)
+	}
+	record := &bcgo.Record{Timestamp: ts}
+	if n := colourgo.GetCanvasChannelNameForRecord(record); n != "Colour-Canvas-2020" {
+		t.Errorf("Expected Colour-Canvas-2020, got %s", n)
+	}
+}
+
+func TestGetNextYear(t *testing.T) {
+	next := colourgo.GetNextYear(time.Date(2020, time.June, 1, 0, 0, 0, 0, time.UTC))
+	if !next.Equal(time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected next year %v", next)
+	}
+}
+
+func TestCanvasID(t *testing.T) {
+	hash := []byte("hash")
+	id := colourgo.FormatCanvasID("2020", hash)
+	t.Run("Year", func(t *testing.T) {
+		names, h, err := colourgo.GetCanvasChannelNamesForID(id, time.Date(2021, time.June, 1, 0, 0, 0, 0, time.UTC))
+		testinggo.AssertNoError(t, err)
+		if len(names) != 1 || names[0] != "Colour-Canvas-2020" {
+			t.Errorf("Expected [Colour-Canvas-2020], got %v", names)
+		}
+		if !bytes.Equal(h, hash) {
+			t.Errorf("Expected %v, got %v", hash, h)
+		}
+	})
+	t.Run("Legacy", func(t *testing.T) {
+		year, h, err := colourgo.ParseCanvasID("aGFzaA")
+		testinggo.AssertNoError(t, err)
+		if year != "" || !bytes.Equal(h, hash) {
+			t.Errorf("Unexpected year %s hash %v", year, h)
+		}
+		// Every year is searched, not just the current one
+		names, _, err := colourgo.GetCanvasChannelNamesForID("aGFzaA", time.Date(2021, time.June, 1, 0, 0, 0, 0, time.UTC))
+		testinggo.AssertNoError(t, err)
+		expected := []string{"Colour-Canvas-2021", "Colour-Canvas-2020", "Colour-Canvas-2019"}
+		if strings.Join(names, ",") != strings.Join(expected, ",") {
+			t.Errorf("Expected %v, got %v", expected, names)
+		}
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		_, _, err := colourgo.ParseCanvasID("year.aGFzaA")
+		testinggo.AssertError(t, "Canvas ID invalid: year.aGFzaA", err)
+	})
+	t.Run("Pool", func(t *testing.T) {
+		canvasID, _, _, err := colourgo.ParsePoolID(colourgo.GetPoolID(id, &colourgo.Location{}, &colourgo.Location{X: 1}))
+		testinggo.AssertNoError(t, err)
+		if canvasID != id {
+			t.Errorf("Expected %s, got %s", id, canvasID)
+		}
+	})
+}
+
+func TestRunYearRollover(t *testing.T) {
+	end := time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+	offset := end.Sub(time.Now()) - 100*time.Millisecond
+	now := func() time.Time {
+		return time.Now().Add(offset)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	years := make(chan string, 1)
+	go colourgo.RunYearRollover(ctx, now, func(year string) {
+		years <- year
+	})
+	select {
+	case y := <-years:
+		if y != "2021" {
+			t.Errorf("Expected 2021, got %s", y)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for rollover")
+	}
+}